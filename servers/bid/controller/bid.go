@@ -3,9 +3,14 @@ package controller
 import (
 	"bid/schema"
 	"bid/utils"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"pagination"
+	"screening"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/GiveGetGo/shared/res"
@@ -57,13 +62,43 @@ func AddBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 			return
 		}
 
+		// only a post that's still up for bids takes one, and never from its owner
+		posts, err := bidUtils.GetPostSummaries([]uint{uint(postID)})
+		if err != nil {
+			log.Printf("Error fetching post %d: %v", postID, err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+		summary, ok := posts[uint(postID)]
+		if !ok {
+			res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			return
+		}
+		if summary.UserID == user.UserID {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+		if summary.Status != schema.Active {
+			res.ResponseError(c, http.StatusConflict, schema.Conflict())
+			return
+		}
+
+		screened, err := bidUtils.ScreenText(req.Description, utils.DescriptionLimit)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, schema.ContentRejected(err))
+			return
+		}
+		req.Description = screened.Text
+
 		// Create a schema.Bid object from the request
+		now := time.Now()
 		bid := schema.Bid{
 			PostID:         uint(postID),
 			UserID:         user.UserID,
 			Username:       user.Username,
 			BidDescription: req.Description,
-			DateSubmitted:  time.Now(),
+			DateSubmitted:  now,
+			DateUpdated:    now,
 			Status:         schema.Submitted, // Default status at the time of bid submission
 		}
 
@@ -75,14 +110,10 @@ func AddBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 			return
 		}
 
-		post, err := bidUtils.GetPostByPostID(c, uint(postID))
-		if err != nil {
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
-			return
-		}
+		flagForModeration(bidUtils, addedBid.BidID, screened)
 
 		// Notify the bid user
-		err = bidUtils.CreateNotification(user.UserID, types.NewBid, post)
+		err = bidUtils.SendNotification(user.UserID, types.NewBid, bidUtils.FormatNotificationDescription(summary))
 		if err != nil {
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
@@ -97,103 +128,179 @@ func AddBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 	}
 }
 
-// GetBidsForPostHandler handles requests to get all bids for a specific post
+// bidPageOptions are the sorts and page sizes of the bid lists
+var bidPageOptions = pagination.Options{
+	DefaultLimit: 25,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"date_submitted": {Column: "date_submitted", Kind: pagination.TimeField},
+	},
+	DefaultSort: "date_submitted",
+	IDColumn:    "bid_id",
+}
+
+// bidSortKey is a bid's position in a list sorted by one of bidPageOptions' sorts
+func bidSortKey(bid schema.Bid, _ string) (interface{}, uint) {
+	return bid.DateSubmitted, bid.BidID
+}
+
+// GetBidsForPostHandler lists the bids on a post. The post's owner sees every bid, anyone else
+// only the ones they placed.
 func GetBidsForPostHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		log.Println("GetBidsForPostHandler called")
-
-		// get user id from user me endpoint
 		user, err := bidUtils.GetUserInfo(c)
 		if err != nil {
 			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
 			return
 		}
 
-		postIDParam := c.Param("postid") // The key "PostID" must match the query parameter
-		postID, err := strconv.ParseUint(postIDParam, 10, 32)
+		postID, err := strconv.ParseUint(c.Param("postid"), 10, 32)
 		if err != nil {
-			log.Printf("Error converting PostID to uint: %v", err)
 			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
-		bids, err := bidUtils.GetBidBypostID(uint(postID))
+		page, err := pagination.Parse(c, bidPageOptions)
 		if err != nil {
-			log.Printf("Error fetching bids: %v", err)
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		posts, err := bidUtils.GetPostSummaries([]uint{uint(postID)})
+		if err != nil {
+			log.Printf("Error fetching post %d: %v", postID, err)
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
 		}
+		post, ok := posts[uint(postID)]
+		if !ok {
+			res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			return
+		}
 
-		//if there is no bid with the postID
-		if len(bids) == 0 {
-			res.ResponseError(c, http.StatusUnauthorized, types.InvalidRequest())
+		var bids []schema.Bid
+		if post.UserID == user.UserID {
+			bids, err = bidUtils.GetBidBypostID(post.PostID, page)
+		} else {
+			bids, err = bidUtils.GetUserBidsOnPost(post.PostID, user.UserID, page)
+		}
+		if err != nil {
+			log.Printf("Error fetching bids: %v", err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
 		}
 
-		// Convert bids to the response structure
-		var responseBids []schema.BidInfoResponse
+		bids, result := pagination.Paginate(bids, page, bidSortKey)
+
+		responseBids := []schema.BidResponse{}
 		for _, bid := range bids {
-			responseBids = append(responseBids, schema.BidInfoResponse{
-				UserID:         user.UserID,
-				Username:       user.Username,
-				BidDescription: bid.BidDescription,
-				DateSubmitted:  bid.DateSubmitted.Format(time.RFC3339),
-			})
+			responseBids = append(responseBids, toBidResponse(bid))
 		}
 
-		res.ResponseSuccessWithData(c, http.StatusOK, "Got full bid list for a post", types.Success(), responseBids)
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "Got bid list for a post", types.Success(), responseBids, result)
 	}
 }
 
-// FindBidByIDHandler retrieves a specific bid by its ID
+// FindBidByIDHandler retrieves a bid for its bidder or the owner of the post it's on
 func FindBidByIDHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		log.Println("FindBidByIDHandler called")
-
-		// get user id from user me endpoint
 		user, err := bidUtils.GetUserInfo(c)
 		if err != nil {
 			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
 			return
 		}
 
-		bidIDParam := c.Param("bidid") // The key "bidID" must match the query parameter
-		bidID, err := strconv.ParseUint(bidIDParam, 10, 32)
-		if err != nil {
-			log.Printf("Error converting Bidid to uint: %v", err)
-			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		bid, ok := findBid(c, bidUtils)
+		if !ok {
 			return
 		}
 
-		bid, err := bidUtils.GetBidBybidID(uint(bidID))
-		if err != nil {
-			log.Printf("Error fetching bid: %v", err)
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
-			return
+		if bid.UserID != user.UserID {
+			posts, err := bidUtils.GetPostSummaries([]uint{bid.PostID})
+			if err != nil {
+				log.Printf("Error fetching post %d of bid %d: %v", bid.PostID, bid.BidID, err)
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				return
+			}
+
+			// someone else's bid doesn't exist as far as the caller is concerned
+			if post, ok := posts[bid.PostID]; !ok || post.UserID != user.UserID {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+				return
+			}
 		}
 
-		// Check if the bid was found
-		if len(bid) == 0 {
-			res.ResponseError(c, http.StatusUnauthorized, types.InvalidRequest())
+		res.ResponseSuccessWithData(c, http.StatusOK, "Find a bid", types.Success(), toBidResponse(bid))
+	}
+}
+
+// GetBidHandler tells another service about a bid
+func GetBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bid, ok := findBid(c, bidUtils)
+		if !ok {
 			return
 		}
 
-		// Convert bids to the response structure
-		var responseBids []schema.BidInfoResponse
-		for _, bid := range bid {
-			responseBids = append(responseBids, schema.BidInfoResponse{
-				UserID:         user.UserID,
-				Username:       user.Username,
-				BidDescription: bid.BidDescription,
-				DateSubmitted:  bid.DateSubmitted.Format(time.RFC3339),
-			})
+		res.ResponseSuccessWithData(c, http.StatusOK, "get bid", types.Success(), toBidResponse(bid))
+	}
+}
+
+// findBid loads the bid in the :bidid parameter, writing the error response otherwise
+func findBid(c *gin.Context, bidUtils utils.IBidUtils) (schema.Bid, bool) {
+	bidID, err := strconv.ParseUint(c.Param("bidid"), 10, 32)
+	if err != nil {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return schema.Bid{}, false
+	}
+
+	bids, err := bidUtils.GetBidBybidID(uint(bidID))
+	if err != nil {
+		log.Printf("Error fetching bid: %v", err)
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return schema.Bid{}, false
+	}
+	if len(bids) == 0 {
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+		return schema.Bid{}, false
+	}
+
+	return bids[0], true
+}
+
+func toBidResponse(bid schema.Bid) schema.BidResponse {
+	return schema.BidResponse{
+		BidID:         bid.BidID,
+		PostID:        bid.PostID,
+		UserID:        bid.UserID,
+		Username:      bid.Username,
+		Description:   bid.BidDescription,
+		Status:        bid.Status,
+		DateSubmitted: bid.DateSubmitted,
+		DateUpdated:   bid.DateUpdated,
+	}
+}
+
+// AcceptBidHandler marks a bid accepted on behalf of the match service once its post was matched with it,
+// the other open bids on the post are rejected
+func AcceptBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bid, ok := findBid(c, bidUtils)
+		if !ok {
+			return
 		}
 
-		res.ResponseSuccessWithData(c, http.StatusOK, "Find a bid", types.Success(), responseBids)
+		if err := bidUtils.AcceptBid(bid.BidID, bid.PostID); err != nil {
+			bidUpdateError(c, err)
+			return
+		}
 
+		bid.Status = schema.Accepted
+		res.ResponseSuccessWithData(c, http.StatusOK, "accept bid", types.Success(), toBidResponse(bid))
 	}
 }
 
+// DeleteBidHandler removes a bid for good on behalf of another service, e.g. when the moderators take it down
 func DeleteBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log.Println("DeleteBidHandler called")
@@ -202,7 +309,7 @@ func DeleteBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 		bidIDParam := c.Param("bidid")
 		bidID, err := strconv.ParseUint(bidIDParam, 10, 32)
 		if err != nil {
-			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
@@ -216,32 +323,49 @@ func DeleteBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 			return
 		}
 
-		log.Println("Post deleted successfully")
+		log.Println("Bid deleted successfully")
 		// Return the success response with deletion confirmation
 		res.ResponseSuccess(c, http.StatusOK, "delete bid", types.Success())
 	}
 }
 
+// WithdrawBidHandler lets the bidder take back a bid that hasn't been answered. The bid is kept
+// as withdrawn so the post's owner sees it happened, and they're told about it.
+func WithdrawBidHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bid, user, ok := ownedBid(c, bidUtils)
+		if !ok {
+			return
+		}
+
+		if err := bidUtils.WithdrawBid(bid.BidID); err != nil {
+			bidUpdateError(c, err)
+			return
+		}
+
+		posts, err := bidUtils.GetPostSummaries([]uint{bid.PostID})
+		if err != nil {
+			log.Printf("Error fetching post %d to notify its owner of withdrawn bid %d: %v", bid.PostID, bid.BidID, err)
+		} else if post, ok := posts[bid.PostID]; ok {
+			description := fmt.Sprintf("%s withdrew their bid on \"%s\".", user.Username, post.Title)
+			if err := bidUtils.SendNotification(post.UserID, utils.BidWithdrawnNotification, description); err != nil {
+				log.Printf("Error notifying user %d of withdrawn bid %d: %v", post.UserID, bid.BidID, err)
+			}
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "withdraw bid", types.Success())
+	}
+}
+
 type UpdateBidDescriptionRequest struct {
 	Description string `json:"Description"`
 }
 
+// UpdateBidDescriptionHandler lets the bidder reword a bid that hasn't been answered
 func UpdateBidDescriptionHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log.Println("UpdateBidDescriptionHandler called")
 
-		// Extract bid ID from parameter
-		bidIDParam := c.Param("bidid")
-		bidID, err := strconv.ParseUint(bidIDParam, 10, 32)
-		if err != nil {
-			log.Printf("Error converting bidid to uint: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   true,
-				"message": "Invalid request parameter: bidid must be an integer.",
-			})
-			return
-		}
-
 		// Bind JSON from the request body to UpdateBidDescriptionRequest struct
 		var updateReq UpdateBidDescriptionRequest
 		if err := c.BindJSON(&updateReq); err != nil {
@@ -250,15 +374,171 @@ func UpdateBidDescriptionHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
 			return
 		}
 
+		bid, _, ok := ownedBid(c, bidUtils)
+		if !ok {
+			return
+		}
+
+		screened, err := bidUtils.ScreenText(updateReq.Description, utils.DescriptionLimit)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, schema.ContentRejected(err))
+			return
+		}
+		updateReq.Description = screened.Text
+
 		// Update the bid description using the bid utilities
-		err = bidUtils.UpdateBidDescription(uint(bidID), updateReq.Description)
+		err = bidUtils.UpdateBidDescription(bid.BidID, updateReq.Description)
 		if err != nil {
-			log.Printf("Error updating bid description: %v", err)
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			bidUpdateError(c, err)
 			return
 		}
 
+		flagForModeration(bidUtils, bid.BidID, screened)
+
 		res.ResponseSuccessWithData(c, http.StatusOK, "update bid", types.Success(), updateReq.Description)
 
 	}
 }
+
+// ownedBid loads the bid in the :bidid parameter and checks the caller placed it, writing the error response otherwise
+func ownedBid(c *gin.Context, bidUtils utils.IBidUtils) (schema.Bid, types.UserInfoResponse, bool) {
+	user, err := bidUtils.GetUserInfo(c)
+	if err != nil {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return schema.Bid{}, types.UserInfoResponse{}, false
+	}
+
+	bid, ok := findBid(c, bidUtils)
+	if !ok {
+		return schema.Bid{}, types.UserInfoResponse{}, false
+	}
+
+	if bid.UserID != user.UserID {
+		res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+		return schema.Bid{}, types.UserInfoResponse{}, false
+	}
+
+	return bid, user, true
+}
+
+func bidUpdateError(c *gin.Context, err error) {
+	if errors.Is(err, utils.ErrBidNotEditable) {
+		res.ResponseError(c, http.StatusConflict, schema.Conflict())
+		return
+	}
+	log.Printf("Error updating bid: %v", err)
+	res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+}
+
+// GetPostBiddersHandler tells another service who is still waiting for an answer on their bid for a post
+func GetPostBiddersHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, err := strconv.ParseUint(c.Param("postid"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		userIDs, err := bidUtils.GetOpenBidderIDs(uint(postID))
+		if err != nil {
+			log.Printf("Error fetching bidders: %v", err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get post bidders", types.Success(), schema.PostBiddersResponse{
+			PostID:  uint(postID),
+			UserIDs: userIDs,
+		})
+	}
+}
+
+// maxBidCountPosts is how many posts one bid count lookup may ask for
+const maxBidCountPosts = 500
+
+// GetBidCountsHandler tells another service how many live bids each post in ?post_ids= has
+func GetBidCountsHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var postIDs []uint
+		for _, value := range strings.Split(c.Query("post_ids"), ",") {
+			if value == "" {
+				continue
+			}
+			postID, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+			postIDs = append(postIDs, uint(postID))
+		}
+		if len(postIDs) > maxBidCountPosts {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		counts, err := bidUtils.CountBidsByPost(postIDs)
+		if err != nil {
+			log.Printf("Error counting bids: %v", err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get bid counts", types.Success(), schema.BidCountsResponse{Counts: counts})
+	}
+}
+
+// GetUserBidPostsHandler tells another service which posts a user has bid on
+func GetUserBidPostsHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseUint(c.Param("userid"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		postIDs, err := bidUtils.GetBidPostIDs(uint(userID))
+		if err != nil {
+			log.Printf("Error fetching the posts user %d bid on: %v", userID, err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get user bid posts", types.Success(), schema.UserBidPostsResponse{
+			UserID:  uint(userID),
+			PostIDs: postIDs,
+		})
+	}
+}
+
+// GetPostBidDatesHandler tells another service when each bid on a post was submitted
+func GetPostBidDatesHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, err := strconv.ParseUint(c.Param("postid"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		dates, err := bidUtils.GetBidDates(uint(postID))
+		if err != nil {
+			log.Printf("Error fetching the bid dates of post %d: %v", postID, err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get post bid dates", types.Success(), schema.PostBidDatesResponse{
+			PostID:    uint(postID),
+			Submitted: dates,
+		})
+	}
+}
+
+// flagForModeration asks the moderators to look at a saved bid the screening flagged
+func flagForModeration(bidUtils utils.IBidUtils, bidID uint, screened screening.Result) {
+	if !screened.Flagged {
+		return
+	}
+	if err := bidUtils.FlagBid(bidID, screened.Types(screening.Flag)); err != nil {
+		log.Printf("Error flagging bid %d for moderation: %v", bidID, err)
+	}
+}
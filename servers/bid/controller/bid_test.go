@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"bid/schema"
+	"bid/utils"
+	"errors"
+	"net/http"
+	"screening"
+	"testing"
+
+	"github.com/GiveGetGo/shared/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnedBid(t *testing.T) {
+	t.Run("signed out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(types.UserInfoResponse{}, errors.New("no session"))
+
+		c, w := newTestContext(http.MethodPut, "", bidParam("12"))
+		_, _, ok := ownedBid(c, bidUtils)
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("bad bid id", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+
+		c, w := newTestContext(http.MethodPut, "", bidParam("abc"))
+		_, _, ok := ownedBid(c, bidUtils)
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing bid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return(nil, nil)
+
+		c, w := newTestContext(http.MethodPut, "", bidParam("12"))
+		_, _, ok := ownedBid(c, bidUtils)
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("someone else's bid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(ownerID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+
+		c, w := newTestContext(http.MethodPut, "", bidParam("12"))
+		_, _, ok := ownedBid(c, bidUtils)
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("bidder", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+
+		c, _ := newTestContext(http.MethodPut, "", bidParam("12"))
+		bid, user, ok := ownedBid(c, bidUtils)
+		assert.True(t, ok)
+		assert.Equal(t, testBid, bid)
+		assert.Equal(t, bidderID, user.UserID)
+	})
+}
+
+func TestFindBidByIDHandler(t *testing.T) {
+	t.Run("bidder sees their bid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+
+		c, w := newTestContext(http.MethodGet, "", bidParam("12"))
+		FindBidByIDHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var bid schema.BidResponse
+		responseData(t, w, &bid)
+		assert.Equal(t, bidderID, bid.UserID)
+		assert.Equal(t, "ari", bid.Username)
+	})
+
+	t.Run("post owner sees the bids on their post", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(ownerID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+
+		c, w := newTestContext(http.MethodGet, "", bidParam("12"))
+		FindBidByIDHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("anyone else gets not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(otherID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+
+		c, w := newTestContext(http.MethodGet, "", bidParam("12"))
+		FindBidByIDHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("bid on a post that's gone", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(ownerID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{}, nil)
+
+		c, w := newTestContext(http.MethodGet, "", bidParam("12"))
+		FindBidByIDHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetBidsForPostHandler(t *testing.T) {
+	otherBid := schema.Bid{BidID: 13, PostID: 5, UserID: otherID, Status: schema.Submitted}
+
+	t.Run("owner lists every bid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(ownerID), nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+		bidUtils.EXPECT().GetBidBypostID(uint(5), gomock.Any()).Return([]schema.Bid{testBid, otherBid}, nil)
+
+		c, w := newTestContext(http.MethodGet, "", postParam("5"))
+		GetBidsForPostHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var bids []schema.BidResponse
+		responseData(t, w, &bids)
+		assert.Len(t, bids, 2)
+	})
+
+	t.Run("bidder lists only their own", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+		bidUtils.EXPECT().GetUserBidsOnPost(uint(5), bidderID, gomock.Any()).Return([]schema.Bid{testBid}, nil)
+
+		c, w := newTestContext(http.MethodGet, "", postParam("5"))
+		GetBidsForPostHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var bids []schema.BidResponse
+		responseData(t, w, &bids)
+		assert.Len(t, bids, 1)
+		assert.Equal(t, uint(12), bids[0].BidID)
+	})
+
+	t.Run("missing post", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(ownerID), nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{}, nil)
+
+		c, w := newTestContext(http.MethodGet, "", postParam("5"))
+		GetBidsForPostHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAddBidHandler(t *testing.T) {
+	body := `{"description": "I can help"}`
+
+	tests := []struct {
+		name   string
+		userID uint
+		posts  map[uint]schema.PostSummary
+		want   int
+	}{
+		{"missing post", bidderID, map[uint]schema.PostSummary{}, http.StatusNotFound},
+		{"own post", ownerID, map[uint]schema.PostSummary{5: testPost}, http.StatusForbidden},
+		{"post no longer active", bidderID, map[uint]schema.PostSummary{5: {PostID: 5, UserID: ownerID, Status: schema.Matched}}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the bid is never saved, the mock fails on an unexpected AddBid
+			ctrl := gomock.NewController(t)
+			bidUtils := utils.NewMockIBidUtils(ctrl)
+			bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(tt.userID), nil)
+			bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(tt.posts, nil)
+
+			c, w := newTestContext(http.MethodPost, body, postParam("5"))
+			AddBidHandler(bidUtils)(c)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestAddBidHandlerNotifiesBidder(t *testing.T) {
+	// the bidder is told about the bid from the post's summary, the post itself isn't fetched again
+	ctrl := gomock.NewController(t)
+	bidUtils := utils.NewMockIBidUtils(ctrl)
+	bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+	bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+	bidUtils.EXPECT().ScreenText("I can help", utils.DescriptionLimit).Return(screening.Result{Text: "I can help"}, nil)
+	bidUtils.EXPECT().AddBid(gomock.Any()).Return(testBid, nil)
+	bidUtils.EXPECT().FormatNotificationDescription(testPost).Return(`You asked sam for "Desk lamp".`)
+	bidUtils.EXPECT().SendNotification(bidderID, types.NewBid, `You asked sam for "Desk lamp".`).Return(nil)
+
+	c, w := newTestContext(http.MethodPost, `{"description": "I can help"}`, postParam("5"))
+	AddBidHandler(bidUtils)(c)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestWithdrawBidHandler(t *testing.T) {
+	t.Run("withdrawn and the owner is told", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().WithdrawBid(uint(12)).Return(nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+		bidUtils.EXPECT().SendNotification(ownerID, utils.BidWithdrawnNotification, gomock.Any()).Return(nil)
+
+		c, w := newTestContext(http.MethodDelete, "", bidParam("12"))
+		WithdrawBidHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("only the bidder", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(ownerID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+
+		c, w := newTestContext(http.MethodDelete, "", bidParam("12"))
+		WithdrawBidHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("already answered", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().WithdrawBid(uint(12)).Return(utils.ErrBidNotEditable)
+
+		c, w := newTestContext(http.MethodDelete, "", bidParam("12"))
+		WithdrawBidHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestUpdateBidDescriptionHandler(t *testing.T) {
+	body := `{"Description": "I can help tomorrow"}`
+
+	t.Run("only the bidder", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(otherID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+
+		c, w := newTestContext(http.MethodPut, body, bidParam("12"))
+		UpdateBidDescriptionHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("already answered", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().ScreenText("I can help tomorrow", utils.DescriptionLimit).Return(screening.Result{Text: "I can help tomorrow"}, nil)
+		bidUtils.EXPECT().UpdateBidDescription(uint(12), "I can help tomorrow").Return(utils.ErrBidNotEditable)
+
+		c, w := newTestContext(http.MethodPut, body, bidParam("12"))
+		UpdateBidDescriptionHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestAcceptBidHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bidUtils := utils.NewMockIBidUtils(ctrl)
+	bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+	bidUtils.EXPECT().AcceptBid(uint(12), uint(5)).Return(nil)
+
+	c, w := newTestContext(http.MethodPut, "", bidParam("12"))
+	AcceptBidHandler(bidUtils)(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var bid schema.BidResponse
+	responseData(t, w, &bid)
+	assert.Equal(t, schema.Accepted, bid.Status)
+}
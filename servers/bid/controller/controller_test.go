@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"bid/schema"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	bidderID = uint(3)
+	ownerID  = uint(7)
+	otherID  = uint(9)
+)
+
+var (
+	testBid  = schema.Bid{BidID: 12, PostID: 5, UserID: bidderID, Username: "ari", BidDescription: "I can help", Status: schema.Submitted}
+	testPost = schema.PostSummary{PostID: 5, UserID: ownerID, Username: "sam", Title: "Desk lamp", Type: schema.OfferPost, Status: schema.Active}
+)
+
+// newTestContext builds a request context with the route parameters already matched
+func newTestContext(method string, body string, params ...gin.Param) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	return c, w
+}
+
+func bidParam(value string) gin.Param {
+	return gin.Param{Key: "bidid", Value: value}
+}
+
+func postParam(value string) gin.Param {
+	return gin.Param{Key: "postid", Value: value}
+}
+
+func asUser(userID uint) types.UserInfoResponse {
+	return types.UserInfoResponse{UserID: userID, Username: "caller"}
+}
+
+// responseData decodes the data of a shared response into dest
+func responseData(t *testing.T, w *httptest.ResponseRecorder, dest interface{}) {
+	var response types.FullResponseWithData
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data, err := json.Marshal(response.Data)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, dest))
+}
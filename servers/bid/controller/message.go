@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bid/schema"
+	"bid/utils"
+	"pagination"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+)
+
+// messagePageOptions page a bid's thread, oldest first by default so it reads like a conversation
+var messagePageOptions = pagination.Options{
+	DefaultLimit: 50,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"date_sent": {Column: "date_sent", Kind: pagination.TimeField},
+	},
+	DefaultSort: "date_sent",
+	IDColumn:    "message_id",
+}
+
+// messageSortKey is a message's position in a list sorted by one of messagePageOptions' sorts
+func messageSortKey(message schema.BidMessage, _ string) (interface{}, uint) {
+	return message.DateSent, message.MessageID
+}
+
+// bidThread is a bid along with its post and the caller, who is the bidder or the post's owner
+type bidThread struct {
+	Bid  schema.Bid
+	Post schema.PostSummary
+	User types.UserInfoResponse
+}
+
+// otherSide is the participant the caller is talking to
+func (t bidThread) otherSide() uint {
+	if t.User.UserID == t.Bid.UserID {
+		return t.Post.UserID
+	}
+	return t.Bid.UserID
+}
+
+// SendBidMessageHandler sends a message to the other side of a bid
+func SendBidMessageHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req schema.BidMessageRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		thread, ok := loadBidThread(c, bidUtils)
+		if !ok {
+			return
+		}
+
+		if !utils.ThreadOpen(thread.Bid, thread.Post) {
+			res.ResponseError(c, http.StatusConflict, schema.Conflict())
+			return
+		}
+
+		// messages only reach the other side of the bid, so flagged text isn't queued for the
+		// moderators like a bid's description is, blocked text is still refused
+		screened, err := bidUtils.ScreenText(req.Body, utils.MessageLimit)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, schema.ContentRejected(err))
+			return
+		}
+
+		message, err := bidUtils.AddBidMessage(schema.BidMessage{
+			BidID:    thread.Bid.BidID,
+			SenderID: thread.User.UserID,
+			Body:     screened.Text,
+			DateSent: time.Now(),
+		})
+		if err != nil {
+			log.Printf("Error saving a message on bid %d: %v", thread.Bid.BidID, err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		// the message is saved either way, a missed notification shows up as unread in the thread
+		recipientID := thread.otherSide()
+		description := fmt.Sprintf("%s sent you a message about \"%s\".", thread.User.Username, thread.Post.Title)
+		if err := bidUtils.SendNotification(recipientID, utils.BidMessageNotification, description); err != nil {
+			log.Printf("Error notifying user %d about a message on bid %d: %v", recipientID, thread.Bid.BidID, err)
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusCreated, "send bid message", types.Success(), toMessageResponse(message))
+	}
+}
+
+// GetBidMessagesHandler lists a page of a bid's thread
+func GetBidMessagesHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		thread, ok := loadBidThread(c, bidUtils)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.Parse(c, messagePageOptions)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		messages, err := bidUtils.GetBidMessages(thread.Bid.BidID, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		messages, result := pagination.Paginate(messages, page, messageSortKey)
+
+		responseMessages := []schema.BidMessageResponse{}
+		for _, message := range messages {
+			responseMessages = append(responseMessages, toMessageResponse(message))
+		}
+
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "get bid messages", types.Success(), responseMessages, result)
+	}
+}
+
+// MarkBidMessagesReadHandler marks every message the caller got on a bid as read
+func MarkBidMessagesReadHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		thread, ok := loadBidThread(c, bidUtils)
+		if !ok {
+			return
+		}
+
+		read, err := bidUtils.MarkBidMessagesRead(thread.Bid.BidID, thread.User.UserID, time.Now())
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "read bid messages", types.Success(), schema.BidMessagesReadResponse{
+			BidID: thread.Bid.BidID,
+			Read:  read,
+		})
+	}
+}
+
+// loadBidThread loads the bid in the :bidid parameter and its post, and checks the caller is the
+// bidder or the post's owner, writing the error response otherwise
+func loadBidThread(c *gin.Context, bidUtils utils.IBidUtils) (bidThread, bool) {
+	bidID, err := strconv.ParseUint(c.Param("bidid"), 10, 32)
+	if err != nil {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return bidThread{}, false
+	}
+
+	user, err := bidUtils.GetUserInfo(c)
+	if err != nil {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return bidThread{}, false
+	}
+
+	bids, err := bidUtils.GetBidBybidID(uint(bidID))
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return bidThread{}, false
+	}
+	if len(bids) == 0 {
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+		return bidThread{}, false
+	}
+	bid := bids[0]
+
+	posts, err := bidUtils.GetPostSummaries([]uint{bid.PostID})
+	if err != nil {
+		log.Printf("Error fetching post %d of bid %d: %v", bid.PostID, bid.BidID, err)
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return bidThread{}, false
+	}
+	// a post that's gone leaves the bidder alone with a closed thread
+	post := posts[bid.PostID]
+
+	if user.UserID != bid.UserID && user.UserID != post.UserID {
+		res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+		return bidThread{}, false
+	}
+
+	return bidThread{Bid: bid, Post: post, User: user}, true
+}
+
+func toMessageResponse(message schema.BidMessage) schema.BidMessageResponse {
+	return schema.BidMessageResponse{
+		MessageID: message.MessageID,
+		BidID:     message.BidID,
+		SenderID:  message.SenderID,
+		Body:      message.Body,
+		DateSent:  message.DateSent,
+		ReadAt:    message.ReadAt,
+	}
+}
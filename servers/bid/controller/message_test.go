@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"bid/schema"
+	"bid/utils"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBidThread(t *testing.T) {
+	t.Run("bidder talks to the post's owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+
+		c, _ := newTestContext(http.MethodGet, "", bidParam("12"))
+		thread, ok := loadBidThread(c, bidUtils)
+		assert.True(t, ok)
+		assert.Equal(t, ownerID, thread.otherSide())
+	})
+
+	t.Run("post's owner talks to the bidder", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(ownerID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+
+		c, _ := newTestContext(http.MethodGet, "", bidParam("12"))
+		thread, ok := loadBidThread(c, bidUtils)
+		assert.True(t, ok)
+		assert.Equal(t, bidderID, thread.otherSide())
+	})
+
+	t.Run("anyone else is forbidden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(otherID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{testBid}, nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+
+		c, w := newTestContext(http.MethodGet, "", bidParam("12"))
+		_, ok := loadBidThread(c, bidUtils)
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("missing bid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetBidBybidID(uint(12)).Return(nil, nil)
+
+		c, w := newTestContext(http.MethodGet, "", bidParam("12"))
+		_, ok := loadBidThread(c, bidUtils)
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSendBidMessageHandlerClosedThread(t *testing.T) {
+	withdrawn := testBid
+	withdrawn.Status = schema.Withdrawn
+
+	ctrl := gomock.NewController(t)
+	bidUtils := utils.NewMockIBidUtils(ctrl)
+	bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(ownerID), nil)
+	bidUtils.EXPECT().GetBidBybidID(uint(12)).Return([]schema.Bid{withdrawn}, nil)
+	bidUtils.EXPECT().GetPostSummaries([]uint{5}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+
+	c, w := newTestContext(http.MethodPost, `{"body": "still there?"}`, bidParam("12"))
+	SendBidMessageHandler(bidUtils)(c)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
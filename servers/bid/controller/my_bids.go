@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"bid/schema"
+	"bid/utils"
+	"pagination"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+)
+
+// myBidPageOptions page the caller's own bids, the newest first by default
+var myBidPageOptions = pagination.Options{
+	DefaultLimit: 25,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"date_submitted": {Column: "date_submitted", Kind: pagination.TimeField},
+		"date_updated":   {Column: "date_updated", Kind: pagination.TimeField},
+	},
+	DefaultSort: "-date_submitted",
+	IDColumn:    "bid_id",
+}
+
+// myBidSortKey is a bid's position in a list sorted by one of myBidPageOptions' sorts
+func myBidSortKey(bid schema.Bid, sort string) (interface{}, uint) {
+	if sort == "date_updated" {
+		return bid.DateUpdated, bid.BidID
+	}
+	return bid.DateSubmitted, bid.BidID
+}
+
+// GetMyBidsHandler lists the caller's bids across posts with their posts' titles and statuses,
+// optionally only the bids in ?status=, along with a count of all their bids by status
+func GetMyBidsHandler(bidUtils utils.IBidUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := bidUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		status := schema.BidStatus(c.Query("status"))
+		if status != "" && !utils.ValidBidStatus(status) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		page, err := pagination.Parse(c, myBidPageOptions)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		bids, err := bidUtils.GetUserBids(user.UserID, status, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+		bids, result := pagination.Paginate(bids, page, myBidSortKey)
+
+		summary, err := bidUtils.CountUserBidsByStatus(user.UserID)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		postIDs := make([]uint, 0, len(bids))
+		for _, bid := range bids {
+			postIDs = append(postIDs, bid.PostID)
+		}
+		posts, err := bidUtils.GetPostSummaries(postIDs)
+		if err != nil {
+			log.Printf("Error fetching the posts of user %d's bids: %v", user.UserID, err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		responseBids := []schema.MyBidResponse{}
+		for _, bid := range bids {
+			post := posts[bid.PostID]
+			responseBids = append(responseBids, schema.MyBidResponse{
+				BidResponse: toBidResponse(bid),
+				PostTitle:   post.Title,
+				PostStatus:  post.Status,
+			})
+		}
+
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "get my bids", types.Success(), schema.MyBidsResponse{
+			Bids:    responseBids,
+			Summary: summary,
+		}, result)
+	}
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"bid/schema"
+	"bid/utils"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMyBidsHandler(t *testing.T) {
+	t.Run("bids with their posts and the summary", func(t *testing.T) {
+		withdrawn := schema.Bid{BidID: 14, PostID: 6, UserID: bidderID, Status: schema.Withdrawn}
+		summary := map[schema.BidStatus]int64{schema.Submitted: 1, schema.Accepted: 0, schema.Rejected: 0, schema.Withdrawn: 1}
+
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetUserBids(bidderID, schema.BidStatus(""), gomock.Any()).Return([]schema.Bid{testBid, withdrawn}, nil)
+		bidUtils.EXPECT().CountUserBidsByStatus(bidderID).Return(summary, nil)
+		// the second post is gone
+		bidUtils.EXPECT().GetPostSummaries([]uint{5, 6}).Return(map[uint]schema.PostSummary{5: testPost}, nil)
+
+		c, w := newTestContext(http.MethodGet, "")
+		GetMyBidsHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response schema.MyBidsResponse
+		responseData(t, w, &response)
+		assert.Equal(t, summary, response.Summary)
+		assert.Len(t, response.Bids, 2)
+		assert.Equal(t, "Desk lamp", response.Bids[0].PostTitle)
+		assert.Equal(t, schema.Active, response.Bids[0].PostStatus)
+		assert.Empty(t, response.Bids[1].PostTitle)
+	})
+
+	t.Run("filtered by status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+		bidUtils.EXPECT().GetUserBids(bidderID, schema.Accepted, gomock.Any()).Return(nil, nil)
+		bidUtils.EXPECT().CountUserBidsByStatus(bidderID).Return(map[schema.BidStatus]int64{}, nil)
+		bidUtils.EXPECT().GetPostSummaries([]uint{}).Return(map[uint]schema.PostSummary{}, nil)
+
+		c, w := newTestContext(http.MethodGet, "")
+		c.Request.URL.RawQuery = "status=Accepted"
+		GetMyBidsHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		bidUtils := utils.NewMockIBidUtils(ctrl)
+		bidUtils.EXPECT().GetUserInfo(gomock.Any()).Return(asUser(bidderID), nil)
+
+		c, w := newTestContext(http.MethodGet, "")
+		c.Request.URL.RawQuery = "status=Pending"
+		GetMyBidsHandler(bidUtils)(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
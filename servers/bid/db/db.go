@@ -2,6 +2,7 @@ package db
 
 import (
 	"bid/schema"
+	"database/sql"
 	"log"
 	"os"
 
@@ -16,6 +17,7 @@ type Database interface {
 	First(dest interface{}, conds ...interface{}) *gorm.DB
 	Delete(value interface{}, conds ...interface{}) *gorm.DB
 	Save(value interface{}) *gorm.DB
+	Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error
 }
 
 // Ensure that *gorm.DB satisfies the Database interface
@@ -47,7 +49,7 @@ func ConnectPostgresDB() *gorm.DB {
 // AutoMigratePostgresDB migrates the database schema
 func AutoMigratePostgresDB(db *gorm.DB) error {
 	// Migrate the schema
-	err := db.AutoMigrate(&schema.Bid{})
+	err := db.AutoMigrate(&schema.Bid{}, &schema.BidMessage{})
 	if err != nil {
 		log.Fatalf("Error migrating PostgreSQL schema: %v", err)
 		return err
@@ -3,12 +3,17 @@ module bid
 go 1.22.0
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/GiveGetGo/shared v0.2.18
 	github.com/gin-gonic/gin v1.9.1
+	github.com/golang/mock v1.6.0
 	github.com/redis/go-redis/v9 v9.5.1
+	github.com/stretchr/testify v1.9.0
 	github.com/ulule/limiter/v3 v3.11.2
 	gorm.io/driver/postgres v1.5.7
 	gorm.io/gorm v1.25.10
+	pagination v0.0.0
+	screening v0.0.0
 )
 
 require (
@@ -48,3 +53,8 @@ require (
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace (
+	pagination => ../pagination
+	screening => ../screening
+)
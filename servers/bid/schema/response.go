@@ -0,0 +1,34 @@
+package schema
+
+import "github.com/GiveGetGo/shared/types"
+
+// not part of the shared response codes yet
+const (
+	ContentRejectedCode = "40005"
+	ForbiddenCode       = "40301"
+	ConflictCode        = "40902"
+)
+
+// func ContentRejected(reason error) Response - the text failed screening, the message says why
+func ContentRejected(reason error) types.Response {
+	return types.Response{
+		Code: ContentRejectedCode,
+		Msg:  "Content rejected: " + reason.Error(),
+	}
+}
+
+// func Forbidden() Response
+func Forbidden() types.Response {
+	return types.Response{
+		Code: ForbiddenCode,
+		Msg:  "Forbidden",
+	}
+}
+
+// func Conflict() Response
+func Conflict() types.Response {
+	return types.Response{
+		Code: ConflictCode,
+		Msg:  "Conflict",
+	}
+}
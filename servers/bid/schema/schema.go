@@ -8,6 +8,14 @@ const (
 	Submitted BidStatus = "Submitted"
 	Accepted  BidStatus = "Accepted"
 	Rejected  BidStatus = "Rejected"
+	Withdrawn BidStatus = "Withdrawn" // the bidder took it back, kept so the post's owner sees it happened
+)
+
+type PostType string
+
+const (
+	RequestPost PostType = "request"
+	OfferPost   PostType = "offer"
 )
 
 type PostStatus string
@@ -26,14 +34,87 @@ type Bid struct {
 	Username       string
 	BidDescription string
 	DateSubmitted  time.Time
+	DateUpdated    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
 	Status         BidStatus
 }
 
-type BidInfoResponse struct {
-	UserID         uint   `json:"userID"`
-	Username       string `json:"username"`
-	BidDescription string `json:"BidDescription"`
-	DateSubmitted  string `json:"DateSubmitted"`
+// MyBidResponse is one of the caller's bids with where its post stands, the post fields are
+// empty when the post is gone
+type MyBidResponse struct {
+	BidResponse
+	PostTitle  string     `json:"post_title"`
+	PostStatus PostStatus `json:"post_status,omitempty"`
+}
+
+// MyBidsResponse is a page of the caller's bids with how many of all their bids are in each status
+type MyBidsResponse struct {
+	Bids    []MyBidResponse     `json:"bids"`
+	Summary map[BidStatus]int64 `json:"summary"`
+}
+
+// BidMessage is one message in the thread between a post's owner and a bidder
+type BidMessage struct {
+	MessageID uint `gorm:"primaryKey"`
+	BidID     uint `gorm:"index"`
+	SenderID  uint
+	Body      string
+	DateSent  time.Time
+	ReadAt    *time.Time // when the other side read it, nil while unread
+}
+
+// BidMessageRequest - request body for sending a message on a bid
+type BidMessageRequest struct {
+	Body string `json:"body"`
+}
+
+type BidMessageResponse struct {
+	MessageID uint       `json:"messageID"`
+	BidID     uint       `json:"bidID"`
+	SenderID  uint       `json:"senderID"`
+	Body      string     `json:"body"`
+	DateSent  time.Time  `json:"date_sent"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+// BidMessagesReadResponse is how many messages marking a thread read changed
+type BidMessagesReadResponse struct {
+	BidID uint  `json:"bidID"`
+	Read  int64 `json:"read"`
+}
+
+// BidResponse is a bid as its bidder and the post's owner see it
+type BidResponse struct {
+	BidID         uint      `json:"bidID"`
+	PostID        uint      `json:"postID"`
+	UserID        uint      `json:"userID"`
+	Username      string    `json:"username"`
+	Description   string    `json:"description"`
+	Status        BidStatus `json:"status"`
+	DateSubmitted time.Time `json:"date_submitted"`
+	DateUpdated   time.Time `json:"date_updated"`
+}
+
+// PostBiddersResponse lists the users with an open bid on a post
+type PostBiddersResponse struct {
+	PostID  uint   `json:"postID"`
+	UserIDs []uint `json:"userIDs"`
+}
+
+// BidCountsResponse is the number of live bids on each post, posts without one are left out
+type BidCountsResponse struct {
+	Counts map[uint]int64 `json:"counts"`
+}
+
+// PostBidDatesResponse lists when each bid on a post was submitted, oldest first
+type PostBidDatesResponse struct {
+	PostID    uint        `json:"postID"`
+	Submitted []time.Time `json:"submitted"`
+}
+
+// UserBidPostsResponse lists the posts a user has bid on
+type UserBidPostsResponse struct {
+	UserID  uint   `json:"userID"`
+	PostIDs []uint `json:"postIDs"`
 }
 
 type PostResponse struct {
@@ -41,7 +122,31 @@ type PostResponse struct {
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
 	Category    string     `json:"category"`
+	Type        PostType   `json:"type"`
+	Quantity    int        `json:"quantity,omitempty"`
+	NeededBy    *time.Time `json:"needed_by,omitempty"`
 	Username    string     `json:"username"`
 	DatePosted  time.Time  `json:"date_posted"`
 	Status      PostStatus `json:"status"`
 }
+
+// PostSummariesResponse is the post service's owner and status of the posts asked about
+type PostSummariesResponse struct {
+	Posts []PostSummary `json:"posts"`
+}
+
+type PostSummary struct {
+	PostID   uint       `json:"postID"`
+	UserID   uint       `json:"userID"`
+	Username string     `json:"username"`
+	Title    string     `json:"title"`
+	Type     PostType   `json:"type"`
+	Status   PostStatus `json:"status"`
+}
+
+// FlaggedContentRequest asks the post service to queue a bid the screening flagged for the moderators
+type FlaggedContentRequest struct {
+	TargetType string   `json:"target_type"`
+	TargetID   uint     `json:"targetID"`
+	Matches    []string `json:"matches"`
+}
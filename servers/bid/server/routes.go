@@ -26,24 +26,59 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 		bidGroup.GET("/health", sharedController.HealthCheckHandler())
 	}
 
-	bidAuthGroup := r.Group("/v1")
+	bidAuthGroup := r.Group("/v1/bid")
 	bidAuthGroup.Use(defaultRateLimiter)
 	bidAuthGroup.Use(middleware.AuthMiddleware())
 	{
 		defaultBidAuthGroup := bidAuthGroup.Group("")
 		{
-			defaultBidAuthGroup.GET("/by-post/:postid", controller.GetBidsForPostHandler(bidUtils))
+			defaultBidAuthGroup.GET("/mine", controller.GetMyBidsHandler(bidUtils))
+			defaultBidAuthGroup.GET("/post/:postid", controller.GetBidsForPostHandler(bidUtils))
 			defaultBidAuthGroup.GET("/:bidid", controller.FindBidByIDHandler(bidUtils))
+			defaultBidAuthGroup.GET("/:bidid/messages", controller.GetBidMessagesHandler(bidUtils))
+			defaultBidAuthGroup.PUT("/:bidid/messages/read", controller.MarkBidMessagesReadHandler(bidUtils))
 		}
 		sensitiveBidAuthGroup := bidAuthGroup.Group("")
 		sensitiveBidAuthGroup.Use(sensitiveRateLimiter)
 		{
-			sensitiveBidAuthGroup.POST("/bid", controller.AddBidHandler(bidUtils))
-			sensitiveBidAuthGroup.POST("/by-post/:postid", controller.AddBidHandler(bidUtils))
-			sensitiveBidAuthGroup.DELETE("/:bidid", controller.DeleteBidHandler(bidUtils))
+			sensitiveBidAuthGroup.POST("/post/:postid", controller.AddBidHandler(bidUtils))
+			sensitiveBidAuthGroup.DELETE("/:bidid", controller.WithdrawBidHandler(bidUtils))
 			sensitiveBidAuthGroup.PUT("/:bidid", controller.UpdateBidDescriptionHandler(bidUtils))
+			sensitiveBidAuthGroup.POST("/:bidid/messages", controller.SendBidMessageHandler(bidUtils))
 		}
 	}
 
+	// the routes from before the bids moved under /v1/bid, kept for the clients that still call them.
+	// The messages were already under /v1/bid and are served by the group above.
+	legacyBidAuthGroup := r.Group("/v1")
+	legacyBidAuthGroup.Use(defaultRateLimiter)
+	legacyBidAuthGroup.Use(middleware.AuthMiddleware())
+	{
+		legacyBidAuthGroup.GET("/by-post/:postid", controller.GetBidsForPostHandler(bidUtils))
+		legacyBidAuthGroup.GET("/:bidid", controller.FindBidByIDHandler(bidUtils))
+
+		sensitiveLegacyBidAuthGroup := legacyBidAuthGroup.Group("")
+		sensitiveLegacyBidAuthGroup.Use(sensitiveRateLimiter)
+		{
+			sensitiveLegacyBidAuthGroup.POST("/bid", controller.AddBidHandler(bidUtils))
+			sensitiveLegacyBidAuthGroup.POST("/by-post/:postid", controller.AddBidHandler(bidUtils))
+			sensitiveLegacyBidAuthGroup.DELETE("/:bidid", controller.WithdrawBidHandler(bidUtils))
+			sensitiveLegacyBidAuthGroup.PUT("/:bidid", controller.UpdateBidDescriptionHandler(bidUtils))
+		}
+	}
+
+	// internal routes, called by the post and match services
+	bidInternalGroup := r.Group("/v1/internal")
+	bidInternalGroup.Use(middleware.InternalAuthMiddleware("POST", "MATCH"))
+	{
+		bidInternalGroup.GET("/bid/post/:postid/bidders", controller.GetPostBiddersHandler(bidUtils))
+		bidInternalGroup.GET("/bid/post/:postid/dates", controller.GetPostBidDatesHandler(bidUtils))
+		bidInternalGroup.GET("/bid/counts", controller.GetBidCountsHandler(bidUtils))
+		bidInternalGroup.GET("/bid/user/:userid/posts", controller.GetUserBidPostsHandler(bidUtils))
+		bidInternalGroup.GET("/bid/:bidid", controller.GetBidHandler(bidUtils))
+		bidInternalGroup.PUT("/bid/:bidid/accept", controller.AcceptBidHandler(bidUtils))
+		bidInternalGroup.DELETE("/bid/:bidid", controller.DeleteBidHandler(bidUtils))
+	}
+
 	return r
 }
@@ -8,26 +8,59 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"bid/db"
 	"bid/middleware"
 	"bid/schema"
+	"pagination"
+	"screening"
 
 	"github.com/GiveGetGo/shared/types"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+const (
+	// BidWithdrawnNotification tells a post's owner a bidder took their bid back
+	BidWithdrawnNotification types.NotificationType = "bidwithdrawn"
+)
+
+// ErrBidNotEditable is returned when a bid was already answered or withdrawn
+var ErrBidNotEditable = errors.New("only bids waiting for an answer can be edited or withdrawn")
+
 type IBidUtils interface {
-	GetBidBypostID(bidID uint) ([]schema.Bid, error)
+	GetBidBypostID(postID uint, page pagination.Page) ([]schema.Bid, error)
+	GetUserBidsOnPost(postID uint, userID uint, page pagination.Page) ([]schema.Bid, error)
 	AddBid(bid schema.Bid) (schema.Bid, error)
 	GetBidBybidID(bidID uint) ([]schema.Bid, error)
 	DeleteBid(bidID uint) error
 	UpdateBidDescription(bidID uint, description string) error
+	WithdrawBid(bidID uint) error
+	AcceptBid(bidID uint, postID uint) error
+	GetOpenBidderIDs(postID uint) ([]uint, error)
+	CountBidsByPost(postIDs []uint) (map[uint]int64, error)
+	GetBidPostIDs(userID uint) ([]uint, error)
+	GetUserBids(userID uint, status schema.BidStatus, page pagination.Page) ([]schema.Bid, error)
+	CountUserBidsByStatus(userID uint) (map[schema.BidStatus]int64, error)
+	GetBidDates(postID uint) ([]time.Time, error)
 	GetUserInfo(c *gin.Context) (types.UserInfoResponse, error)
-	CreateNotification(userID uint, notificationType types.NotificationType, post schema.PostResponse) error
+	SendNotification(userID uint, notificationType types.NotificationType, description string) error
 	GetPostByPostID(c *gin.Context, postID uint) (schema.PostResponse, error)
-	FormatNotificationDescription(post schema.PostResponse) string
+	GetPostSummaries(postIDs []uint) (map[uint]schema.PostSummary, error)
+	FormatNotificationDescription(post schema.PostSummary) string
+
+	// Messages
+	AddBidMessage(message schema.BidMessage) (schema.BidMessage, error)
+	GetBidMessages(bidID uint, page pagination.Page) ([]schema.BidMessage, error)
+	MarkBidMessagesRead(bidID uint, readerID uint, at time.Time) (int64, error)
+
+	// Screening
+	ScreenText(text string, limit screening.Limit) (screening.Result, error)
+	FlagBid(bidID uint, matches []screening.MatchType) error
 }
 
 // Ensure PostUtils implements IPostUtils
@@ -36,6 +69,7 @@ var _ IBidUtils = (*BidUtils)(nil)
 type BidUtils struct {
 	DB          db.Database
 	RedisClient middleware.RedisClientInterface
+	Screener    *screening.Screener
 }
 
 // NewbidUtils creates a new bidUtils
@@ -43,13 +77,24 @@ func NewBidUtils(DB db.Database, redisClient middleware.RedisClientInterface) *B
 	return &BidUtils{
 		DB:          DB,
 		RedisClient: redisClient,
+		Screener:    screening.NewFromEnv(),
 	}
 }
 
 // func GetBidBypostID retrieves a bid by its postID
-func (bu *BidUtils) GetBidBypostID(postID uint) ([]schema.Bid, error) {
+func (bu *BidUtils) GetBidBypostID(postID uint, page pagination.Page) ([]schema.Bid, error) {
+	var bids []schema.Bid
+	err := page.Apply(bu.DB.Where("post_id = ?", postID)).Find(&bids).Error
+	if err != nil {
+		return nil, err
+	}
+	return bids, nil
+}
+
+// GetUserBidsOnPost retrieves a page of the bids one user placed on a post
+func (bu *BidUtils) GetUserBidsOnPost(postID uint, userID uint, page pagination.Page) ([]schema.Bid, error) {
 	var bids []schema.Bid
-	err := bu.DB.Where("post_id = ?", postID).Find(&bids).Error
+	err := page.Apply(bu.DB.Where("post_id = ? AND user_id = ?", postID, userID)).Find(&bids).Error
 	if err != nil {
 		return nil, err
 	}
@@ -90,21 +135,155 @@ func (pu *BidUtils) DeleteBid(bidID uint) error {
 		return err // Return any error that occurs during the delete operation.
 	}
 
+	// the thread goes with the bid
+	if err := pu.DB.Where("bid_id = ?", bidID).Delete(&schema.BidMessage{}).Error; err != nil {
+		return err
+	}
+
 	return nil // Return nil if the delete operation is successful.
 }
 
-// UpdateBidDescription updates the description of a bid identified by bidID
+// UpdateBidDescription updates the description of a bid still waiting for an answer,
+// ErrBidNotEditable once it was answered or withdrawn
 func (bu *BidUtils) UpdateBidDescription(bidID uint, description string) error {
-	// Find the bid by ID
-	var bid schema.Bid
-	result := bu.DB.First(&bid, "bid_id = ?", bidID)
+	return updateOpenBid(bu.DB, bidID, map[string]interface{}{"bid_description": description})
+}
+
+// WithdrawBid takes back a bid still waiting for an answer, ErrBidNotEditable once it was answered or withdrawn
+func (bu *BidUtils) WithdrawBid(bidID uint) error {
+	return updateOpenBid(bu.DB, bidID, map[string]interface{}{"status": schema.Withdrawn})
+}
+
+// AcceptBid marks the bid its post was matched with as accepted and rejects the other bids still
+// waiting on the post, ErrBidNotEditable once the bid was answered or withdrawn
+func (bu *BidUtils) AcceptBid(bidID uint, postID uint) error {
+	return bu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updateOpenBid(tx, bidID, map[string]interface{}{"status": schema.Accepted}); err != nil {
+			return err
+		}
+
+		return tx.Where("post_id = ? AND bid_id <> ? AND status = ?", postID, bidID, schema.Submitted).
+			Model(&schema.Bid{}).
+			Updates(map[string]interface{}{"status": schema.Rejected, "date_updated": time.Now()}).Error
+	})
+}
+
+// updateOpenBid changes a bid only while it's submitted, so an answer that lands at the same
+// time isn't overwritten
+func updateOpenBid(DB db.Database, bidID uint, updates map[string]interface{}) error {
+	updates["date_updated"] = time.Now()
+	result := DB.Where("bid_id = ? AND status = ?", bidID, schema.Submitted).Model(&schema.Bid{}).Updates(updates)
 	if result.Error != nil {
-		return result.Error // If not found or other DB error
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBidNotEditable
+	}
+	return nil
+}
+
+// GetOpenBidderIDs lists the users whose bid on the post hasn't been answered or withdrawn yet
+func (bu *BidUtils) GetOpenBidderIDs(postID uint) ([]uint, error) {
+	userIDs := []uint{}
+	err := bu.DB.Where("post_id = ? AND status = ?", postID, schema.Submitted).
+		Model(&schema.Bid{}).Distinct().Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// CountBidsByPost counts the bids on each post that weren't rejected or withdrawn
+func (bu *BidUtils) CountBidsByPost(postIDs []uint) (map[uint]int64, error) {
+	counts := map[uint]int64{}
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID uint
+		Count  int64
+	}
+	err := bu.DB.Where("post_id IN ? AND status NOT IN ?", postIDs, []schema.BidStatus{schema.Rejected, schema.Withdrawn}).
+		Model(&schema.Bid{}).
+		Select("post_id, COUNT(*) AS count").
+		Group("post_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
 	}
 
-	// Update the bid's description
-	bid.BidDescription = description
-	return bu.DB.Save(&bid).Error
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetBidPostIDs lists the posts the user has bid on
+func (bu *BidUtils) GetBidPostIDs(userID uint) ([]uint, error) {
+	postIDs := []uint{}
+	err := bu.DB.Where("user_id = ?", userID).Model(&schema.Bid{}).Distinct().Pluck("post_id", &postIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return postIDs, nil
+}
+
+// BidStatuses are the statuses a bid can be in
+var BidStatuses = []schema.BidStatus{schema.Submitted, schema.Accepted, schema.Rejected, schema.Withdrawn}
+
+// ValidBidStatus reports whether status is one a bid can be in
+func ValidBidStatus(status schema.BidStatus) bool {
+	return slices.Contains(BidStatuses, status)
+}
+
+// GetUserBids retrieves a page of the bids the user placed, only those in status unless it's empty
+func (bu *BidUtils) GetUserBids(userID uint, status schema.BidStatus, page pagination.Page) ([]schema.Bid, error) {
+	query := bu.DB.Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var bids []schema.Bid
+	if err := page.Apply(query).Find(&bids).Error; err != nil {
+		return nil, err
+	}
+	return bids, nil
+}
+
+// CountUserBidsByStatus counts the bids the user placed in each status, every status is listed
+func (bu *BidUtils) CountUserBidsByStatus(userID uint) (map[schema.BidStatus]int64, error) {
+	var rows []struct {
+		Status schema.BidStatus
+		Count  int64
+	}
+	err := bu.DB.Where("user_id = ?", userID).
+		Model(&schema.Bid{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[schema.BidStatus]int64{}
+	for _, status := range BidStatuses {
+		counts[status] = 0
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// GetBidDates lists when each bid on the post was submitted, oldest first, rejected ones included
+func (bu *BidUtils) GetBidDates(postID uint) ([]time.Time, error) {
+	dates := []time.Time{}
+	err := bu.DB.Where("post_id = ?", postID).Model(&schema.Bid{}).Order("date_submitted").Pluck("date_submitted", &dates).Error
+	if err != nil {
+		return nil, err
+	}
+	return dates, nil
 }
 
 func (bu *BidUtils) GetUserInfo(c *gin.Context) (types.UserInfoResponse, error) {
@@ -163,9 +342,8 @@ func (bu *BidUtils) GetUserInfo(c *gin.Context) (types.UserInfoResponse, error)
 	return userInfo, nil
 }
 
-func (bu *BidUtils) CreateNotification(userID uint, notificationType types.NotificationType, post schema.PostResponse) error {
-	description := bu.FormatNotificationDescription(post)
-
+// SendNotification sends a notification with the given description to a user through the notification service
+func (bu *BidUtils) SendNotification(userID uint, notificationType types.NotificationType, description string) error {
 	// Marshal the request body
 	notificationReqBody, err := json.Marshal(types.CreateNotificationRequest{
 		UserID:           userID,
@@ -260,6 +438,63 @@ func (bu *BidUtils) GetPostByPostID(c *gin.Context, postID uint) (schema.PostRes
 	return postResponse, nil
 }
 
-func (bu *BidUtils) FormatNotificationDescription(post schema.PostResponse) string {
-	return fmt.Sprintf("New matching request %s for \"%s\".", post.Username, post.Title)
+// GetPostSummaries asks the post service who owns each post and its status, keyed by post ID.
+// Posts that are gone are left out.
+func (bu *BidUtils) GetPostSummaries(postIDs []uint) (map[uint]schema.PostSummary, error) {
+	summaries := map[uint]schema.PostSummary{}
+	if len(postIDs) == 0 {
+		return summaries, nil
+	}
+
+	ids := make([]string, 0, len(postIDs))
+	for _, postID := range postIDs {
+		ids = append(ids, strconv.FormatUint(uint64(postID), 10))
+	}
+
+	postServiceURL := os.Getenv("POST_SERVICE_URL") + "/v1/internal/post/summaries?post_ids=" + strings.Join(ids, ",")
+	req, err := http.NewRequest("GET", postServiceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Service", "BID")
+	req.Header.Set("X-Api-Key", os.Getenv("BID_API_KEY"))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("post service responded with status: %d", resp.StatusCode)
+	}
+
+	var fullResponse types.FullResponseWithData
+	if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.Marshal(fullResponse.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.PostSummariesResponse
+	if err := json.Unmarshal(jsonData, &response); err != nil {
+		return nil, err
+	}
+
+	for _, summary := range response.Posts {
+		summaries[summary.PostID] = summary
+	}
+	return summaries, nil
+}
+
+// FormatNotificationDescription describes the bid to the bidder, on an offer a bid asks for the item
+func (bu *BidUtils) FormatNotificationDescription(post schema.PostSummary) string {
+	if post.Type == schema.OfferPost {
+		return fmt.Sprintf("You asked %s for \"%s\".", post.Username, post.Title)
+	}
+	return fmt.Sprintf("You offered to help %s with \"%s\".", post.Username, post.Title)
 }
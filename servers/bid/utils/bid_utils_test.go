@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"bid/schema"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB, DriverName: "postgres"}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+func TestWithdrawBid(t *testing.T) {
+	db, mock := newTestDB(t)
+	bidUtils := &BidUtils{DB: db}
+
+	// only a submitted bid is changed
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "bids" SET "date_updated"=\$1,"status"=\$2 WHERE bid_id = \$3 AND status = \$4`).
+		WithArgs(sqlmock.AnyArg(), schema.Withdrawn, 12, schema.Submitted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, bidUtils.WithdrawBid(12))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateBidDescriptionNotEditable(t *testing.T) {
+	db, mock := newTestDB(t)
+	bidUtils := &BidUtils{DB: db}
+
+	// the bid was answered or withdrawn in the meantime
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "bids" SET "bid_description"=\$1,"date_updated"=\$2 WHERE bid_id = \$3 AND status = \$4`).
+		WithArgs("I can help tomorrow", sqlmock.AnyArg(), 12, schema.Submitted).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := bidUtils.UpdateBidDescription(12, "I can help tomorrow")
+	assert.ErrorIs(t, err, ErrBidNotEditable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcceptBid(t *testing.T) {
+	db, mock := newTestDB(t)
+	bidUtils := &BidUtils{DB: db}
+
+	// the bid is accepted and the other bids still waiting on the post are turned down together
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "bids" SET "date_updated"=\$1,"status"=\$2 WHERE bid_id = \$3 AND status = \$4`).
+		WithArgs(sqlmock.AnyArg(), schema.Accepted, 12, schema.Submitted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "bids" SET "date_updated"=\$1,"status"=\$2 WHERE post_id = \$3 AND bid_id <> \$4 AND status = \$5`).
+		WithArgs(sqlmock.AnyArg(), schema.Rejected, 5, 12, schema.Submitted).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	assert.NoError(t, bidUtils.AcceptBid(12, 5))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcceptBidRollsBack(t *testing.T) {
+	db, mock := newTestDB(t)
+	bidUtils := &BidUtils{DB: db}
+
+	// rejecting the other bids failed, so the accepted bid goes back to submitted
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "bids" SET "date_updated"=\$1,"status"=\$2 WHERE bid_id = \$3 AND status = \$4`).
+		WithArgs(sqlmock.AnyArg(), schema.Accepted, 12, schema.Submitted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "bids" SET "date_updated"=\$1,"status"=\$2 WHERE post_id = \$3`).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	assert.Error(t, bidUtils.AcceptBid(12, 5))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcceptBidNotSubmitted(t *testing.T) {
+	db, mock := newTestDB(t)
+	bidUtils := &BidUtils{DB: db}
+
+	// a withdrawn bid can't be accepted and the others are left alone
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "bids" SET "date_updated"=\$1,"status"=\$2 WHERE bid_id = \$3 AND status = \$4`).
+		WithArgs(sqlmock.AnyArg(), schema.Accepted, 12, schema.Submitted).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, bidUtils.AcceptBid(12, 5), ErrBidNotEditable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
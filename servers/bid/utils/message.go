@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"time"
+
+	"bid/schema"
+	"pagination"
+	"screening"
+
+	"github.com/GiveGetGo/shared/types"
+)
+
+// BidMessageNotification tells one side of a bid the other sent them a message
+const BidMessageNotification types.NotificationType = "bidmessage"
+
+// MessageLimit is the length limit of a message on a bid, in characters
+var MessageLimit = screening.Limit{Min: 1, Max: 1000}
+
+// ThreadOpen reports whether new messages may be sent on the bid. The thread closes once the bid
+// is rejected or withdrawn or its post is no longer active, what was said stays readable.
+func ThreadOpen(bid schema.Bid, post schema.PostSummary) bool {
+	return bid.Status != schema.Rejected && bid.Status != schema.Withdrawn && post.Status == schema.Active
+}
+
+// AddBidMessage saves a message on a bid
+func (bu *BidUtils) AddBidMessage(message schema.BidMessage) (schema.BidMessage, error) {
+	if err := bu.DB.Create(&message).Error; err != nil {
+		return schema.BidMessage{}, err
+	}
+	return message, nil
+}
+
+// GetBidMessages retrieves a page of a bid's messages
+func (bu *BidUtils) GetBidMessages(bidID uint, page pagination.Page) ([]schema.BidMessage, error) {
+	var messages []schema.BidMessage
+	if err := page.Apply(bu.DB.Where("bid_id = ?", bidID)).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkBidMessagesRead marks the unread messages the reader got on a bid as read at at, returning how many there were
+func (bu *BidUtils) MarkBidMessagesRead(bidID uint, readerID uint, at time.Time) (int64, error) {
+	result := bu.DB.Where("bid_id = ? AND sender_id <> ? AND read_at IS NULL", bidID, readerID).
+		Model(&schema.BidMessage{}).
+		Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
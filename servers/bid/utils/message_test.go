@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"bid/schema"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreadOpen(t *testing.T) {
+	active := schema.PostSummary{Status: schema.Active}
+
+	assert.True(t, ThreadOpen(schema.Bid{Status: schema.Submitted}, active))
+	assert.True(t, ThreadOpen(schema.Bid{Status: schema.Accepted}, active))
+	assert.False(t, ThreadOpen(schema.Bid{Status: schema.Rejected}, active))
+	assert.False(t, ThreadOpen(schema.Bid{Status: schema.Withdrawn}, active))
+	assert.False(t, ThreadOpen(schema.Bid{Status: schema.Submitted}, schema.PostSummary{Status: schema.Matched}))
+	// a post that's gone has no status
+	assert.False(t, ThreadOpen(schema.Bid{Status: schema.Submitted}, schema.PostSummary{}))
+}
@@ -0,0 +1,393 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/bid_utils.go
+
+// Package utils is a generated GoMock package.
+package utils
+
+import (
+	schema "bid/schema"
+	pagination "pagination"
+	reflect "reflect"
+	screening "screening"
+	time "time"
+
+	types "github.com/GiveGetGo/shared/types"
+	gin "github.com/gin-gonic/gin"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIBidUtils is a mock of IBidUtils interface.
+type MockIBidUtils struct {
+	ctrl     *gomock.Controller
+	recorder *MockIBidUtilsMockRecorder
+}
+
+// MockIBidUtilsMockRecorder is the mock recorder for MockIBidUtils.
+type MockIBidUtilsMockRecorder struct {
+	mock *MockIBidUtils
+}
+
+// NewMockIBidUtils creates a new mock instance.
+func NewMockIBidUtils(ctrl *gomock.Controller) *MockIBidUtils {
+	mock := &MockIBidUtils{ctrl: ctrl}
+	mock.recorder = &MockIBidUtilsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIBidUtils) EXPECT() *MockIBidUtilsMockRecorder {
+	return m.recorder
+}
+
+// AcceptBid mocks base method.
+func (m *MockIBidUtils) AcceptBid(bidID, postID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptBid", bidID, postID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptBid indicates an expected call of AcceptBid.
+func (mr *MockIBidUtilsMockRecorder) AcceptBid(bidID, postID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptBid", reflect.TypeOf((*MockIBidUtils)(nil).AcceptBid), bidID, postID)
+}
+
+// AddBid mocks base method.
+func (m *MockIBidUtils) AddBid(bid schema.Bid) (schema.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBid", bid)
+	ret0, _ := ret[0].(schema.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddBid indicates an expected call of AddBid.
+func (mr *MockIBidUtilsMockRecorder) AddBid(bid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBid", reflect.TypeOf((*MockIBidUtils)(nil).AddBid), bid)
+}
+
+// AddBidMessage mocks base method.
+func (m *MockIBidUtils) AddBidMessage(message schema.BidMessage) (schema.BidMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBidMessage", message)
+	ret0, _ := ret[0].(schema.BidMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddBidMessage indicates an expected call of AddBidMessage.
+func (mr *MockIBidUtilsMockRecorder) AddBidMessage(message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBidMessage", reflect.TypeOf((*MockIBidUtils)(nil).AddBidMessage), message)
+}
+
+// CountBidsByPost mocks base method.
+func (m *MockIBidUtils) CountBidsByPost(postIDs []uint) (map[uint]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountBidsByPost", postIDs)
+	ret0, _ := ret[0].(map[uint]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountBidsByPost indicates an expected call of CountBidsByPost.
+func (mr *MockIBidUtilsMockRecorder) CountBidsByPost(postIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountBidsByPost", reflect.TypeOf((*MockIBidUtils)(nil).CountBidsByPost), postIDs)
+}
+
+// CountUserBidsByStatus mocks base method.
+func (m *MockIBidUtils) CountUserBidsByStatus(userID uint) (map[schema.BidStatus]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUserBidsByStatus", userID)
+	ret0, _ := ret[0].(map[schema.BidStatus]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUserBidsByStatus indicates an expected call of CountUserBidsByStatus.
+func (mr *MockIBidUtilsMockRecorder) CountUserBidsByStatus(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserBidsByStatus", reflect.TypeOf((*MockIBidUtils)(nil).CountUserBidsByStatus), userID)
+}
+
+// DeleteBid mocks base method.
+func (m *MockIBidUtils) DeleteBid(bidID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBid", bidID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBid indicates an expected call of DeleteBid.
+func (mr *MockIBidUtilsMockRecorder) DeleteBid(bidID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBid", reflect.TypeOf((*MockIBidUtils)(nil).DeleteBid), bidID)
+}
+
+// FlagBid mocks base method.
+func (m *MockIBidUtils) FlagBid(bidID uint, matches []screening.MatchType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagBid", bidID, matches)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagBid indicates an expected call of FlagBid.
+func (mr *MockIBidUtilsMockRecorder) FlagBid(bidID, matches interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagBid", reflect.TypeOf((*MockIBidUtils)(nil).FlagBid), bidID, matches)
+}
+
+// FormatNotificationDescription mocks base method.
+func (m *MockIBidUtils) FormatNotificationDescription(post schema.PostSummary) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FormatNotificationDescription", post)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FormatNotificationDescription indicates an expected call of FormatNotificationDescription.
+func (mr *MockIBidUtilsMockRecorder) FormatNotificationDescription(post interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FormatNotificationDescription", reflect.TypeOf((*MockIBidUtils)(nil).FormatNotificationDescription), post)
+}
+
+// GetBidBybidID mocks base method.
+func (m *MockIBidUtils) GetBidBybidID(bidID uint) ([]schema.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidBybidID", bidID)
+	ret0, _ := ret[0].([]schema.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBidBybidID indicates an expected call of GetBidBybidID.
+func (mr *MockIBidUtilsMockRecorder) GetBidBybidID(bidID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidBybidID", reflect.TypeOf((*MockIBidUtils)(nil).GetBidBybidID), bidID)
+}
+
+// GetBidBypostID mocks base method.
+func (m *MockIBidUtils) GetBidBypostID(postID uint, page pagination.Page) ([]schema.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidBypostID", postID, page)
+	ret0, _ := ret[0].([]schema.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBidBypostID indicates an expected call of GetBidBypostID.
+func (mr *MockIBidUtilsMockRecorder) GetBidBypostID(postID, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidBypostID", reflect.TypeOf((*MockIBidUtils)(nil).GetBidBypostID), postID, page)
+}
+
+// GetBidDates mocks base method.
+func (m *MockIBidUtils) GetBidDates(postID uint) ([]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidDates", postID)
+	ret0, _ := ret[0].([]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBidDates indicates an expected call of GetBidDates.
+func (mr *MockIBidUtilsMockRecorder) GetBidDates(postID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidDates", reflect.TypeOf((*MockIBidUtils)(nil).GetBidDates), postID)
+}
+
+// GetBidMessages mocks base method.
+func (m *MockIBidUtils) GetBidMessages(bidID uint, page pagination.Page) ([]schema.BidMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidMessages", bidID, page)
+	ret0, _ := ret[0].([]schema.BidMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBidMessages indicates an expected call of GetBidMessages.
+func (mr *MockIBidUtilsMockRecorder) GetBidMessages(bidID, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidMessages", reflect.TypeOf((*MockIBidUtils)(nil).GetBidMessages), bidID, page)
+}
+
+// GetBidPostIDs mocks base method.
+func (m *MockIBidUtils) GetBidPostIDs(userID uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBidPostIDs", userID)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBidPostIDs indicates an expected call of GetBidPostIDs.
+func (mr *MockIBidUtilsMockRecorder) GetBidPostIDs(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBidPostIDs", reflect.TypeOf((*MockIBidUtils)(nil).GetBidPostIDs), userID)
+}
+
+// GetOpenBidderIDs mocks base method.
+func (m *MockIBidUtils) GetOpenBidderIDs(postID uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenBidderIDs", postID)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenBidderIDs indicates an expected call of GetOpenBidderIDs.
+func (mr *MockIBidUtilsMockRecorder) GetOpenBidderIDs(postID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenBidderIDs", reflect.TypeOf((*MockIBidUtils)(nil).GetOpenBidderIDs), postID)
+}
+
+// GetPostByPostID mocks base method.
+func (m *MockIBidUtils) GetPostByPostID(c *gin.Context, postID uint) (schema.PostResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostByPostID", c, postID)
+	ret0, _ := ret[0].(schema.PostResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostByPostID indicates an expected call of GetPostByPostID.
+func (mr *MockIBidUtilsMockRecorder) GetPostByPostID(c, postID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostByPostID", reflect.TypeOf((*MockIBidUtils)(nil).GetPostByPostID), c, postID)
+}
+
+// GetPostSummaries mocks base method.
+func (m *MockIBidUtils) GetPostSummaries(postIDs []uint) (map[uint]schema.PostSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostSummaries", postIDs)
+	ret0, _ := ret[0].(map[uint]schema.PostSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostSummaries indicates an expected call of GetPostSummaries.
+func (mr *MockIBidUtilsMockRecorder) GetPostSummaries(postIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostSummaries", reflect.TypeOf((*MockIBidUtils)(nil).GetPostSummaries), postIDs)
+}
+
+// GetUserBids mocks base method.
+func (m *MockIBidUtils) GetUserBids(userID uint, status schema.BidStatus, page pagination.Page) ([]schema.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserBids", userID, status, page)
+	ret0, _ := ret[0].([]schema.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserBids indicates an expected call of GetUserBids.
+func (mr *MockIBidUtilsMockRecorder) GetUserBids(userID, status, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBids", reflect.TypeOf((*MockIBidUtils)(nil).GetUserBids), userID, status, page)
+}
+
+// GetUserBidsOnPost mocks base method.
+func (m *MockIBidUtils) GetUserBidsOnPost(postID, userID uint, page pagination.Page) ([]schema.Bid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserBidsOnPost", postID, userID, page)
+	ret0, _ := ret[0].([]schema.Bid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserBidsOnPost indicates an expected call of GetUserBidsOnPost.
+func (mr *MockIBidUtilsMockRecorder) GetUserBidsOnPost(postID, userID, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBidsOnPost", reflect.TypeOf((*MockIBidUtils)(nil).GetUserBidsOnPost), postID, userID, page)
+}
+
+// GetUserInfo mocks base method.
+func (m *MockIBidUtils) GetUserInfo(c *gin.Context) (types.UserInfoResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserInfo", c)
+	ret0, _ := ret[0].(types.UserInfoResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserInfo indicates an expected call of GetUserInfo.
+func (mr *MockIBidUtilsMockRecorder) GetUserInfo(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInfo", reflect.TypeOf((*MockIBidUtils)(nil).GetUserInfo), c)
+}
+
+// MarkBidMessagesRead mocks base method.
+func (m *MockIBidUtils) MarkBidMessagesRead(bidID, readerID uint, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkBidMessagesRead", bidID, readerID, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkBidMessagesRead indicates an expected call of MarkBidMessagesRead.
+func (mr *MockIBidUtilsMockRecorder) MarkBidMessagesRead(bidID, readerID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkBidMessagesRead", reflect.TypeOf((*MockIBidUtils)(nil).MarkBidMessagesRead), bidID, readerID, at)
+}
+
+// ScreenText mocks base method.
+func (m *MockIBidUtils) ScreenText(text string, limit screening.Limit) (screening.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScreenText", text, limit)
+	ret0, _ := ret[0].(screening.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScreenText indicates an expected call of ScreenText.
+func (mr *MockIBidUtilsMockRecorder) ScreenText(text, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScreenText", reflect.TypeOf((*MockIBidUtils)(nil).ScreenText), text, limit)
+}
+
+// SendNotification mocks base method.
+func (m *MockIBidUtils) SendNotification(userID uint, notificationType types.NotificationType, description string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendNotification", userID, notificationType, description)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendNotification indicates an expected call of SendNotification.
+func (mr *MockIBidUtilsMockRecorder) SendNotification(userID, notificationType, description interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendNotification", reflect.TypeOf((*MockIBidUtils)(nil).SendNotification), userID, notificationType, description)
+}
+
+// UpdateBidDescription mocks base method.
+func (m *MockIBidUtils) UpdateBidDescription(bidID uint, description string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBidDescription", bidID, description)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBidDescription indicates an expected call of UpdateBidDescription.
+func (mr *MockIBidUtilsMockRecorder) UpdateBidDescription(bidID, description interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBidDescription", reflect.TypeOf((*MockIBidUtils)(nil).UpdateBidDescription), bidID, description)
+}
+
+// WithdrawBid mocks base method.
+func (m *MockIBidUtils) WithdrawBid(bidID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawBid", bidID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithdrawBid indicates an expected call of WithdrawBid.
+func (mr *MockIBidUtilsMockRecorder) WithdrawBid(bidID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawBid", reflect.TypeOf((*MockIBidUtils)(nil).WithdrawBid), bidID)
+}
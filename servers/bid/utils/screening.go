@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"bid/schema"
+	"screening"
+)
+
+// DescriptionLimit is the length limit of a bid's description, in characters
+var DescriptionLimit = screening.Limit{Min: 1, Max: 2000}
+
+// ScreenText normalizes user text and checks it against the screening rules
+func (bu *BidUtils) ScreenText(text string, limit screening.Limit) (screening.Result, error) {
+	return bu.Screener.Screen(text, limit)
+}
+
+// FlagBid puts a bid the screening flagged in the post service's moderation queue
+func (bu *BidUtils) FlagBid(bidID uint, matches []screening.MatchType) error {
+	found := make([]string, 0, len(matches))
+	for _, match := range matches {
+		found = append(found, string(match))
+	}
+
+	body, err := json.Marshal(schema.FlaggedContentRequest{
+		TargetType: "bid",
+		TargetID:   bidID,
+		Matches:    found,
+	})
+	if err != nil {
+		return err
+	}
+
+	postServiceURL := os.Getenv("POST_SERVICE_URL") + "/v1/internal/post/reports/flagged"
+	req, err := http.NewRequest("POST", postServiceURL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	// Set the headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service", "BID")
+	req.Header.Set("X-Api-Key", os.Getenv("BID_API_KEY"))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("post service responded with status: %d", resp.StatusCode)
+	}
+	return nil
+}
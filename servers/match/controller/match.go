@@ -1,14 +1,19 @@
 package controller
 
 import (
+	"errors"
+	"log"
 	"match/schema"
 	"match/utils"
 	"net/http"
+	"pagination"
 	"strconv"
+	"time"
 
 	"github.com/GiveGetGo/shared/res"
 	"github.com/GiveGetGo/shared/types"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // func MatchHandler - add a match
@@ -26,32 +31,77 @@ func MatchHandler(matchUtils utils.IMatchUtils) gin.HandlerFunc {
 			return
 		}
 
-		helperUserid, err := matchUtils.GetHelperUserID(c, req.BidID)
+		// only the post's owner may pick the bid it's matched with
+		ownerID, err := matchUtils.GetPostOwnerID(req.PostID)
 		if err != nil {
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			if errors.Is(err, utils.ErrPostNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			} else {
+				log.Printf("Error fetching the owner of post %d: %v", req.PostID, err)
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+		if ownerID != user.UserID {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+
+		bid, err := matchUtils.GetBid(req.BidID)
+		if err != nil {
+			if errors.Is(err, utils.ErrBidNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			} else {
+				log.Printf("Error fetching bid %d: %v", req.BidID, err)
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
 			return
 		}
 
+		// the bid has to be on the post being matched and still waiting for an answer
+		if bid.PostID != req.PostID {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+		if bid.Status != schema.Submitted {
+			res.ResponseError(c, http.StatusConflict, schema.Conflict())
+			return
+		}
+		bidUserid := bid.UserID
+
 		post, err := matchUtils.GetPostByPostID(c, req.PostID)
 		if err != nil {
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
 		}
 
-		// create new match
-		_, err = matchUtils.CreateMatch(post.PostID, user.UserID, helperUserid)
+		// claim the post first so two matches can't be made for the same post
+		err = matchUtils.UpdatePostStatus(req.PostID, schema.Matched, "matched")
 		if err != nil {
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			if errors.Is(err, utils.ErrPostStatusConflict) {
+				res.ResponseError(c, http.StatusConflict, schema.Conflict())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
 			return
 		}
 
-		// after created match, update post status to matched
-		err = matchUtils.UpdatePostStatus(req.PostID, schema.Matched)
+		// create new match
+		_, err = matchUtils.CreateMatch(post, user.UserID, bidUserid)
 		if err != nil {
+			// put the post back on the board
+			if err := matchUtils.UpdatePostStatus(req.PostID, schema.Active, "match failed"); err != nil {
+				log.Printf("Error reverting post %d to active: %v", req.PostID, err)
+			}
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
 		}
 
+		// the match stands either way, a failure only leaves the bids on the post showing as submitted
+		if err := matchUtils.AcceptBid(bid.BidID); err != nil {
+			log.Printf("Error accepting bid %d for post %d: %v", bid.BidID, req.PostID, err)
+		}
+
 		// Notify both post user and bid user
 		err = matchUtils.CreateNotification(user.UserID, types.NewMatch, post)
 		if err != nil {
@@ -59,7 +109,7 @@ func MatchHandler(matchUtils utils.IMatchUtils) gin.HandlerFunc {
 			return
 		}
 
-		err = matchUtils.CreateNotification(helperUserid, types.BidMatch, post)
+		err = matchUtils.CreateNotification(bidUserid, types.BidMatch, post)
 		if err != nil {
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
@@ -92,6 +142,128 @@ func GetMatchHandler(matchUtils utils.IMatchUtils) gin.HandlerFunc {
 	}
 }
 
+// SetMatchTimeHandler lets either side of a match set when they meet for the handover
+func SetMatchTimeHandler(matchUtils utils.IMatchUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		matchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		var req schema.MatchTimeRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		user, err := matchUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		match, err := matchUtils.GetMatchByID(uint(matchID))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+
+		if user.UserID != match.HelperUserID && user.UserID != match.RecipientUserID {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+
+		if err := matchUtils.SetAgreedTime(match.MatchID, req.AgreedTime); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "set-match-time", types.Success(), gin.H{"agreed_time": req.AgreedTime})
+	}
+}
+
+// GetScheduledMatchesHandler tells another service about a user's matches with an agreed time,
+// from a day ago on so a handover that just happened stays on their calendar
+func GetScheduledMatchesHandler(matchUtils utils.IMatchUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseUint(c.Param("userid"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		matches, err := matchUtils.GetScheduledMatches(uint(userID), time.Now().Add(-24*time.Hour))
+		if err != nil {
+			log.Printf("Error fetching the scheduled matches of user %d: %v", userID, err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		scheduled := []schema.ScheduledMatch{}
+		for _, match := range matches {
+			scheduled = append(scheduled, schema.ScheduledMatch{
+				MatchID:            match.MatchID,
+				PostID:             match.PostID,
+				PostUserID:         match.PostUserID,
+				HelperUserID:       match.HelperUserID,
+				RecipientUserID:    match.RecipientUserID,
+				AgreedTime:         *match.AgreedTime,
+				FulfillmentDetails: match.FulfillmentDetails,
+			})
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get-scheduled-matches", types.Success(), schema.ScheduledMatchesResponse{
+			UserID:  uint(userID),
+			Matches: scheduled,
+		})
+	}
+}
+
+// matchPageOptions are the sorts and page sizes of the match lists
+var matchPageOptions = pagination.Options{
+	DefaultLimit: 25,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"date_matched": {Column: "date_matched", Kind: pagination.TimeField},
+	},
+	DefaultSort: "-date_matched",
+	IDColumn:    "match_id",
+}
+
+// GetMatchesHandler - list the matches the user is in
+func GetMatchesHandler(matchUtils utils.IMatchUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := matchUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		page, err := pagination.Parse(c, matchPageOptions)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		matches, err := matchUtils.GetAllMatchesByUserID(user.UserID, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		matches, result := pagination.Paginate(matches, page, func(match schema.Match, _ string) (interface{}, uint) {
+			return match.DateMatched, match.MatchID
+		})
+
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "get-matches", types.Success(), matches, result)
+	}
+}
+
 // DeleteMatchHandler - delete match by matchid
 func DeleteMatchHandler(matchUtils utils.IMatchUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -52,6 +52,13 @@ func AutoMigratePostgresDB(db *gorm.DB) error {
 		return err
 	}
 
+	// matches from before offers were all on requests, where the post owner receives the help
+	err = db.Exec(`UPDATE matches SET recipient_user_id = post_user_id WHERE recipient_user_id IS NULL OR recipient_user_id = 0`).Error
+	if err != nil {
+		log.Fatalf("Error backfilling match recipients: %v", err)
+		return err
+	}
+
 	log.Println("Successfully migrated PostgreSQL schema")
 	return nil
 }
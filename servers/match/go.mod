@@ -9,6 +9,7 @@ require (
 	github.com/ulule/limiter/v3 v3.11.2
 	gorm.io/driver/postgres v1.5.7
 	gorm.io/gorm v1.25.10
+	pagination v0.0.0
 )
 
 require (
@@ -48,3 +49,5 @@ require (
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace pagination => ../pagination
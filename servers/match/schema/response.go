@@ -0,0 +1,25 @@
+package schema
+
+import "github.com/GiveGetGo/shared/types"
+
+// not part of the shared response codes yet
+const (
+	ForbiddenCode = "40301"
+	ConflictCode  = "40902"
+)
+
+// func Forbidden() Response
+func Forbidden() types.Response {
+	return types.Response{
+		Code: ForbiddenCode,
+		Msg:  "Forbidden",
+	}
+}
+
+// func Conflict() Response
+func Conflict() types.Response {
+	return types.Response{
+		Code: ConflictCode,
+		Msg:  "Conflict",
+	}
+}
@@ -9,6 +9,13 @@ import "time"
 // 	Unfulfilled MatchStatus = "Unfulfilled"
 // )
 
+type PostType string
+
+const (
+	RequestPost PostType = "request"
+	OfferPost   PostType = "offer"
+)
+
 type PostStatus string
 
 const (
@@ -18,28 +25,74 @@ const (
 	Expired PostStatus = "Expired"
 )
 
+// Match pairs a post with the bid that was accepted. On a request the bidder helps the
+// post owner, on an offer the post owner gives to the bidder.
 type Match struct {
-	MatchID        uint `gorm:"primaryKey"`
-	PostID         uint `gorm:"index"`
-	PostUserID     uint `gorm:"index"`
-	HelperUserID   uint `gorm:"index"`
-	PostUsername   string
-	HelperUsername string
+	MatchID         uint     `gorm:"primaryKey"`
+	PostID          uint     `gorm:"index"`
+	PostType        PostType `gorm:"default:request"`
+	PostUserID      uint     `gorm:"index"`
+	HelperUserID    uint     `gorm:"index"` // who gives the help or the item
+	RecipientUserID uint     `gorm:"index"` // who receives it
+	PostUsername    string
+	HelperUsername  string
 	// Status             MatchStatus
 	DateMatched        time.Time
 	FulfillmentDetails string
+	// when the two sides agreed to meet for the handover, nil until one of them sets it
+	AgreedTime *time.Time `gorm:"index"`
+}
+
+// MatchTimeRequest - request body for setting the agreed time of a match, null clears it
+type MatchTimeRequest struct {
+	AgreedTime *time.Time `json:"agreed_time"`
+}
+
+// ScheduledMatchesResponse lists a user's matches that have an agreed time, soonest first
+type ScheduledMatchesResponse struct {
+	UserID  uint             `json:"userID"`
+	Matches []ScheduledMatch `json:"matches"`
+}
+
+type ScheduledMatch struct {
+	MatchID            uint      `json:"matchID"`
+	PostID             uint      `json:"postID"`
+	PostUserID         uint      `json:"postUserID"`
+	HelperUserID       uint      `json:"helperUserID"`
+	RecipientUserID    uint      `json:"recipientUserID"`
+	AgreedTime         time.Time `json:"agreed_time"`
+	FulfillmentDetails string    `json:"fulfillment_details"`
 }
 
 type PostStatusUpdateRequest struct {
 	PostID uint       `json:"postID"`
 	Status PostStatus `json:"status"`
+	Reason string     `json:"reason"`
+}
+
+type BidStatus string
+
+// only a bid still waiting for an answer can be matched
+const Submitted BidStatus = "Submitted"
+
+// BidResponse is the bid service's view of a bid
+type BidResponse struct {
+	BidID    uint      `json:"bidID"`
+	PostID   uint      `json:"postID"`
+	UserID   uint      `json:"userID"`
+	Username string    `json:"username"`
+	Status   BidStatus `json:"status"`
 }
 
-type BidInfoResponse struct {
-	UserID         uint   `json:"userID"`
-	Username       string `json:"username"`
-	BidDescription string `json:"BidDescription"`
-	DateSubmitted  string `json:"DateSubmitted"`
+// PostSummariesResponse is the post service's owner and status of the posts asked about
+type PostSummariesResponse struct {
+	Posts []PostSummary `json:"posts"`
+}
+
+type PostSummary struct {
+	PostID uint       `json:"postID"`
+	UserID uint       `json:"userID"`
+	Status PostStatus `json:"status"`
 }
 
 type PostResponse struct {
@@ -47,6 +100,9 @@ type PostResponse struct {
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
 	Category    string     `json:"category"`
+	Type        PostType   `json:"type"`
+	Quantity    int        `json:"quantity,omitempty"`
+	NeededBy    *time.Time `json:"needed_by,omitempty"`
 	Username    string     `json:"username"`
 	DatePosted  time.Time  `json:"date_posted"`
 	Status      PostStatus `json:"status"`
@@ -33,6 +33,7 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 	{
 		defaultMatchAuthGroup := matchAuthGroup.Group("")
 		{
+			defaultMatchAuthGroup.GET("/match", controller.GetMatchesHandler(matchUtils))
 			defaultMatchAuthGroup.GET("/match/:id", controller.GetMatchHandler(matchUtils))
 		}
 
@@ -40,8 +41,16 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 		sensitiveMatchAuthGroup.Use(sensitiveRateLimiter)
 		{
 			sensitiveMatchAuthGroup.POST("/match", controller.MatchHandler(matchUtils))
+			sensitiveMatchAuthGroup.PUT("/match/:id/time", controller.SetMatchTimeHandler(matchUtils))
 		}
 	}
 
+	// interal routes
+	matchInternalGroup := r.Group("/v1/internal")
+	matchInternalGroup.Use(middleware.InternalAuthMiddleware())
+	{
+		matchInternalGroup.GET("/match/user/:userid/scheduled", controller.GetScheduledMatchesHandler(matchUtils))
+	}
+
 	return r
 }
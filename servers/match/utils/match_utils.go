@@ -11,23 +11,37 @@ import (
 	"match/schema"
 	"net/http"
 	"os"
+	"pagination"
 	"time"
 
 	"github.com/GiveGetGo/shared/types"
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	// ErrPostStatusConflict is returned when the post service refuses the status change, e.g. the post is no longer active
+	ErrPostStatusConflict = errors.New("post status change not allowed")
+	// ErrBidNotFound is returned when the bid service has no such bid
+	ErrBidNotFound = errors.New("bid not found")
+	// ErrPostNotFound is returned when the post service has no such post
+	ErrPostNotFound = errors.New("post not found")
+)
+
 type IMatchUtils interface {
-	CreateMatch(postID, postUserID, helperUserID uint) (schema.Match, error)
+	CreateMatch(post schema.PostResponse, postUserID, bidUserID uint) (schema.Match, error)
 	GetMatchByID(matchID uint) (schema.Match, error)
-	GetAllMatchesByUserID(userid uint) ([]schema.Match, error)
-	UpdatePostStatus(postID uint, status schema.PostStatus) error
+	GetAllMatchesByUserID(userid uint, page pagination.Page) ([]schema.Match, error)
+	UpdatePostStatus(postID uint, status schema.PostStatus, reason string) error
 	DeleteMatch(matchID uint) error
-	GetHelperUserID(c *gin.Context, bidId uint) (uint, error)
+	SetAgreedTime(matchID uint, agreedTime *time.Time) error
+	GetScheduledMatches(userID uint, since time.Time) ([]schema.Match, error)
+	GetBid(bidID uint) (schema.BidResponse, error)
+	AcceptBid(bidID uint) error
+	GetPostOwnerID(postID uint) (uint, error)
 	GetUserInfo(c *gin.Context) (types.UserInfoResponse, error)
 	CreateNotification(userID uint, notificationType types.NotificationType, post schema.PostResponse) error
 	GetPostByPostID(c *gin.Context, postID uint) (schema.PostResponse, error)
-	FormatNotificationDescription(post schema.PostResponse) string
+	FormatNotificationDescription(notificationType types.NotificationType, post schema.PostResponse) string
 }
 
 type MatchUtils struct {
@@ -43,13 +57,23 @@ func NewMatchUtils(DB db.Database, redisClient middleware.RedisClientInterface)
 	}
 }
 
-// func create new match
-func (mu *MatchUtils) CreateMatch(postID, postUserID, helperUserID uint) (schema.Match, error) {
+// func create new match, on an offer the post owner is the helper and the bidder the recipient
+func (mu *MatchUtils) CreateMatch(post schema.PostResponse, postUserID, bidUserID uint) (schema.Match, error) {
+	helperUserID, recipientUserID := bidUserID, postUserID
+	if post.Type == schema.OfferPost {
+		helperUserID, recipientUserID = postUserID, bidUserID
+	}
+
 	newMatch := schema.Match{
-		PostID:       postID,
-		PostUserID:   postUserID,
-		HelperUserID: helperUserID,
-		DateMatched:  time.Now(),
+		PostID:          post.PostID,
+		PostType:        post.Type,
+		PostUserID:      postUserID,
+		HelperUserID:    helperUserID,
+		RecipientUserID: recipientUserID,
+		DateMatched:     time.Now(),
+	}
+	if newMatch.PostType == "" {
+		newMatch.PostType = schema.RequestPost
 	}
 
 	// Create the match in the database
@@ -58,8 +82,6 @@ func (mu *MatchUtils) CreateMatch(postID, postUserID, helperUserID uint) (schema
 		return schema.Match{}, result.Error
 	}
 
-	// update post status to matched
-
 	return newMatch, nil
 }
 
@@ -74,10 +96,11 @@ func (mu *MatchUtils) GetMatchByID(matchID uint) (schema.Match, error) {
 	return match, nil
 }
 
-func (mu *MatchUtils) GetAllMatchesByUserID(userid uint) ([]schema.Match, error) {
+// GetAllMatchesByUserID retrieves a page of the matches the user is in, as helper or recipient
+func (mu *MatchUtils) GetAllMatchesByUserID(userid uint, page pagination.Page) ([]schema.Match, error) {
 	var matches []schema.Match
 
-	result := mu.DB.Where("user_id = ?", userid).Find(&matches)
+	result := page.Apply(mu.DB.Where("helper_user_id = ? OR recipient_user_id = ?", userid, userid)).Find(&matches)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -85,6 +108,28 @@ func (mu *MatchUtils) GetAllMatchesByUserID(userid uint) ([]schema.Match, error)
 	return matches, nil
 }
 
+// SetAgreedTime stores when the two sides of a match meet, nil clears it
+func (mu *MatchUtils) SetAgreedTime(matchID uint, agreedTime *time.Time) error {
+	return mu.DB.Where("match_id = ?", matchID).Model(&schema.Match{}).Update("agreed_time", agreedTime).Error
+}
+
+// maxScheduledMatches is how many scheduled matches one lookup returns
+const maxScheduledMatches = 500
+
+// GetScheduledMatches retrieves the user's matches with an agreed time from since on, soonest first
+func (mu *MatchUtils) GetScheduledMatches(userID uint, since time.Time) ([]schema.Match, error) {
+	matches := []schema.Match{}
+	err := mu.DB.Where("helper_user_id = ? OR recipient_user_id = ?", userID, userID).
+		Where("agreed_time >= ?", since).
+		Order("agreed_time").
+		Limit(maxScheduledMatches).
+		Find(&matches).Error
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
 func (mu *MatchUtils) DeleteMatch(matchID uint) error {
 	var match schema.Match
 	if result := mu.DB.First(&match, matchID); result.Error != nil {
@@ -98,21 +143,83 @@ func (mu *MatchUtils) DeleteMatch(matchID uint) error {
 	return nil
 }
 
-func (mu *MatchUtils) GetHelperUserID(c *gin.Context, bidId uint) (uint, error) {
-	bidServiceURL := fmt.Sprintf("%s/v1/bid/%d", os.Getenv("BID_SERVICE_URL"), bidId)
+// GetBid asks the bid service about a bid, the bidder is who the match pairs the post's owner with
+func (mu *MatchUtils) GetBid(bidID uint) (schema.BidResponse, error) {
+	bidServiceURL := fmt.Sprintf("%s/v1/internal/bid/%d", os.Getenv("BID_SERVICE_URL"), bidID)
+	req, err := http.NewRequest("GET", bidServiceURL, nil)
+	if err != nil {
+		return schema.BidResponse{}, err
+	}
+	req.Header.Set("X-Service", "MATCH")
+	req.Header.Set("X-Api-Key", os.Getenv("MATCH_API_KEY"))
 
-	// Extract the session cookie from the incoming request
-	cookie, err := c.Request.Cookie("givegetgo")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
 	if err != nil {
-		return 0, errors.New("session cookie is missing")
+		return schema.BidResponse{}, err
 	}
+	defer resp.Body.Close()
 
-	// Create a new request to the user service
-	req, err := http.NewRequest("GET", bidServiceURL, nil)
+	if resp.StatusCode == http.StatusNotFound {
+		return schema.BidResponse{}, ErrBidNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return schema.BidResponse{}, fmt.Errorf("bid service responded with status: %d", resp.StatusCode)
+	}
+
+	// the bid comes in the data of the shared response
+	var fullResponse types.FullResponseWithData
+	if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
+		return schema.BidResponse{}, err
+	}
+
+	jsonData, err := json.Marshal(fullResponse.Data)
+	if err != nil {
+		return schema.BidResponse{}, err
+	}
+
+	var bid schema.BidResponse
+	if err := json.Unmarshal(jsonData, &bid); err != nil {
+		return schema.BidResponse{}, err
+	}
+
+	return bid, nil
+}
+
+// AcceptBid tells the bid service the post was matched with the bid, so the bid is accepted and
+// the other bids on the post are rejected
+func (mu *MatchUtils) AcceptBid(bidID uint) error {
+	bidServiceURL := fmt.Sprintf("%s/v1/internal/bid/%d/accept", os.Getenv("BID_SERVICE_URL"), bidID)
+	req, err := http.NewRequest("PUT", bidServiceURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Service", "MATCH")
+	req.Header.Set("X-Api-Key", os.Getenv("MATCH_API_KEY"))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bid service responded with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetPostOwnerID asks the post service who owns a post
+func (mu *MatchUtils) GetPostOwnerID(postID uint) (uint, error) {
+	postServiceURL := fmt.Sprintf("%s/v1/internal/post/summaries?post_ids=%d", os.Getenv("POST_SERVICE_URL"), postID)
+	req, err := http.NewRequest("GET", postServiceURL, nil)
 	if err != nil {
 		return 0, err
 	}
-	req.Header.Set("Cookie", cookie.String()) // Forward the session cookie
+	req.Header.Set("X-Service", "MATCH")
+	req.Header.Set("X-Api-Key", os.Getenv("MATCH_API_KEY"))
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
@@ -121,18 +228,31 @@ func (mu *MatchUtils) GetHelperUserID(c *gin.Context, bidId uint) (uint, error)
 	}
 	defer resp.Body.Close()
 
-	// Check response status code
 	if resp.StatusCode != http.StatusOK {
-		return 0, errors.New("failed to verify session or session not found")
+		return 0, fmt.Errorf("post service responded with status: %d", resp.StatusCode)
 	}
 
-	// Decode the JSON response into a struct
-	var response schema.BidInfoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	var fullResponse types.FullResponseWithData
+	if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
 		return 0, err
 	}
 
-	return response.UserID, nil
+	jsonData, err := json.Marshal(fullResponse.Data)
+	if err != nil {
+		return 0, err
+	}
+
+	var summaries schema.PostSummariesResponse
+	if err := json.Unmarshal(jsonData, &summaries); err != nil {
+		return 0, err
+	}
+
+	for _, summary := range summaries.Posts {
+		if summary.PostID == postID {
+			return summary.UserID, nil
+		}
+	}
+	return 0, ErrPostNotFound
 }
 
 func (mu *MatchUtils) GetUserInfo(c *gin.Context) (types.UserInfoResponse, error) {
@@ -192,7 +312,7 @@ func (mu *MatchUtils) GetUserInfo(c *gin.Context) (types.UserInfoResponse, error
 }
 
 // UpdatePostStatus sends a request to an external service to update the status of a post
-func (mu *MatchUtils) UpdatePostStatus(postID uint, status schema.PostStatus) error {
+func (mu *MatchUtils) UpdatePostStatus(postID uint, status schema.PostStatus, reason string) error {
 	// Marshal the request body
 	updateReqBody, err := json.Marshal(schema.PostStatusUpdateRequest{
 		PostID: postID,
@@ -223,6 +343,9 @@ func (mu *MatchUtils) UpdatePostStatus(postID uint, status schema.PostStatus) er
 	defer resp.Body.Close()
 
 	// Check the response status
+	if resp.StatusCode == http.StatusConflict {
+		return ErrPostStatusConflict
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("post service responded with status: %d", resp.StatusCode)
 	}
@@ -231,7 +354,7 @@ func (mu *MatchUtils) UpdatePostStatus(postID uint, status schema.PostStatus) er
 }
 
 func (mu *MatchUtils) CreateNotification(userID uint, notificationType types.NotificationType, post schema.PostResponse) error {
-	description := mu.FormatNotificationDescription(post)
+	description := mu.FormatNotificationDescription(notificationType, post)
 
 	// Marshal the request body
 	notificationReqBody, err := json.Marshal(types.CreateNotificationRequest{
@@ -327,6 +450,17 @@ func (mu *MatchUtils) GetPostByPostID(c *gin.Context, postID uint) (schema.PostR
 	return postResponse, nil
 }
 
-func (mu *MatchUtils) FormatNotificationDescription(post schema.PostResponse) string {
-	return fmt.Sprintf("Match succeeded with %s for \"%s\". Click in to rate this match!", post.Username, post.Title)
+// FormatNotificationDescription describes the match to the post owner (NewMatch) or the bidder (BidMatch)
+func (mu *MatchUtils) FormatNotificationDescription(notificationType types.NotificationType, post schema.PostResponse) string {
+	offer := post.Type == schema.OfferPost
+	switch {
+	case notificationType == types.NewMatch && offer:
+		return fmt.Sprintf("Your offer \"%s\" has found a taker. Click in to rate this match!", post.Title)
+	case notificationType == types.NewMatch:
+		return fmt.Sprintf("Your request \"%s\" has found a helper. Click in to rate this match!", post.Title)
+	case offer:
+		return fmt.Sprintf("%s is giving you \"%s\". Click in to rate this match!", post.Username, post.Title)
+	default:
+		return fmt.Sprintf("Match succeeded with %s for \"%s\". Click in to rate this match!", post.Username, post.Title)
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"notification/schema"
 	"notification/utils"
+	"pagination"
 	"strconv"
 	"time"
 
@@ -14,6 +15,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// notificationPageOptions are the sorts and page sizes of the notification list, newest first by default
+var notificationPageOptions = pagination.Options{
+	DefaultLimit: 25,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"created_date": {Column: "created_date", Kind: pagination.TimeField},
+	},
+	DefaultSort: "-created_date",
+	IDColumn:    "notification_id",
+}
+
 // Public operations
 func GetNotification(notificationUtils utils.INotificationUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -23,22 +35,23 @@ func GetNotification(notificationUtils utils.INotificationUtils) gin.HandlerFunc
 			return
 		}
 
-		notifications, err := notificationUtils.GetNotificationByUserID(user.UserID)
+		page, err := pagination.Parse(c, notificationPageOptions)
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
-			} else {
-				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
-			}
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
-		if len(notifications) == 0 {
-			res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+		notifications, err := notificationUtils.GetNotificationByUserID(user.UserID, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
 		}
 
-		res.ResponseSuccessWithData(c, http.StatusOK, "Get user notification", types.Success(), notifications)
+		notifications, result := pagination.Paginate(notifications, page, func(notification schema.Notification, _ string) (interface{}, uint) {
+			return notification.CreatedDate, notification.NotificationID
+		})
+
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "Get user notification", types.Success(), notifications, result)
 	}
 }
 
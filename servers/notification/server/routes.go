@@ -33,7 +33,7 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 	{
 		defaultNotificationAuthGroup := notificationAuthGroup.Group("")
 		{
-			defaultNotificationAuthGroup.GET("/notfication", controller.GetNotification(notificationUtils))
+			defaultNotificationAuthGroup.GET("/notification", controller.GetNotification(notificationUtils))
 		}
 
 		sensitiveNotificationAuthGroup := notificationAuthGroup.Group("")
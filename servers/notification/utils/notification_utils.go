@@ -10,6 +10,7 @@ import (
 	"notification/middleware"
 	"notification/schema"
 	"os"
+	"pagination"
 	"time"
 
 	"github.com/GiveGetGo/shared/types"
@@ -18,7 +19,7 @@ import (
 )
 
 type INotificationUtils interface {
-	GetNotificationByUserID(userID uint) ([]schema.Notification, error)
+	GetNotificationByUserID(userID uint, page pagination.Page) ([]schema.Notification, error)
 	DeleteNotificationByID(notificationID uint) error
 	CreateNotification(notification schema.Notification) (*schema.Notification, error)
 	GetUserInfo(c *gin.Context) (types.UserInfoResponse, error)
@@ -40,10 +41,10 @@ func NewNotificationUtils(DB db.Database, redisClient middleware.RedisClientInte
 	}
 }
 
-// GetNotificationByUserID
-func (nu *NotificationUtils) GetNotificationByUserID(userID uint) ([]schema.Notification, error) {
+// GetNotificationByUserID - a page of the user's notifications
+func (nu *NotificationUtils) GetNotificationByUserID(userID uint, page pagination.Page) ([]schema.Notification, error) {
 	var notifications []schema.Notification
-	result := nu.DB.Where("user_id = ?", userID).Find(&notifications)
+	result := page.Apply(nu.DB.Where("user_id = ?", userID)).Find(&notifications)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -0,0 +1,229 @@
+// Package pagination implements keyset paging for list endpoints: opaque cursors,
+// bounded page sizes, whitelisted sort fields and the standard list response envelope.
+// It's a module of its own in the workspace so every service pages its lists the same way.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPage = errors.New("invalid limit, sort or cursor")
+
+// Kind is how a sort field's value is carried in a cursor
+type Kind int
+
+const (
+	TimeField Kind = iota
+	IntField
+	FloatField
+	StringField
+)
+
+// Field is a column a list may be sorted by. Column may also be an SQL expression
+// built by the service, never one taken from the request.
+type Field struct {
+	Column string
+	Kind   Kind
+}
+
+// Options describe how one list endpoint pages
+type Options struct {
+	DefaultLimit int
+	MaxLimit     int
+	Sorts        map[string]Field // sort names accepted in ?sort=, mapped to their columns
+	DefaultSort  string           // a sort name, prefixed with - for descending
+	IDColumn     string           // unique tiebreaker, rows with equal sort values are ordered by it
+}
+
+// Page is a parsed page request
+type Page struct {
+	Limit int
+	Sort  string
+	Desc  bool
+
+	field      Field
+	idColumn   string
+	after      bool
+	afterValue interface{}
+	afterID    uint
+}
+
+// Result is what the client needs to fetch the next page
+type Result struct {
+	NextCursor string
+	HasMore    bool
+}
+
+// Response is the shared response with data, plus the paging fields
+type Response struct {
+	types.FullResponseWithData
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// cursor is the position of the last row on a page, bound to the sort it was made for
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// ParseLimit reads ?limit=, falling back to the default and capping at the maximum
+func ParseLimit(c *gin.Context, defaultLimit, maxLimit int) (int, error) {
+	limitParam, ok := c.GetQuery("limit")
+	if !ok {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 1 {
+		return 0, ErrInvalidPage
+	}
+	return min(limit, maxLimit), nil
+}
+
+// Parse reads ?limit=, ?sort= and ?cursor= for a list endpoint.
+// A cursor is only valid with the sort it was issued for.
+func Parse(c *gin.Context, opts Options) (Page, error) {
+	limit, err := ParseLimit(c, opts.DefaultLimit, opts.MaxLimit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	sort := c.DefaultQuery("sort", opts.DefaultSort)
+	desc := strings.HasPrefix(sort, "-")
+	name := strings.TrimPrefix(sort, "-")
+	field, ok := opts.Sorts[name]
+	if !ok {
+		return Page{}, ErrInvalidPage
+	}
+
+	page := Page{
+		Limit:    limit,
+		Sort:     name,
+		Desc:     desc,
+		field:    field,
+		idColumn: opts.IDColumn,
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		after, err := decodeCursor(value)
+		if err != nil || after.Sort != sort {
+			return Page{}, ErrInvalidPage
+		}
+		if page.afterValue, err = field.parse(after.Value); err != nil {
+			return Page{}, ErrInvalidPage
+		}
+		page.after, page.afterID = true, after.ID
+	}
+
+	return page, nil
+}
+
+// Apply adds the cursor condition, the ordering and the limit to a query.
+// One extra row is fetched so Paginate can tell whether another page follows.
+func (p Page) Apply(query *gorm.DB) *gorm.DB {
+	direction, op := "ASC", ">"
+	if p.Desc {
+		direction, op = "DESC", "<"
+	}
+
+	if p.after {
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", p.field.Column, p.idColumn, op), p.afterValue, p.afterID)
+	}
+
+	return query.
+		Order(p.field.Column + " " + direction).
+		Order(p.idColumn + " " + direction).
+		Limit(p.Limit + 1)
+}
+
+// Paginate drops the extra row fetched by Apply and makes the cursor for the next page.
+// key returns an item's value for the page's sort and its ID.
+func Paginate[T any](items []T, page Page, key func(item T, sort string) (interface{}, uint)) ([]T, Result) {
+	if len(items) <= page.Limit {
+		return items, Result{}
+	}
+
+	items = items[:page.Limit]
+	value, id := key(items[len(items)-1], page.Sort)
+
+	sort := page.Sort
+	if page.Desc {
+		sort = "-" + sort
+	}
+	return items, Result{
+		NextCursor: encodeCursor(cursor{Sort: sort, Value: page.field.format(value), ID: id}),
+		HasMore:    true,
+	}
+}
+
+// ResponseSuccessWithPage is res.ResponseSuccessWithData for lists
+func ResponseSuccessWithPage(c *gin.Context, status int, event string, response types.Response, data interface{}, result Result) {
+	c.JSON(status, Response{
+		FullResponseWithData: types.FullResponseWithData{
+			FullResponse: types.FullResponse{
+				Event: event,
+				Code:  response.Code,
+				Msg:   response.Msg,
+			},
+			Data: data,
+		},
+		NextCursor: result.NextCursor,
+		HasMore:    result.HasMore,
+	})
+}
+
+func (f Field) format(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (f Field) parse(value string) (interface{}, error) {
+	switch f.Kind {
+	case TimeField:
+		return time.Parse(time.RFC3339Nano, value)
+	case IntField:
+		return strconv.ParseInt(value, 10, 64)
+	case FloatField:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidPage
+	}
+
+	var decoded cursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID == 0 {
+		return nil, ErrInvalidPage
+	}
+	return &decoded, nil
+}
@@ -0,0 +1,115 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ItemID  uint
+	Created time.Time
+}
+
+var testOptions = Options{
+	DefaultLimit: 2,
+	MaxLimit:     5,
+	Sorts: map[string]Field{
+		"created": {Column: "created", Kind: TimeField},
+		"item_id": {Column: "item_id", Kind: IntField},
+	},
+	DefaultSort: "-created",
+	IDColumn:    "item_id",
+}
+
+func itemKey(it item, sort string) (interface{}, uint) {
+	if sort == "item_id" {
+		return it.ItemID, it.ItemID
+	}
+	return it.Created, it.ItemID
+}
+
+func newTestContext(query string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/items?"+query, nil)
+	return c
+}
+
+func TestParse(t *testing.T) {
+	page, err := Parse(newTestContext(""), testOptions)
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Limit)
+	assert.Equal(t, "created", page.Sort)
+	assert.True(t, page.Desc)
+
+	page, err = Parse(newTestContext("limit=50&sort=item_id"), testOptions)
+	require.NoError(t, err)
+	assert.Equal(t, 5, page.Limit)
+	assert.False(t, page.Desc)
+
+	for _, query := range []string{"limit=0", "limit=abc", "sort=password", "cursor=garbage"} {
+		_, err := Parse(newTestContext(query), testOptions)
+		assert.ErrorIs(t, err, ErrInvalidPage, query)
+	}
+}
+
+func TestPaginateCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	items := []item{
+		{ItemID: 9, Created: created.Add(2 * time.Hour)},
+		{ItemID: 4, Created: created},
+		{ItemID: 3, Created: created},
+	}
+
+	page, err := Parse(newTestContext(""), testOptions)
+	require.NoError(t, err)
+
+	pageItems, result := Paginate(items, page, itemKey)
+	assert.Len(t, pageItems, 2)
+	assert.True(t, result.HasMore)
+	require.NotEmpty(t, result.NextCursor)
+
+	// the next page continues after the last item of this one
+	next, err := Parse(newTestContext("cursor="+result.NextCursor), testOptions)
+	require.NoError(t, err)
+	assert.Equal(t, created, next.afterValue)
+	assert.Equal(t, uint(4), next.afterID)
+
+	// a cursor can't be replayed against another sort
+	_, err = Parse(newTestContext("sort=item_id&cursor="+result.NextCursor), testOptions)
+	assert.ErrorIs(t, err, ErrInvalidPage)
+
+	// the last page has no cursor
+	pageItems, result = Paginate(items[2:], next, itemKey)
+	assert.Len(t, pageItems, 1)
+	assert.False(t, result.HasMore)
+	assert.Empty(t, result.NextCursor)
+}
+
+func TestApply(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB, DriverName: "postgres"}), &gorm.Config{})
+	require.NoError(t, err)
+
+	created := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	page := Page{Limit: 2, Sort: "created", Desc: true, field: testOptions.Sorts["created"], idColumn: "item_id",
+		after: true, afterValue: created, afterID: 4}
+
+	mock.ExpectQuery(`SELECT \* FROM "items" WHERE owner_id = \$1 AND \(created, item_id\) < \(\$2, \$3\) ORDER BY created DESC,item_id DESC LIMIT \$4`).
+		WithArgs(1, created, 4, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"item_id", "created"}))
+
+	var items []item
+	require.NoError(t, page.Apply(db.Where("owner_id = ?", 1)).Find(&items).Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
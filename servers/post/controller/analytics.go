@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"post/utils"
+	"strconv"
+	"time"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPostAnalyticsHandler shows the owner how their post is doing, day by day over the last ?days=
+// (30 by default, at most 90). Views show up once the flush worker has written them.
+func GetPostAnalyticsHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days := 30
+		if dayParam, ok := c.GetQuery("days"); ok {
+			day, err := strconv.Atoi(dayParam)
+			if err != nil || day < 1 || day > 90 {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+			days = day
+		}
+
+		post, ok := ownedPost(c, postUtils)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		since := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+
+		views, err := postUtils.GetViewStats(post.PostID, since)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		bookmarks, err := postUtils.CountBookmarks([]uint{post.PostID})
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		dailyBookmarks, err := postUtils.GetDailyBookmarks(post.PostID, since)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		bidDates, err := postUtils.GetBidDates(post.PostID)
+		if err != nil {
+			log.Printf("Error fetching the bid dates of post %d: %v", post.PostID, err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		analytics := utils.BuildPostAnalytics(post, since, now, views, bookmarks[post.PostID], dailyBookmarks, bidDates)
+		res.ResponseSuccessWithData(c, http.StatusOK, "get post analytics", types.Success(), analytics)
+	}
+}
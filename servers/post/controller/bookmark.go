@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"pagination"
+	"post/schema"
+	"post/utils"
+	"strconv"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// bookmarkPageOptions pages a user's bookmarks, the most recently bookmarked first by default
+var bookmarkPageOptions = pagination.Options{
+	DefaultLimit: 25,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"date_bookmarked": {Column: "bookmarks.date_created", Kind: pagination.TimeField},
+	},
+	DefaultSort: "-date_bookmarked",
+	IDColumn:    "posts.post_id",
+}
+
+// AddBookmarkHandler saves a post to the caller's bookmarks
+func AddBookmarkHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		post, user, ok := bookmarkablePost(c, postUtils)
+		if !ok {
+			return
+		}
+
+		if err := postUtils.AddBookmark(user.UserID, post.PostID); err != nil {
+			bookmarkError(c, err)
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusCreated, "add bookmark", types.Success())
+	}
+}
+
+// RemoveBookmarkHandler takes a post out of the caller's bookmarks
+func RemoveBookmarkHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		if err := postUtils.RemoveBookmark(user.UserID, uint(postID)); err != nil {
+			bookmarkError(c, err)
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "remove bookmark", types.Success())
+	}
+}
+
+// GetBookmarksHandler lists the posts the caller bookmarked
+func GetBookmarksHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		page, err := pagination.Parse(c, bookmarkPageOptions)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		posts, err := postUtils.GetBookmarkedPosts(user.UserID, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		responsePosts(c, postUtils, "get bookmarks", posts, page)
+	}
+}
+
+// bookmarkablePost loads the post in the :id parameter, which the caller can bookmark unless it's
+// their own, hidden or a draft, writing the error response otherwise
+func bookmarkablePost(c *gin.Context, postUtils utils.IPostUtils) (schema.Post, schema.UserInfoResponse, bool) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return schema.Post{}, schema.UserInfoResponse{}, false
+	}
+
+	user, err := postUtils.GetUserInfo(c)
+	if err != nil {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return schema.Post{}, schema.UserInfoResponse{}, false
+	}
+
+	post, err := postUtils.GetPostByID(uint(postID))
+	if err == nil && (post.Status == schema.Hidden || post.Status == schema.Draft) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		bookmarkError(c, err)
+		return schema.Post{}, schema.UserInfoResponse{}, false
+	}
+
+	if post.UserID == user.UserID {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return schema.Post{}, schema.UserInfoResponse{}, false
+	}
+
+	return post, user, true
+}
+
+func bookmarkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+	case errors.Is(err, utils.ErrAlreadyBookmarked):
+		res.ResponseError(c, http.StatusConflict, schema.Conflict())
+	default:
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+	}
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"post/schema"
+	"post/utils"
+	"strconv"
+	"time"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetCategoriesHandler lists the active categories as a tree with their active post counts
+func GetCategoriesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categoryTree(c, postUtils, false)
+	}
+}
+
+// AdminGetCategoriesHandler lists every category, including inactive ones
+func AdminGetCategoriesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := requireRole(c, postUtils, schema.AdminRole); !ok {
+			return
+		}
+
+		categoryTree(c, postUtils, true)
+	}
+}
+
+func AdminAddCategoryHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := requireRole(c, postUtils, schema.AdminRole); !ok {
+			return
+		}
+
+		var req schema.CategoryRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		category := schema.Category{
+			Active:      true,
+			DateCreated: time.Now(),
+		}
+		if !applyCategoryRequest(c, &category, req) {
+			return
+		}
+
+		if _, err := postUtils.GetCategoryBySlug(category.Slug); err == nil {
+			res.ResponseError(c, http.StatusBadRequest, types.AlreadyExists())
+			return
+		}
+
+		category, err := postUtils.AddCategory(category)
+		if err != nil {
+			categoryError(c, err)
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusCreated, "add category", types.Success(), category.CategoryID)
+	}
+}
+
+func AdminEditCategoryHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := requireRole(c, postUtils, schema.AdminRole); !ok {
+			return
+		}
+
+		categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		var req schema.CategoryRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		category, err := postUtils.GetCategoryByID(uint(categoryID))
+		if err != nil {
+			categoryError(c, err)
+			return
+		}
+
+		if !applyCategoryRequest(c, &category, req) {
+			return
+		}
+
+		if existing, err := postUtils.GetCategoryBySlug(category.Slug); err == nil && existing.CategoryID != category.CategoryID {
+			res.ResponseError(c, http.StatusBadRequest, types.AlreadyExists())
+			return
+		}
+
+		if err := postUtils.UpdateCategory(category); err != nil {
+			categoryError(c, err)
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "edit category", types.Success())
+	}
+}
+
+func AdminDeleteCategoryHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := requireRole(c, postUtils, schema.AdminRole); !ok {
+			return
+		}
+
+		categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		if err := postUtils.DeleteCategory(uint(categoryID)); err != nil {
+			categoryError(c, err)
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "delete category", types.Success())
+	}
+}
+
+func categoryTree(c *gin.Context, postUtils utils.IPostUtils, includeInactive bool) {
+	categories, err := postUtils.GetCategories(includeInactive)
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	counts, err := postUtils.CountActivePostsByCategory()
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	res.ResponseSuccessWithData(c, http.StatusOK, "get categories", types.Success(), utils.BuildCategoryTree(categories, counts))
+}
+
+// applyCategoryRequest copies the request onto the category, deriving the slug from the name when none is given
+func applyCategoryRequest(c *gin.Context, category *schema.Category, req schema.CategoryRequest) bool {
+	slug := req.Slug
+	if slug == "" {
+		slug = schema.Slugify(req.Name)
+	}
+	if !utils.ValidCategorySlug(slug) || req.DefaultLifetimeDays < 0 {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return false
+	}
+
+	category.ParentID = req.ParentID
+	category.Slug = slug
+	category.Name = req.Name
+	category.Icon = req.Icon
+	category.SortOrder = req.SortOrder
+	category.DefaultLifetimeDays = req.DefaultLifetimeDays
+	category.DateUpdated = time.Now()
+	if req.Active != nil {
+		category.Active = *req.Active
+	}
+
+	return true
+}
+
+func categoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+	case errors.Is(err, utils.ErrInvalidCategory), errors.Is(err, utils.ErrCategoryCycle):
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+	case errors.Is(err, utils.ErrCategoryInUse):
+		res.ResponseError(c, http.StatusConflict, schema.Conflict())
+	default:
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+	}
+}
+
+// resolveCategory maps a post's category to the registry, writing the error response on failure
+func resolveCategory(c *gin.Context, postUtils utils.IPostUtils, value string) (schema.Category, bool) {
+	category, err := postUtils.ResolveCategory(value)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCategory) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		} else {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		}
+		return schema.Category{}, false
+	}
+
+	return category, true
+}
+
+// requireRole checks the caller holds one of the roles, writing the error response otherwise
+func requireRole(c *gin.Context, postUtils utils.IPostUtils, roles ...string) (schema.UserInfoResponse, bool) {
+	user, err := postUtils.GetUserInfo(c)
+	if err != nil {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return schema.UserInfoResponse{}, false
+	}
+
+	for _, role := range roles {
+		if user.Role == role {
+			return user, true
+		}
+	}
+
+	res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+	return schema.UserInfoResponse{}, false
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"post/schema"
+	"post/utils"
+	"strconv"
+	"time"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RenewPostHandler lets the owner extend an active post or reopen an expired one.
+// Without an expires_at in the body the post gets a fresh category lifetime.
+func RenewPostHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		var req schema.PostRenewRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.BindJSON(&req); err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+		}
+
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		post, err := postUtils.GetPostByID(uint(postID))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+
+		if post.UserID != user.UserID {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+
+		category, err := postUtils.GetCategoryBySlug(post.Category)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		expiresAt, ok := resolveExpiry(c, postUtils, category, req.ExpiresAt)
+		if !ok {
+			return
+		}
+
+		if err := postUtils.RenewPost(post.PostID, user.UserID, expiresAt); err != nil {
+			if errors.Is(err, utils.ErrPostNotRenewable) || errors.Is(err, utils.ErrIllegalTransition) {
+				res.ResponseError(c, http.StatusConflict, schema.Conflict())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "renew post", types.Success(), gin.H{"expires_at": expiresAt})
+	}
+}
+
+// resolveExpiry picks the post's expiry, writing the error response on failure
+func resolveExpiry(c *gin.Context, postUtils utils.IPostUtils, category schema.Category, requested *time.Time) (time.Time, bool) {
+	expiresAt, err := postUtils.ResolveExpiry(category, requested)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidExpiry) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		} else {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		}
+		return time.Time{}, false
+	}
+
+	return expiresAt, true
+}
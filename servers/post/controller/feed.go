@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"net/http"
+	"pagination"
+	"post/schema"
+	"post/utils"
+	"time"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultFeedLimit = 25
+	maxFeedLimit     = 100
+)
+
+// rankedFeed writes a page of the recent posts ranked for the caller. The cursor carries the time the
+// first page was ranked at, so the following pages continue the same ranking.
+func rankedFeed(c *gin.Context, postUtils utils.IPostUtils, days int, filter schema.PostFilter) {
+	viewer, err := postUtils.GetUserInfo(c)
+	if err != nil {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return
+	}
+
+	limit, err := pagination.ParseLimit(c, defaultFeedLimit, maxFeedLimit)
+	if err != nil {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return
+	}
+
+	rankedAt := time.Now()
+	var after *schema.FeedCursor
+	if cursor := c.Query("cursor"); cursor != "" {
+		if after, err = utils.DecodeFeedCursor(cursor); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+		rankedAt = time.Unix(after.RankedAt, 0)
+	}
+
+	candidates, err := postUtils.GetFeedCandidates(days, filter)
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	// posts made after the first page was ranked wait for the next fresh feed
+	if after != nil {
+		kept := candidates[:0]
+		for _, post := range candidates {
+			if !post.DatePosted.After(rankedAt) {
+				kept = append(kept, post)
+			}
+		}
+		candidates = kept
+	}
+
+	ranked, hasMore := utils.PageRankedFeed(utils.RankFeed(postUtils, viewer, candidates, rankedAt), after, limit)
+
+	var result pagination.Result
+	if hasMore {
+		result = pagination.Result{NextCursor: utils.EncodeFeedCursor(ranked[len(ranked)-1], rankedAt), HasMore: true}
+	}
+
+	posts := make([]schema.Post, 0, len(ranked))
+	for _, post := range ranked {
+		posts = append(posts, post.Post)
+	}
+	responsePosts, err := buildPostResponses(postUtils, posts)
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	responseRanked := []schema.RankedPostResponse{}
+	for i, post := range ranked {
+		responseRanked = append(responseRanked, schema.RankedPostResponse{
+			PostResponse: responsePosts[i],
+			Score:        post.Score,
+		})
+	}
+
+	pagination.ResponseSuccessWithPage(c, http.StatusOK, "Post retrieved", types.Success(), responseRanked, result)
+}
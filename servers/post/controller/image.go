@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"post/schema"
+	"post/utils"
+	"strconv"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AddPostImagesHandler attaches the images uploaded in the multipart "images" field to the owner's post
+func AddPostImagesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		post, ok := ownedPost(c, postUtils)
+		if !ok {
+			return
+		}
+
+		limits := postUtils.ImageLimits()
+		// every image at its largest, plus room for the multipart framing
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(limits.MaxPerPost)*limits.MaxBytes+1<<20)
+
+		form, err := c.MultipartForm()
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+		files := form.File["images"]
+		if len(files) == 0 {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		count, err := postUtils.CountPostImages(post.PostID)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+		if count+int64(len(files)) > int64(limits.MaxPerPost) {
+			res.ResponseError(c, http.StatusConflict, schema.Conflict())
+			return
+		}
+
+		// validate everything before storing anything
+		processed := make([]utils.ProcessedImage, 0, len(files))
+		for _, header := range files {
+			file, err := header.Open()
+			if err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+			data, err := io.ReadAll(io.LimitReader(file, limits.MaxBytes+1))
+			file.Close()
+			if err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+
+			image, err := utils.ProcessImage(data, limits)
+			if err != nil {
+				if errors.Is(err, utils.ErrImageTooLarge) {
+					res.ResponseError(c, http.StatusRequestEntityTooLarge, types.InvalidRequest())
+				} else if errors.Is(err, utils.ErrInvalidImage) {
+					res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				} else {
+					res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				}
+				return
+			}
+			processed = append(processed, image)
+		}
+
+		images := []schema.PostImage{}
+		for _, image := range processed {
+			added, err := postUtils.AddPostImage(c.Request.Context(), post.PostID, image)
+			if err != nil {
+				log.Printf("Error adding image to post %d: %v", post.PostID, err)
+				if errors.Is(err, utils.ErrTooManyImages) {
+					res.ResponseError(c, http.StatusConflict, schema.Conflict())
+				} else {
+					res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				}
+				return
+			}
+			images = append(images, added)
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusCreated, "add post images", types.Success(), toImageResponses(postUtils, images))
+	}
+}
+
+// DeletePostImageHandler removes one image from the owner's post
+func DeletePostImageHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		imageID, err := strconv.ParseUint(c.Param("imageid"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		post, ok := ownedPost(c, postUtils)
+		if !ok {
+			return
+		}
+
+		if err := postUtils.DeletePostImage(c.Request.Context(), post.PostID, uint(imageID)); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "delete post image", types.Success())
+	}
+}
+
+func toImageResponses(postUtils utils.IPostUtils, images []schema.PostImage) []schema.PostImageResponse {
+	responses := []schema.PostImageResponse{}
+	for _, image := range images {
+		responses = append(responses, schema.PostImageResponse{
+			ImageID:      image.ImageID,
+			URL:          postUtils.ImageURL(image.Key),
+			ThumbnailURL: postUtils.ImageURL(image.ThumbnailKey),
+			Width:        image.Width,
+			Height:       image.Height,
+		})
+	}
+	return responses
+}
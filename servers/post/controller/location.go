@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"net/http"
+	"post/utils"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCampusBuildingsHandler lists the campus buildings posts can use as their pickup spot
+func GetCampusBuildingsHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res.ResponseSuccessWithData(c, http.StatusOK, "get campus buildings", types.Success(), postUtils.CampusBuildings())
+	}
+}
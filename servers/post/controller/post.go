@@ -3,10 +3,14 @@ package controller
 import (
 	"errors"
 	"log"
+	"maps"
 	"net/http"
+	"pagination"
 	"post/schema"
 	"post/utils"
+	"screening"
 	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -18,56 +22,124 @@ import (
 
 func AddPostHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var req types.PostRequest
+		var req schema.PostRequest
 		if err := c.BindJSON(&req); err != nil {
 			log.Printf("Error binding JSON: %v", err)
 			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
+		if err := utils.NormalizePostType(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		flagged, ok := screenPostRequest(c, postUtils, &req)
+		if !ok {
+			return
+		}
+
 		user, err := postUtils.GetUserInfo(c)
 		if err != nil {
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
 		}
 
+		category, ok := resolveCategory(c, postUtils, req.Category)
+		if !ok {
+			return
+		}
+
+		expiresAt, ok := resolveExpiry(c, postUtils, category, req.ExpiresAt)
+		if !ok {
+			return
+		}
+
+		// a request is no use after it's needed, unless the owner chose the expiry themselves
+		if req.ExpiresAt == nil && req.NeededBy != nil && req.NeededBy.Before(expiresAt) {
+			expiresAt = *req.NeededBy
+		}
+
+		// a draft, scheduled or not, stays out of every listing until it's published
+		status := schema.Active
+		if req.Draft || req.PublishAt != nil {
+			status = schema.Draft
+		}
+		if req.PublishAt != nil {
+			if err := postUtils.ValidatePublishAt(*req.PublishAt); err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+		}
+
 		// Create a schema.Post object from the request
 		post := schema.Post{
 			UserID:      user.UserID,
 			Username:    user.Username,
 			Title:       req.Title,
 			Description: req.Description,
-			Category:    req.Category,
-			Status:      schema.Active,
+			Category:    category.Slug,
+			Type:        req.Type,
+			Quantity:    req.Quantity,
+			NeededBy:    req.NeededBy,
+			Status:      status,
 			DatePosted:  time.Now(),
 			DateUpdated: time.Now(),
+			ExpiresAt:   expiresAt,
+			PublishAt:   req.PublishAt,
+		}
+
+		if err := postUtils.ApplyLocation(&post, req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
 		}
 
 		// Add the post using the post utilities
-		_, err = postUtils.AddPost(post)
+		post, err = postUtils.AddPost(post)
 		if err != nil {
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
 		}
 
+		flagForModeration(postUtils, post.PostID, flagged)
+
+		// subscribers are alerted in the background so matching doesn't slow down posting,
+		// drafts are matched once they're published
+		if post.Status == schema.Active {
+			go utils.MatchSavedSearches(postUtils, post)
+		}
+
 		// Return the success response with post creation details
 		//Use UserCreated() before pushing my shared document -> change to PostCreated() after
 		res.ResponseSuccess(c, http.StatusCreated, "post", types.PostCreated())
 	}
 }
 
+// postPageOptions are the sorts and page sizes shared by the post lists
+var postPageOptions = pagination.Options{
+	DefaultLimit: 25,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"date_posted":  {Column: "date_posted", Kind: pagination.TimeField},
+		"date_updated": {Column: "date_updated", Kind: pagination.TimeField},
+		"expires_at":   {Column: "expires_at", Kind: pagination.TimeField},
+		"title":        {Column: "title", Kind: pagination.StringField},
+	},
+	DefaultSort: "-date_posted",
+	IDColumn:    "post_id",
+}
+
 // GetPostHandler retrieve post from recent
 func GetPostHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Set default values
 		days := 14 // two weeks
-		var count int
 
 		// Check if day is specified in the query
 		if dayParam, ok := c.GetQuery("day"); ok {
 			day, err := strconv.Atoi(dayParam)
 			if err != nil {
-				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 				return
 			}
 			if day < 1 {
@@ -79,41 +151,31 @@ func GetPostHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 			}
 		}
 
-		// Check if count is specified in the query
-		if countParam, ok := c.GetQuery("count"); ok {
-			cnt, err := strconv.Atoi(countParam)
-			if err != nil {
-				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
-				return
-			}
-			if cnt > 0 {
-				count = cnt
-			}
+		filter, ok := parsePostFilter(c, postUtils)
+		if !ok {
+			return
 		}
 
-		// Retrieve posts from the recent days
-		posts, err := postUtils.GetRecentPosts(days, count)
+		// the personalized feed, the chronological one stays the default
+		if c.Query("sort") == "relevance" {
+			rankedFeed(c, postUtils, days, filter)
+			return
+		}
+
+		page, err := pagination.Parse(c, postPageOptionsFor(postUtils, filter))
 		if err != nil {
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
-		// Convert posts to the response structure
-		var responsePosts []schema.PostResponse
-		for _, post := range posts {
-			responsePosts = append(responsePosts, schema.PostResponse{
-				PostID:      post.PostID,
-				Title:       post.Title,
-				Description: post.Description,
-				Category:    post.Category,
-				Username:    post.Username,
-				DatePosted:  post.DatePosted,
-				Status:      post.Status,
-			})
+		// Retrieve posts from the recent days
+		posts, err := postUtils.GetRecentPosts(days, filter, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
 		}
 
-		// Return the success response with post details
-		res.ResponseSuccessWithData(c, http.StatusOK, "Post retrieved", types.Success(), responsePosts)
+		responsePosts(c, postUtils, "Post retrieved", posts, page)
 	}
 }
 
@@ -121,7 +183,6 @@ func GetPostArchiveHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Set default values
 		days := 28 // four weeks
-		var count int
 
 		// Check if day is specified in the query
 		if dayParam, ok := c.GetQuery("day"); ok {
@@ -137,41 +198,25 @@ func GetPostArchiveHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 			}
 		}
 
-		// Check if count is specified in the query
-		if countParam, ok := c.GetQuery("count"); ok {
-			cnt, err := strconv.Atoi(countParam)
-			if err != nil {
-				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
-				return
-			}
-			if cnt > 0 {
-				count = cnt
-			}
+		filter, ok := parsePostFilter(c, postUtils)
+		if !ok {
+			return
 		}
 
-		// Retrieve posts from the recent days
-		posts, err := postUtils.GetArchivePosts(days, count)
+		page, err := pagination.Parse(c, postPageOptionsFor(postUtils, filter))
 		if err != nil {
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
-		// Convert posts to the response structure
-		var responsePosts []schema.PostResponse
-		for _, post := range posts {
-			responsePosts = append(responsePosts, schema.PostResponse{
-				PostID:      post.PostID,
-				Title:       post.Title,
-				Description: post.Description,
-				Category:    post.Category,
-				Username:    post.Username,
-				DatePosted:  post.DatePosted,
-				Status:      post.Status,
-			})
+		// Retrieve posts from the recent days
+		posts, err := postUtils.GetArchivePosts(days, filter, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
 		}
 
-		// Return the success response with post details
-		res.ResponseSuccessWithData(c, http.StatusOK, "Post retrieved", types.Success(), responsePosts)
+		responsePosts(c, postUtils, "Post retrieved", posts, page)
 	}
 }
 
@@ -194,15 +239,30 @@ func GetPostByPostIdHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 			return
 		}
 
-		responsePost := schema.PostResponse{
-			PostID:      post.PostID,
-			Title:       post.Title,
-			Description: post.Description,
-			Category:    post.Category,
-			Username:    post.Username,
-			DatePosted:  post.DatePosted,
-			Status:      post.Status,
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		if !postVisible(post, user) {
+			res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			return
+		}
+
+		// the owner looking at their own post isn't a view
+		if post.UserID != user.UserID {
+			if err := postUtils.RecordView(c.Request.Context(), post.PostID, user.UserID, time.Now()); err != nil {
+				log.Printf("Error recording a view of post %d: %v", post.PostID, err)
+			}
+		}
+
+		responsePosts, err := buildPostResponses(postUtils, []schema.Post{post})
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
 		}
+		responsePost := responsePosts[0]
 
 		res.ResponseSuccessWithData(c, http.StatusOK, "Post Retrieved", types.Success(), responsePost)
 	}
@@ -217,68 +277,95 @@ func GetPostByUserIdHandler(postutils utils.IPostUtils) gin.HandlerFunc {
 			return
 		}
 
-		posts, err := postutils.GetPostByUserID(user.UserID)
+		filter, ok := parsePostFilter(c, postutils)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.Parse(c, postPageOptionsFor(postutils, filter))
 		if err != nil {
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
-		// Convert posts to the response structure
-		var responsePosts []schema.PostResponse
-		for _, post := range posts {
-			responsePosts = append(responsePosts, schema.PostResponse{
-				PostID:      post.PostID,
-				Title:       post.Title,
-				Description: post.Description,
-				Category:    post.Category,
-				Username:    post.Username,
-				DatePosted:  post.DatePosted,
-				Status:      post.Status,
-			})
+		posts, err := postutils.GetPostByUserID(user.UserID, filter, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
 		}
 
-		res.ResponseSuccessWithData(c, http.StatusOK, "get post by user", types.Success(), responsePosts)
+		responsePosts(c, postutils, "get post by user", posts, page)
 	}
 }
 
 func EditPostByIdHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		postIDParam := c.Param("id")
-		postID, err := strconv.ParseUint(postIDParam, 10, 32)
-		if err != nil {
-			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		post, ok := ownedPost(c, postUtils)
+		if !ok {
 			return
 		}
 
-		var updateReq types.PostRequest
+		var updateReq schema.PostRequest
 		if err := c.BindJSON(&updateReq); err != nil {
 			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
-		err = postUtils.UpdatePost(uint(postID), updateReq)
+		if err := utils.NormalizePostType(&updateReq); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		flagged, ok := screenPostRequest(c, postUtils, &updateReq)
+		if !ok {
+			return
+		}
+
+		category, ok := resolveCategory(c, postUtils, updateReq.Category)
+		if !ok {
+			return
+		}
+		updateReq.Category = category.Slug
+
+		// only a new expiry chosen by the owner moves it, editing alone doesn't
+		if updateReq.ExpiresAt != nil {
+			expiresAt, ok := resolveExpiry(c, postUtils, category, updateReq.ExpiresAt)
+			if !ok {
+				return
+			}
+			updateReq.ExpiresAt = &expiresAt
+		}
+
+		post, revision, err := postUtils.UpdatePost(post.PostID, post.UserID, updateReq)
 		if err != nil {
-			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			if errors.Is(err, utils.ErrInvalidLocation) {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
 			return
 		}
 
+		// bidders and bookmarkers hear about the change without holding up the owner
+		if revision.Revision > 0 {
+			go utils.NotifyPostEdited(postUtils, post, revision)
+		}
+		flagForModeration(postUtils, post.PostID, flagged)
+
 		res.ResponseSuccess(c, http.StatusOK, "edit post", types.Success())
 	}
 }
 
 func DeletePostHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extract post ID from query parameter
-		postIDParam := c.Param("id")
-		postID, err := strconv.ParseUint(postIDParam, 10, 32)
-		if err != nil {
-			log.Printf("Error converting postid to uint: %v", err)
-			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		// only the owner may delete a post, its images, bookmarks and views go with it
+		post, ok := ownedPost(c, postUtils)
+		if !ok {
 			return
 		}
 
 		// Attempt to delete the post using the post utilities
-		err = postUtils.DeletePost(uint(postID))
+		err := postUtils.DeletePost(post.PostID)
 		if err != nil {
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
@@ -289,28 +376,305 @@ func DeletePostHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
 	}
 }
 
-func UpdatePostStatusHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Parse the request body
-		var updateReq schema.PostStatusUpdateRequest
-		if err := c.BindJSON(&updateReq); err != nil {
+// screenPostRequest normalizes the title and description and checks them against the screening rules,
+// writing the error response when they're refused. It returns what a moderator should look at.
+func screenPostRequest(c *gin.Context, postUtils utils.IPostUtils, req *schema.PostRequest) ([]screening.MatchType, bool) {
+	fields := []struct {
+		text  *string
+		limit screening.Limit
+	}{
+		{&req.Title, utils.TitleLimit},
+		{&req.Description, utils.DescriptionLimit},
+	}
+
+	var flagged []screening.MatchType
+	for _, field := range fields {
+		result, err := postUtils.ScreenText(*field.text, field.limit)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, schema.ContentRejected(err))
+			return nil, false
+		}
+		*field.text = result.Text
+		flagged = append(flagged, result.Types(screening.Flag)...)
+	}
+
+	return flagged, true
+}
+
+// flagForModeration queues a post the screening flagged for review, the post itself is already saved
+func flagForModeration(postUtils utils.IPostUtils, postID uint, flagged []screening.MatchType) {
+	if len(flagged) == 0 {
+		return
+	}
+	if err := postUtils.FlagContent(schema.PostReportTarget, postID, flagged); err != nil && !errors.Is(err, utils.ErrDuplicateReport) {
+		log.Printf("Error flagging post %d for moderation: %v", postID, err)
+	}
+}
+
+// parsePostFilter reads the ?type= and location list filters, writing the error response on failure
+func parsePostFilter(c *gin.Context, postUtils utils.IPostUtils) (schema.PostFilter, bool) {
+	filter := schema.PostFilter{Type: schema.PostType(c.Query("type"))}
+	if filter.Type != "" && !utils.ValidPostType(filter.Type) {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return schema.PostFilter{}, false
+	}
+
+	var ok bool
+	if filter.Near, filter.RadiusKm, ok = parseNear(c, postUtils); !ok {
+		return schema.PostFilter{}, false
+	}
+
+	return filter, true
+}
+
+// parseNear reads the point to search around, either ?lat=&lon= or a campus building in ?near=,
+// and the ?radius_km= around it. No point means no location filter.
+func parseNear(c *gin.Context, postUtils utils.IPostUtils) (*schema.GeoPoint, float64, bool) {
+	limits := postUtils.LocationLimits()
+
+	var point *schema.GeoPoint
+	latParam, hasLat := c.GetQuery("lat")
+	lonParam, hasLon := c.GetQuery("lon")
+	building := c.Query("near")
+	switch {
+	case building != "":
+		found, ok := postUtils.CampusBuilding(building)
+		if !ok || hasLat || hasLon {
 			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
-			return
+			return nil, 0, false
+		}
+		point = &schema.GeoPoint{Latitude: found.Latitude, Longitude: found.Longitude}
+	case hasLat || hasLon:
+		lat, latErr := strconv.ParseFloat(latParam, 64)
+		lon, lonErr := strconv.ParseFloat(lonParam, 64)
+		if latErr != nil || lonErr != nil || !utils.ValidGeoPoint(lat, lon) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return nil, 0, false
 		}
+		point = &schema.GeoPoint{Latitude: lat, Longitude: lon}
+	default:
+		return nil, 0, true
+	}
 
-		// Validate the new status
-		if updateReq.Status != schema.Active && updateReq.Status != schema.Matched &&
-			updateReq.Status != schema.Closed && updateReq.Status != schema.Expired {
+	radius := limits.DefaultRadiusKm
+	if radiusParam, ok := c.GetQuery("radius_km"); ok {
+		parsed, err := strconv.ParseFloat(radiusParam, 64)
+		if err != nil || !(parsed > 0) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return nil, 0, false
+		}
+		radius = min(parsed, limits.MaxRadiusKm)
+	}
+
+	return point, radius, true
+}
+
+// postPageOptionsFor adds sorting by distance when the list is filtered around a point
+func postPageOptionsFor(postUtils utils.IPostUtils, filter schema.PostFilter) pagination.Options {
+	if filter.Near == nil {
+		return postPageOptions
+	}
+
+	options := postPageOptions
+	options.Sorts = maps.Clone(postPageOptions.Sorts)
+	options.Sorts["distance"] = pagination.Field{Column: postUtils.DistanceSQL(*filter.Near), Kind: pagination.FloatField}
+	return options
+}
+
+// responsePosts writes a page of posts in the list envelope
+func responsePosts(c *gin.Context, postUtils utils.IPostUtils, event string, posts []schema.Post, page pagination.Page) {
+	posts, result := pagination.Paginate(posts, page, postSortKey)
+
+	responsePosts, err := buildPostResponses(postUtils, posts)
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	pagination.ResponseSuccessWithPage(c, http.StatusOK, event, types.Success(), responsePosts, result)
+}
+
+// postSortKey is a post's position in a list sorted by one of postPageOptions' sorts
+func postSortKey(post schema.Post, sort string) (interface{}, uint) {
+	switch sort {
+	case "date_updated":
+		return post.DateUpdated, post.PostID
+	case "expires_at":
+		return post.ExpiresAt, post.PostID
+	case "title":
+		return post.Title, post.PostID
+	case "date_bookmarked":
+		if post.BookmarkedAt == nil {
+			return time.Time{}, post.PostID
+		}
+		return *post.BookmarkedAt, post.PostID
+	case "distance":
+		if post.Distance == nil {
+			return 0.0, post.PostID
+		}
+		return *post.Distance, post.PostID
+	default:
+		return post.DatePosted, post.PostID
+	}
+}
+
+// maxSummaryPosts is how many posts one summary lookup may ask for
+const maxSummaryPosts = 500
+
+// GetPostSummariesHandler tells another service who owns each post in ?post_ids= and its status.
+// Posts that are gone are left out.
+func GetPostSummariesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var postIDs []uint
+		for _, value := range strings.Split(c.Query("post_ids"), ",") {
+			if value == "" {
+				continue
+			}
+			postID, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+			postIDs = append(postIDs, uint(postID))
+		}
+		if len(postIDs) > maxSummaryPosts {
 			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
 			return
 		}
 
-		// Update the post status
-		if err := postUtils.UpdatePostStatus(uint(updateReq.PostID), updateReq.Status); err != nil {
+		posts, err := postUtils.GetPostsByIDs(postIDs)
+		if err != nil {
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
 			return
 		}
 
-		res.ResponseSuccess(c, http.StatusOK, "update post sucess", types.Success())
+		summaries := []schema.PostSummary{}
+		for _, postID := range postIDs {
+			post, ok := posts[postID]
+			if !ok {
+				continue
+			}
+			summaries = append(summaries, schema.PostSummary{
+				PostID:   post.PostID,
+				UserID:   post.UserID,
+				Username: post.Username,
+				Title:    post.Title,
+				Type:     post.Type,
+				Status:   post.Status,
+			})
+			// a post asked for twice is only listed once
+			delete(posts, postID)
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get post summaries", types.Success(), schema.PostSummariesResponse{Posts: summaries})
 	}
 }
+
+// buildPostResponses converts posts into their API representation with their images
+func buildPostResponses(postUtils utils.IPostUtils, posts []schema.Post) ([]schema.PostResponse, error) {
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.PostID)
+	}
+
+	images, err := postUtils.GetPostImages(postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks, err := postUtils.CountBookmarks(postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]schema.PostResponse, 0, len(posts))
+	for _, post := range posts {
+		response := toPostResponse(post)
+		response.Images = toImageResponses(postUtils, images[post.PostID])
+		response.BookmarkCount = bookmarks[post.PostID]
+		if response.Location != nil && post.Building != "" {
+			if building, ok := postUtils.CampusBuilding(post.Building); ok {
+				response.Location.BuildingName = building.Name
+			}
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// postVisible reports whether the user may see the post. A hidden post is gone for everyone but
+// its owner and the moderators, and a draft for everyone but its owner.
+func postVisible(post schema.Post, user schema.UserInfoResponse) bool {
+	switch {
+	case post.Status != schema.Hidden && post.Status != schema.Draft:
+		return true
+	case post.UserID == user.UserID:
+		return true
+	default:
+		return post.Status == schema.Hidden && (user.Role == schema.ModeratorRole || user.Role == schema.AdminRole)
+	}
+}
+
+// ownedPost loads the post in the :id parameter and checks the caller owns it, writing the error response otherwise
+func ownedPost(c *gin.Context, postUtils utils.IPostUtils) (schema.Post, bool) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return schema.Post{}, false
+	}
+
+	user, err := postUtils.GetUserInfo(c)
+	if err != nil {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return schema.Post{}, false
+	}
+
+	post, err := postUtils.GetPostByID(uint(postID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+		} else {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		}
+		return schema.Post{}, false
+	}
+
+	if post.UserID != user.UserID {
+		res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+		return schema.Post{}, false
+	}
+
+	return post, true
+}
+
+// toPostResponse converts a stored post into its API representation
+func toPostResponse(post schema.Post) schema.PostResponse {
+	response := schema.PostResponse{
+		PostID:      post.PostID,
+		Title:       post.Title,
+		Description: post.Description,
+		Category:    post.Category,
+		Type:        post.Type,
+		Quantity:    post.Quantity,
+		NeededBy:    post.NeededBy,
+		Username:    post.Username,
+		DatePosted:  post.DatePosted,
+		Status:      post.Status,
+		ExpiresAt:   post.ExpiresAt,
+		Revision:    post.Revision,
+		Edited:      post.Revision > 0,
+		PublishAt:   post.PublishAt,
+		SeriesID:    post.SeriesID,
+	}
+
+	if post.Latitude != nil && post.Longitude != nil {
+		response.Location = &schema.PostLocation{
+			Latitude:  *post.Latitude,
+			Longitude: *post.Longitude,
+			Building:  post.Building,
+		}
+		response.DistanceKm = post.Distance
+	}
+
+	return response
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"post/schema"
+	"post/utils"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PublishPostHandler lets the owner publish a draft. With a publish_at in the body the draft is
+// scheduled instead, and the publisher makes it active at that time.
+func PublishPostHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req schema.PostPublishRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.BindJSON(&req); err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+		}
+
+		post, ok := ownedPost(c, postUtils)
+		if !ok {
+			return
+		}
+
+		if req.PublishAt != nil {
+			if err := postUtils.ValidatePublishAt(*req.PublishAt); err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+
+			if err := postUtils.SchedulePost(post.PostID, req.PublishAt); err != nil {
+				publishError(c, err)
+				return
+			}
+
+			res.ResponseSuccessWithData(c, http.StatusOK, "schedule post", types.Success(), gin.H{"publish_at": req.PublishAt})
+			return
+		}
+
+		post, err := postUtils.PublishPost(post.PostID, post.UserID)
+		if err != nil {
+			publishError(c, err)
+			return
+		}
+
+		// subscribers are alerted in the background, the same as for a post published on creation
+		go utils.MatchSavedSearches(postUtils, post)
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "publish post", types.Success(), gin.H{
+			"status":     post.Status,
+			"expires_at": post.ExpiresAt,
+		})
+	}
+}
+
+// UnschedulePostHandler keeps a scheduled draft a draft until the owner publishes it
+func UnschedulePostHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		post, ok := ownedPost(c, postUtils)
+		if !ok {
+			return
+		}
+
+		if err := postUtils.SchedulePost(post.PostID, nil); err != nil {
+			publishError(c, err)
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "unschedule post", types.Success())
+	}
+}
+
+func publishError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+	case errors.Is(err, utils.ErrPostNotDraft), errors.Is(err, utils.ErrIllegalTransition):
+		res.ResponseError(c, http.StatusConflict, schema.Conflict())
+	default:
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+	}
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"post/schema"
+	"post/utils"
+	"strconv"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StartSeriesHandler makes one of the caller's active posts recurring
+func StartSeriesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req schema.PostSeriesRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		post, ok := ownedPost(c, postUtils)
+		if !ok {
+			return
+		}
+
+		series, err := postUtils.StartSeries(post.PostID, post.UserID, req.Frequency, *req.EndsAt)
+		if err != nil {
+			seriesError(c, err)
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusCreated, "start series", types.Success(), toSeriesResponse(series))
+	}
+}
+
+// GetSeriesHandler lists the caller's post series
+func GetSeriesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		series, err := postUtils.GetSeries(user.UserID)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		responseSeries := []schema.PostSeriesResponse{}
+		for _, s := range series {
+			responseSeries = append(responseSeries, toSeriesResponse(s))
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get series", types.Success(), responseSeries)
+	}
+}
+
+// PauseSeriesHandler stops a series from reposting until the owner resumes it
+func PauseSeriesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setSeriesStatus(c, postUtils, schema.SeriesPaused, "pause series")
+	}
+}
+
+// ResumeSeriesHandler restarts a paused series from its next occurrence
+func ResumeSeriesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setSeriesStatus(c, postUtils, schema.SeriesActive, "resume series")
+	}
+}
+
+// CancelSeriesHandler stops a series for good, its latest post stays up until it expires
+func CancelSeriesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setSeriesStatus(c, postUtils, schema.SeriesCancelled, "cancel series")
+	}
+}
+
+func setSeriesStatus(c *gin.Context, postUtils utils.IPostUtils, status schema.SeriesStatus, message string) {
+	series, ok := ownedSeries(c, postUtils)
+	if !ok {
+		return
+	}
+
+	series, err := postUtils.SetSeriesStatus(series.SeriesID, status)
+	if err != nil {
+		seriesError(c, err)
+		return
+	}
+
+	res.ResponseSuccessWithData(c, http.StatusOK, message, types.Success(), toSeriesResponse(series))
+}
+
+// ownedSeries loads the series in the :id parameter and checks the caller owns it, writing the error response otherwise
+func ownedSeries(c *gin.Context, postUtils utils.IPostUtils) (schema.PostSeries, bool) {
+	seriesID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return schema.PostSeries{}, false
+	}
+
+	user, err := postUtils.GetUserInfo(c)
+	if err != nil {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return schema.PostSeries{}, false
+	}
+
+	series, err := postUtils.GetSeriesByID(uint(seriesID))
+	if err != nil {
+		seriesError(c, err)
+		return schema.PostSeries{}, false
+	}
+
+	// someone else's series doesn't exist as far as the caller is concerned
+	if series.UserID != user.UserID {
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+		return schema.PostSeries{}, false
+	}
+
+	return series, true
+}
+
+func toSeriesResponse(series schema.PostSeries) schema.PostSeriesResponse {
+	response := schema.PostSeriesResponse{
+		SeriesID:     series.SeriesID,
+		LatestPostID: series.LatestPostID,
+		Frequency:    series.Frequency,
+		EndsAt:       series.EndsAt,
+		Occurrences:  series.Occurrences,
+		Status:       series.Status,
+		DateCreated:  series.DateCreated,
+	}
+
+	// a series that won't post again has no next occurrence
+	if series.Status == schema.SeriesActive || series.Status == schema.SeriesPaused {
+		next := series.NextOccurrence
+		response.NextOccurrence = &next
+	}
+
+	return response
+}
+
+func seriesError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+	case errors.Is(err, utils.ErrInvalidRecurrence):
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+	case errors.Is(err, utils.ErrAlreadyRecurring), errors.Is(err, utils.ErrPostNotRecurrable), errors.Is(err, utils.ErrSeriesFinished):
+		res.ResponseError(c, http.StatusConflict, schema.Conflict())
+	default:
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+	}
+}
@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"pagination"
+	"post/schema"
+	"post/utils"
+	"screening"
+	"strconv"
+	"time"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AddReportHandler lets any user report a post, bid or user to the moderators
+func AddReportHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req schema.ReportRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		if !utils.ValidReport(req.TargetType, req.Reason) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		// reported posts have to exist, and nobody reports their own content
+		selfReport := req.TargetType == schema.UserReportTarget && req.TargetID == user.UserID
+		if req.TargetType == schema.PostReportTarget {
+			post, err := postUtils.GetPostByID(req.TargetID)
+			if err == nil && post.Status == schema.Draft && post.UserID != user.UserID {
+				err = gorm.ErrRecordNotFound
+			}
+			if err != nil {
+				reportError(c, err)
+				return
+			}
+			selfReport = post.UserID == user.UserID
+		}
+		if selfReport {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		report, hidden, err := postUtils.AddReport(schema.Report{
+			TargetType:     req.TargetType,
+			TargetID:       req.TargetID,
+			ReporterUserID: user.UserID,
+			Reason:         req.Reason,
+			Details:        screening.Normalize(req.Details),
+			Status:         schema.OpenReport,
+			DateCreated:    time.Now(),
+		})
+		if err != nil {
+			reportError(c, err)
+			return
+		}
+
+		if hidden {
+			log.Printf("Post %d hidden after report %d", report.TargetID, report.ReportID)
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusCreated, "add report", types.Success(), toReportResponse(report))
+	}
+}
+
+// AddFlaggedContentHandler queues content another service's screening flagged for the moderators
+func AddFlaggedContentHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req schema.FlaggedContentRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		if !utils.ValidReport(req.TargetType, schema.OtherReportReason) || len(req.Matches) == 0 {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		matches := make([]screening.MatchType, 0, len(req.Matches))
+		for _, match := range req.Matches {
+			matches = append(matches, screening.MatchType(match))
+		}
+
+		// the content is already flagged when it is flagged again before a moderator got to it
+		err := postUtils.FlagContent(req.TargetType, req.TargetID, matches)
+		if err != nil && !errors.Is(err, utils.ErrDuplicateReport) {
+			reportError(c, err)
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "flag content", types.Success())
+	}
+}
+
+// reportPageOptions pages the moderation queue, oldest report first by default
+var reportPageOptions = pagination.Options{
+	DefaultLimit: 25,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"date_created": {Column: "date_created", Kind: pagination.TimeField},
+	},
+	DefaultSort: "date_created",
+	IDColumn:    "report_id",
+}
+
+// GetModerationQueueHandler lists reports for moderators, the open ones unless ?status= says otherwise,
+// optionally narrowed to one ?target_type= and ?target_id=
+func GetModerationQueueHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := requireRole(c, postUtils, schema.ModeratorRole, schema.AdminRole); !ok {
+			return
+		}
+
+		filter := schema.ReportFilter{
+			Status:     schema.ReportStatus(c.DefaultQuery("status", string(schema.OpenReport))),
+			TargetType: schema.ReportTarget(c.Query("target_type")),
+		}
+		switch filter.Status {
+		case schema.OpenReport, schema.DismissedReport, schema.ActionedReport:
+		default:
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+		if filter.TargetType != "" && !utils.ValidReport(filter.TargetType, schema.OtherReportReason) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+		if targetID := c.Query("target_id"); targetID != "" {
+			id, err := strconv.ParseUint(targetID, 10, 32)
+			if err != nil || filter.TargetType == "" {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+			filter.TargetID = uint(id)
+		}
+
+		page, err := pagination.Parse(c, reportPageOptions)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		reports, err := postUtils.GetReports(filter, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+		reports, result := pagination.Paginate(reports, page, func(report schema.Report, _ string) (interface{}, uint) {
+			return report.DateCreated, report.ReportID
+		})
+
+		responseReports := []schema.ReportResponse{}
+		for _, report := range reports {
+			responseReports = append(responseReports, toReportResponse(report))
+		}
+
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "get moderation queue", types.Success(), responseReports, result)
+	}
+}
+
+// ResolveReportHandler applies a moderator's decision to the reported content and resolves
+// every open report about it, then lets the reporters know
+func ResolveReportHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		moderator, ok := requireRole(c, postUtils, schema.ModeratorRole, schema.AdminRole)
+		if !ok {
+			return
+		}
+
+		reportID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		var req schema.ReportResolveRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		report, err := postUtils.GetReportByID(uint(reportID))
+		if err != nil {
+			reportError(c, err)
+			return
+		}
+		if report.Status != schema.OpenReport {
+			res.ResponseError(c, http.StatusConflict, schema.Conflict())
+			return
+		}
+		if !utils.ActionApplies(req.Action, report.TargetType) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		// act first, so the reports stay in the queue if the action fails
+		switch {
+		case report.TargetType == schema.PostReportTarget && req.Action == schema.SuspendUserAction:
+			var post schema.Post
+			if post, err = postUtils.GetPostByID(report.TargetID); err == nil {
+				err = postUtils.SuspendUser(post.UserID, req.Note)
+			}
+		case report.TargetType == schema.PostReportTarget:
+			err = postUtils.ModeratePost(report.TargetID, moderator.UserID, req.Action, req.Note)
+		case req.Action == schema.RemoveBidAction:
+			err = postUtils.RemoveBid(report.TargetID)
+		case req.Action == schema.SuspendUserAction:
+			err = postUtils.SuspendUser(report.TargetID, req.Note)
+		}
+		if err != nil {
+			log.Printf("Error applying %s to %s %d: %v", req.Action, report.TargetType, report.TargetID, err)
+			reportError(c, err)
+			return
+		}
+
+		resolved, err := postUtils.ResolveReports(report.ReportID, moderator.UserID, req.Action, req.Note)
+		if err != nil {
+			reportError(c, err)
+			return
+		}
+
+		go utils.NotifyReporters(postUtils, resolved)
+
+		responseReports := []schema.ReportResponse{}
+		for _, report := range resolved {
+			responseReports = append(responseReports, toReportResponse(report))
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "resolve report", types.Success(), responseReports)
+	}
+}
+
+func toReportResponse(report schema.Report) schema.ReportResponse {
+	return schema.ReportResponse{
+		ReportID:        report.ReportID,
+		TargetType:      report.TargetType,
+		TargetID:        report.TargetID,
+		ReporterUserID:  report.ReporterUserID,
+		Reason:          report.Reason,
+		Details:         report.Details,
+		Status:          report.Status,
+		Action:          report.Action,
+		ModeratorUserID: report.ModeratorUserID,
+		Resolution:      report.Resolution,
+		DateCreated:     report.DateCreated,
+		DateResolved:    report.DateResolved,
+	}
+}
+
+func reportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+	case errors.Is(err, utils.ErrDuplicateReport), errors.Is(err, utils.ErrReportResolved), errors.Is(err, utils.ErrIllegalTransition):
+		res.ResponseError(c, http.StatusConflict, schema.Conflict())
+	default:
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+	}
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"pagination"
+	"post/schema"
+	"post/utils"
+	"strconv"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// revisionPageOptions pages a post's revisions, oldest first by default
+var revisionPageOptions = pagination.Options{
+	DefaultLimit: 50,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"revision": {Column: "revision", Kind: pagination.IntField},
+	},
+	DefaultSort: "revision",
+	IDColumn:    "revision_id",
+}
+
+// GetPostRevisionsHandler lists the edits made to a post, so bidders can see what changed since they bid
+func GetPostRevisionsHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		page, err := pagination.Parse(c, revisionPageOptions)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		post, err := postUtils.GetPostByID(uint(postID))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+
+		revisions, err := postUtils.GetPostRevisions(post.PostID, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+		revisions, result := pagination.Paginate(revisions, page, func(revision schema.PostRevision, _ string) (interface{}, uint) {
+			return revision.Revision, revision.RevisionID
+		})
+
+		responseRevisions := []schema.PostRevisionResponse{}
+		for _, revision := range revisions {
+			responseRevisions = append(responseRevisions, schema.PostRevisionResponse{
+				Revision:    revision.Revision,
+				Changes:     revision.Changes,
+				CreatedDate: revision.CreatedDate,
+			})
+		}
+
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "get post revisions", types.Success(), responseRevisions, result)
+	}
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"post/schema"
+	"post/utils"
+	"screening"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// length limits of a saved search's name and query, in characters
+const (
+	maxSavedSearchName  = 80
+	maxSavedSearchQuery = 200
+)
+
+// AddSavedSearchHandler subscribes the caller to a search
+func AddSavedSearchHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req schema.SavedSearchRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		search := schema.SavedSearch{UserID: user.UserID, DateCreated: time.Now()}
+		if !applySavedSearchRequest(c, postUtils, &search, req) {
+			return
+		}
+
+		search, err = postUtils.AddSavedSearch(search)
+		if err != nil {
+			savedSearchError(c, err)
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusCreated, "add saved search", types.Success(), toSavedSearchResponse(search))
+	}
+}
+
+// GetSavedSearchesHandler lists the caller's saved searches
+func GetSavedSearchesHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		searches, err := postUtils.GetSavedSearches(user.UserID)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		responseSearches := []schema.SavedSearchResponse{}
+		for _, search := range searches {
+			responseSearches = append(responseSearches, toSavedSearchResponse(search))
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get saved searches", types.Success(), responseSearches)
+	}
+}
+
+// EditSavedSearchHandler replaces one of the caller's saved searches
+func EditSavedSearchHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		search, ok := ownedSavedSearch(c, postUtils)
+		if !ok {
+			return
+		}
+
+		var req schema.SavedSearchRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		if !applySavedSearchRequest(c, postUtils, &search, req) {
+			return
+		}
+
+		if err := postUtils.UpdateSavedSearch(search); err != nil {
+			savedSearchError(c, err)
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "edit saved search", types.Success(), toSavedSearchResponse(search))
+	}
+}
+
+// DeleteSavedSearchHandler unsubscribes the caller from one of their saved searches
+func DeleteSavedSearchHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		search, ok := ownedSavedSearch(c, postUtils)
+		if !ok {
+			return
+		}
+
+		if err := postUtils.DeleteSavedSearch(search.SavedSearchID); err != nil {
+			savedSearchError(c, err)
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "delete saved search", types.Success())
+	}
+}
+
+// applySavedSearchRequest validates the request and copies it onto the saved search, writing the
+// error response when it's invalid. A saved search has to narrow the posts down somehow.
+func applySavedSearchRequest(c *gin.Context, postUtils utils.IPostUtils, search *schema.SavedSearch, req schema.SavedSearchRequest) bool {
+	name := screening.Normalize(req.Name)
+	query := screening.Normalize(req.Query)
+	if name == "" || utf8.RuneCountInString(name) > maxSavedSearchName || utf8.RuneCountInString(query) > maxSavedSearchQuery {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return false
+	}
+
+	switch req.Type {
+	case "", schema.RequestPost, schema.OfferPost:
+	default:
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return false
+	}
+
+	if req.Frequency == "" {
+		req.Frequency = schema.ImmediateAlerts
+	}
+	if req.Frequency != schema.ImmediateAlerts && req.Frequency != schema.DigestAlerts {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return false
+	}
+
+	category := ""
+	if req.Category != "" {
+		resolved, err := postUtils.ResolveCategory(req.Category)
+		if err != nil {
+			savedSearchError(c, err)
+			return false
+		}
+		category = resolved.Slug
+	}
+
+	if query == "" && category == "" && req.Type == "" {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return false
+	}
+
+	search.Name = name
+	search.Query = query
+	search.Category = category
+	search.Type = req.Type
+	search.Frequency = req.Frequency
+	search.DateUpdated = time.Now()
+	return true
+}
+
+// ownedSavedSearch loads the saved search in the :id parameter and checks the caller owns it, writing the error response otherwise
+func ownedSavedSearch(c *gin.Context, postUtils utils.IPostUtils) (schema.SavedSearch, bool) {
+	savedSearchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return schema.SavedSearch{}, false
+	}
+
+	user, err := postUtils.GetUserInfo(c)
+	if err != nil {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return schema.SavedSearch{}, false
+	}
+
+	search, err := postUtils.GetSavedSearchByID(uint(savedSearchID))
+	if err != nil {
+		savedSearchError(c, err)
+		return schema.SavedSearch{}, false
+	}
+
+	// someone else's saved search doesn't exist as far as the caller is concerned
+	if search.UserID != user.UserID {
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+		return schema.SavedSearch{}, false
+	}
+
+	return search, true
+}
+
+func toSavedSearchResponse(search schema.SavedSearch) schema.SavedSearchResponse {
+	return schema.SavedSearchResponse{
+		SavedSearchID: search.SavedSearchID,
+		Name:          search.Name,
+		Query:         search.Query,
+		Category:      search.Category,
+		Type:          search.Type,
+		Frequency:     search.Frequency,
+		DateCreated:   search.DateCreated,
+		DateUpdated:   search.DateUpdated,
+	}
+}
+
+func savedSearchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+	case errors.Is(err, utils.ErrInvalidCategory):
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+	case errors.Is(err, utils.ErrTooManySavedSearches):
+		res.ResponseError(c, http.StatusConflict, schema.Conflict())
+	default:
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+	}
+}
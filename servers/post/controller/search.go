@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"net/http"
+	"pagination"
+	"post/schema"
+	"post/utils"
+	"strings"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// SearchPostsHandler runs a ranked full text search over post titles and descriptions
+func SearchPostsHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := schema.PostSearchQuery{
+			Text:     strings.TrimSpace(c.Query("q")),
+			Category: schema.Slugify(c.Query("category")),
+			Type:     schema.PostType(c.Query("type")),
+			Status:   schema.PostStatus(c.DefaultQuery("status", string(schema.Active))),
+		}
+
+		if query.Text == "" {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		if query.Type != "" && !utils.ValidPostType(query.Type) {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		if query.Status != schema.Active && query.Status != schema.Matched &&
+			query.Status != schema.Closed && query.Status != schema.Expired {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		var ok bool
+		if query.Near, query.RadiusKm, ok = parseNear(c, postUtils); !ok {
+			return
+		}
+
+		// nearest first only makes sense around a point
+		switch c.DefaultQuery("sort", "rank") {
+		case "rank":
+		case "distance":
+			if query.Near == nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+			query.ByDistance = true
+		default:
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		var err error
+		if query.Limit, err = pagination.ParseLimit(c, defaultSearchLimit, maxSearchLimit); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		if query.Since, err = utils.ParseSearchSince(c.Query("since")); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+		if query.Until, err = utils.ParseSearchUntil(c.Query("until")); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		if cursor := c.Query("cursor"); cursor != "" {
+			if query.After, err = utils.DecodeSearchCursor(cursor); err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+		}
+
+		// fetch one extra hit to know whether another page follows
+		pageSize := query.Limit
+		query.Limit++
+		hits, err := postUtils.SearchPosts(query)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		// search hits carry their own rank or distance cursor rather than a sort field
+		var result pagination.Result
+		if len(hits) > pageSize {
+			hits = hits[:pageSize]
+			result = pagination.Result{NextCursor: utils.EncodeSearchCursor(hits[len(hits)-1], query.ByDistance), HasMore: true}
+		}
+
+		posts := make([]schema.Post, 0, len(hits))
+		for _, hit := range hits {
+			posts = append(posts, hit.Post)
+		}
+		responsePosts, err := buildPostResponses(postUtils, posts)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		responseHits := []schema.PostSearchHitResponse{}
+		for i, hit := range hits {
+			responseHits = append(responseHits, schema.PostSearchHitResponse{
+				PostResponse:         responsePosts[i],
+				Rank:                 hit.Rank,
+				TitleHighlight:       hit.TitleHighlight,
+				DescriptionHighlight: hit.DescriptionHighlight,
+			})
+		}
+
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "search posts", types.Success(), responseHits, result)
+	}
+}
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"pagination"
+	"post/schema"
+	"post/utils"
+	"strconv"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// internalStatusActors maps the calling service onto the actor it acts as
+var internalStatusActors = map[string]schema.StatusActor{
+	"MATCH": schema.MatchActor,
+}
+
+// UpdatePostStatusHandler moves a post between statuses on behalf of another service
+func UpdatePostStatusHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Parse the request body
+		var updateReq schema.PostStatusUpdateRequest
+		if err := c.BindJSON(&updateReq); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		actor, ok := internalStatusActors[c.GetHeader("X-Service")]
+		if !ok {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+
+		// Update the post status
+		post, err := postUtils.TransitionPostStatus(updateReq.PostID, updateReq.Status, actor, 0, updateReq.Reason)
+		if err != nil {
+			statusError(c, err)
+			return
+		}
+
+		go utils.NotifyBookmarkersOfStatus(postUtils, post)
+
+		res.ResponseSuccess(c, http.StatusOK, "update post sucess", types.Success())
+	}
+}
+
+// EditPostStatusHandler lets the owner, or an admin, move a post between statuses
+func EditPostStatusHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		var updateReq schema.PostStatusUpdateRequest
+		if err := c.BindJSON(&updateReq); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		post, err := postUtils.GetPostByID(uint(postID))
+		if err != nil {
+			statusError(c, err)
+			return
+		}
+
+		// an admin owner gets the wider admin transitions when the owner ones don't allow the change
+		var actors []schema.StatusActor
+		if post.UserID == user.UserID {
+			actors = append(actors, schema.OwnerActor)
+		}
+		if user.Role == schema.AdminRole {
+			actors = append(actors, schema.AdminActor)
+		}
+		if len(actors) == 0 {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+
+		actor := actors[0]
+		for _, candidate := range actors {
+			if utils.CanTransition(post.Status, updateReq.Status, candidate) {
+				actor = candidate
+				break
+			}
+		}
+
+		post, err = postUtils.TransitionPostStatus(post.PostID, updateReq.Status, actor, user.UserID, updateReq.Reason)
+		if err != nil {
+			statusError(c, err)
+			return
+		}
+
+		go utils.NotifyBookmarkersOfStatus(postUtils, post)
+
+		res.ResponseSuccess(c, http.StatusOK, "edit post status", types.Success())
+	}
+}
+
+// historyPageOptions pages a post's status history, oldest first by default
+var historyPageOptions = pagination.Options{
+	DefaultLimit: 50,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"created_date": {Column: "created_date", Kind: pagination.TimeField},
+	},
+	DefaultSort: "created_date",
+	IDColumn:    "history_id",
+}
+
+// GetPostStatusHistoryHandler lists a post's status changes for its owner or an admin
+func GetPostStatusHistoryHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		page, err := pagination.Parse(c, historyPageOptions)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		post, err := postUtils.GetPostByID(uint(postID))
+		if err != nil {
+			statusError(c, err)
+			return
+		}
+
+		if post.UserID != user.UserID && user.Role != schema.AdminRole {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+
+		history, err := postUtils.GetPostStatusHistory(post.PostID, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+		history, result := pagination.Paginate(history, page, func(change schema.PostStatusHistory, _ string) (interface{}, uint) {
+			return change.CreatedDate, change.HistoryID
+		})
+
+		responseHistory := []schema.PostStatusHistoryResponse{}
+		for _, change := range history {
+			responseHistory = append(responseHistory, schema.PostStatusHistoryResponse{
+				FromStatus:  change.FromStatus,
+				ToStatus:    change.ToStatus,
+				Actor:       change.Actor,
+				Reason:      change.Reason,
+				CreatedDate: change.CreatedDate,
+			})
+		}
+
+		pagination.ResponseSuccessWithPage(c, http.StatusOK, "get post status history", types.Success(), responseHistory, result)
+	}
+}
+
+func statusError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+	case errors.Is(err, utils.ErrIllegalTransition):
+		res.ResponseError(c, http.StatusConflict, schema.Conflict())
+	default:
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+	}
+}
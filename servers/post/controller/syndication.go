@@ -0,0 +1,309 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"pagination"
+	"post/schema"
+	"post/utils"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// syndicationDays is how far back the post feeds reach
+const syndicationDays = 14
+
+// syndicationPageOptions size the post feeds, feed readers always get the newest posts
+var syndicationPageOptions = pagination.Options{
+	DefaultLimit: 50,
+	MaxLimit:     100,
+	Sorts: map[string]pagination.Field{
+		"date_posted": {Column: "date_posted", Kind: pagination.TimeField},
+	},
+	DefaultSort: "-date_posted",
+	IDColumn:    "post_id",
+}
+
+// CreateFeedTokenHandler gives the caller a new feed token, the old one stops working
+func CreateFeedTokenHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		token, err := postUtils.CreateFeedToken(user.UserID)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		feedsURL := postUtils.SyndicationLinks().FeedsURL(token)
+		res.ResponseSuccessWithData(c, http.StatusCreated, "create feed token", types.Success(), schema.FeedTokenResponse{
+			Token:      token,
+			FeedsURL:   feedsURL,
+			RecentURL:  feedsURL + "/recent",
+			MatchesURL: feedsURL + "/matches",
+		})
+	}
+}
+
+// RevokeFeedTokenHandler turns off the caller's feeds
+func RevokeFeedTokenHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := postUtils.GetUserInfo(c)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		if err := postUtils.RevokeFeedToken(user.UserID); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "revoke feed token", types.Success())
+	}
+}
+
+// GetRecentFeedHandler is the recent posts as an Atom or RSS feed
+func GetRecentFeedHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := feedTokenUser(c, postUtils); !ok {
+			return
+		}
+
+		page, ok := parseFeedPage(c)
+		if !ok {
+			return
+		}
+
+		posts, err := postUtils.GetRecentPosts(syndicationDays, schema.PostFilter{}, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		posts, _ = pagination.Paginate(posts, page, postSortKey)
+		writeFeed(c, postUtils, "GiveGetGo: recent posts", posts)
+	}
+}
+
+// GetCategoryFeedHandler is the recent posts of one category as an Atom or RSS feed
+func GetCategoryFeedHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := feedTokenUser(c, postUtils); !ok {
+			return
+		}
+
+		category, err := postUtils.GetCategoryBySlug(c.Param("slug"))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+
+		page, ok := parseFeedPage(c)
+		if !ok {
+			return
+		}
+
+		posts, err := postUtils.GetRecentPosts(syndicationDays, schema.PostFilter{Category: category.Slug}, page)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		posts, _ = pagination.Paginate(posts, page, postSortKey)
+		writeFeed(c, postUtils, "GiveGetGo: "+category.Name, posts)
+	}
+}
+
+// GetSavedSearchFeedHandler is the recent posts matching one of the token owner's saved searches
+// as an Atom or RSS feed
+func GetSavedSearchFeedHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := feedTokenUser(c, postUtils)
+		if !ok {
+			return
+		}
+
+		savedSearchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		search, err := postUtils.GetSavedSearchByID(uint(savedSearchID))
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+		// someone else's saved search doesn't exist as far as the token is concerned
+		if err != nil || search.UserID != userID {
+			res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			return
+		}
+
+		page, ok := parseFeedPage(c)
+		if !ok {
+			return
+		}
+
+		var posts []schema.Post
+		if search.Query == "" {
+			posts, err = postUtils.GetRecentPosts(syndicationDays, schema.PostFilter{Type: search.Type, Category: search.Category}, page)
+			posts, _ = pagination.Paginate(posts, page, postSortKey)
+		} else {
+			posts, err = searchFeedPosts(postUtils, search, page.Limit)
+		}
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		writeFeed(c, postUtils, "GiveGetGo: "+search.Name, posts)
+	}
+}
+
+// searchFeedPosts runs a saved search's keywords over the recent active posts, newest first like the other feeds
+func searchFeedPosts(postUtils utils.IPostUtils, search schema.SavedSearch, limit int) ([]schema.Post, error) {
+	hits, err := postUtils.SearchPosts(schema.PostSearchQuery{
+		Text:     search.Query,
+		Category: search.Category,
+		Type:     search.Type,
+		Status:   schema.Active,
+		Since:    time.Now().AddDate(0, 0, -syndicationDays),
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]schema.Post, 0, len(hits))
+	for _, hit := range hits {
+		posts = append(posts, hit.Post)
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].DatePosted.After(posts[j].DatePosted)
+	})
+	return posts, nil
+}
+
+// GetMatchCalendarHandler is the token owner's matches with an agreed time as an iCalendar feed
+func GetMatchCalendarHandler(postUtils utils.IPostUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := feedTokenUser(c, postUtils)
+		if !ok {
+			return
+		}
+
+		matches, err := postUtils.GetScheduledMatches(userID)
+		if err != nil {
+			log.Printf("Error fetching the scheduled matches of user %d: %v", userID, err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		postIDs := make([]uint, 0, len(matches))
+		for _, match := range matches {
+			postIDs = append(postIDs, match.PostID)
+		}
+		found, err := postUtils.GetPostsByIDs(postIDs)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		posts := make([]schema.Post, 0, len(found))
+		for _, post := range found {
+			posts = append(posts, post)
+		}
+		responsePosts, err := buildPostResponses(postUtils, posts)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		byID := make(map[uint]schema.PostResponse, len(responsePosts))
+		for _, post := range responsePosts {
+			byID[post.PostID] = post
+		}
+
+		calendar := postUtils.SyndicationLinks().RenderMatchCalendar(userID, matches, byID, time.Now())
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", calendar)
+	}
+}
+
+// feedTokenUser finds whose feeds the :token parameter unlocks, writing the error response otherwise.
+// An unknown or revoked token looks the same as a feed that doesn't exist.
+func feedTokenUser(c *gin.Context, postUtils utils.IPostUtils) (uint, bool) {
+	userID, err := postUtils.GetFeedTokenUserID(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+		} else {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		}
+		return 0, false
+	}
+	return userID, true
+}
+
+func parseFeedPage(c *gin.Context) (pagination.Page, bool) {
+	if format := c.DefaultQuery("format", "atom"); format != "atom" && format != "rss" {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return pagination.Page{}, false
+	}
+
+	page, err := pagination.Parse(c, syndicationPageOptions)
+	if err != nil {
+		res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+		return pagination.Page{}, false
+	}
+	return page, true
+}
+
+// writeFeed renders the posts in the ?format= the reader asked for, Atom by default
+func writeFeed(c *gin.Context, postUtils utils.IPostUtils, title string, posts []schema.Post) {
+	responsePosts, err := buildPostResponses(postUtils, posts)
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	links := postUtils.SyndicationLinks()
+	feed := utils.SyndicationFeed{
+		Title:   title,
+		SelfURL: fmt.Sprintf("%s%s", links.APIURL, c.Request.URL.RequestURI()),
+		Posts:   responsePosts,
+		Updated: time.Now(),
+	}
+
+	contentType := "application/atom+xml; charset=utf-8"
+	render := links.RenderAtom
+	if c.DefaultQuery("format", "atom") == "rss" {
+		contentType = "application/rss+xml; charset=utf-8"
+		render = links.RenderRSS
+	}
+
+	body, err := render(feed)
+	if err != nil {
+		log.Printf("Error rendering feed %q: %v", title, err)
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
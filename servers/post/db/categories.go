@@ -0,0 +1,170 @@
+package db
+
+import (
+	"log"
+	"post/schema"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fallbackCategory receives any old free text category that can't be mapped
+const fallbackCategory = "other"
+
+// defaultCategories seeds an empty registry, children name their parent by slug and follow it
+var defaultCategories = []struct {
+	Slug   string
+	Name   string
+	Icon   string
+	Parent string
+}{
+	{Slug: "books", Name: "Books", Icon: "book"},
+	{Slug: "textbooks", Name: "Textbooks", Icon: "book-open", Parent: "books"},
+	{Slug: "notes-and-study-guides", Name: "Notes & Study Guides", Icon: "notebook", Parent: "books"},
+	{Slug: "electronics", Name: "Electronics", Icon: "cpu"},
+	{Slug: "calculators", Name: "Calculators", Icon: "calculator", Parent: "electronics"},
+	{Slug: "computers-and-accessories", Name: "Computers & Accessories", Icon: "laptop", Parent: "electronics"},
+	{Slug: "phones-and-tablets", Name: "Phones & Tablets", Icon: "smartphone", Parent: "electronics"},
+	{Slug: "furniture", Name: "Furniture", Icon: "armchair"},
+	{Slug: "household", Name: "Household", Icon: "home"},
+	{Slug: "kitchen", Name: "Kitchen", Icon: "utensils", Parent: "household"},
+	{Slug: "clothing", Name: "Clothing", Icon: "shirt"},
+	{Slug: "school-supplies", Name: "School Supplies", Icon: "pencil"},
+	{Slug: "tickets", Name: "Tickets", Icon: "ticket"},
+	{Slug: "services", Name: "Services", Icon: "handshake"},
+	{Slug: "tutoring", Name: "Tutoring", Icon: "graduation-cap", Parent: "services"},
+	{Slug: "rides", Name: "Rides", Icon: "car", Parent: "services"},
+	{Slug: "moving-help", Name: "Moving Help", Icon: "truck", Parent: "services"},
+	{Slug: fallbackCategory, Name: "Other", Icon: "tag"},
+}
+
+// categoryAliases maps slugified free text seen in old posts onto registry slugs
+var categoryAliases = map[string]string{
+	"book":             "books",
+	"textbook":         "textbooks",
+	"text-book":        "textbooks",
+	"text-books":       "textbooks",
+	"notes":            "notes-and-study-guides",
+	"study-guide":      "notes-and-study-guides",
+	"calculator":       "calculators",
+	"electronic":       "electronics",
+	"tech":             "electronics",
+	"computer":         "computers-and-accessories",
+	"computers":        "computers-and-accessories",
+	"laptop":           "computers-and-accessories",
+	"laptops":          "computers-and-accessories",
+	"phone":            "phones-and-tablets",
+	"phones":           "phones-and-tablets",
+	"tablet":           "phones-and-tablets",
+	"desk":             "furniture",
+	"chair":            "furniture",
+	"home":             "household",
+	"moving-boxes":     "household",
+	"kitchenware":      "kitchen",
+	"clothes":          "clothing",
+	"apparel":          "clothing",
+	"supplies":         "school-supplies",
+	"school":           "school-supplies",
+	"stationery":       "school-supplies",
+	"ticket":           "tickets",
+	"service":          "services",
+	"tutor":            "tutoring",
+	"ride":             "rides",
+	"carpool":          "rides",
+	"moving":           "moving-help",
+	"misc":             fallbackCategory,
+	"miscellaneous":    fallbackCategory,
+	"others":           fallbackCategory,
+	"general":          fallbackCategory,
+	"free":             fallbackCategory,
+	"everything-else":  fallbackCategory,
+	"school-supply":    "school-supplies",
+	"household-items":  "household",
+	"kitchen-supplies": "kitchen",
+}
+
+// MigratePostCategories seeds the category registry when it is empty and rewrites
+// free text post categories to registry slugs. It is safe to run on every start.
+func MigratePostCategories(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&schema.Category{}).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		if err := seedCategories(db); err != nil {
+			return err
+		}
+	}
+
+	var categories []schema.Category
+	if err := db.Find(&categories).Error; err != nil {
+		return err
+	}
+	slugs := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		slugs[category.Slug] = true
+	}
+
+	var values []string
+	err := db.Model(&schema.Post{}).Distinct("category").
+		Where("category NOT IN (?)", db.Model(&schema.Category{}).Select("slug")).
+		Pluck("category", &values).Error
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		slug := mapCategory(value, slugs)
+		if err := db.Model(&schema.Post{}).Where("category = ?", value).Update("category", slug).Error; err != nil {
+			return err
+		}
+		log.Printf("Mapped post category %q to %q", value, slug)
+	}
+
+	return nil
+}
+
+func seedCategories(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		ids := make(map[string]uint)
+		for i, seed := range defaultCategories {
+			category := schema.Category{
+				Slug:        seed.Slug,
+				Name:        seed.Name,
+				Icon:        seed.Icon,
+				Active:      true,
+				SortOrder:   i,
+				DateCreated: time.Now(),
+				DateUpdated: time.Now(),
+			}
+			if seed.Parent != "" {
+				parentID := ids[seed.Parent]
+				category.ParentID = &parentID
+			}
+
+			if err := tx.Create(&category).Error; err != nil {
+				return err
+			}
+			ids[category.Slug] = category.CategoryID
+		}
+		return nil
+	})
+}
+
+// mapCategory finds the registry slug for a free text category, falling back to "other"
+func mapCategory(value string, slugs map[string]bool) string {
+	slug := schema.Slugify(value)
+	candidates := []string{slug, strings.TrimSuffix(slug, "s")}
+	for _, candidate := range candidates {
+		if alias, ok := categoryAliases[candidate]; ok {
+			return alias
+		}
+		if slugs[candidate] {
+			return candidate
+		}
+	}
+
+	return fallbackCategory
+}
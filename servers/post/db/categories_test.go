@@ -0,0 +1,32 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapCategory(t *testing.T) {
+	slugs := make(map[string]bool)
+	for _, seed := range defaultCategories {
+		slugs[seed.Slug] = true
+	}
+
+	cases := map[string]string{
+		"Books":           "books",
+		"books":           "books",
+		" BOOK ":          "books",
+		"textbook":        "textbooks",
+		"Text Books":      "textbooks",
+		"Calculators":     "calculators",
+		"School Supplies": "school-supplies",
+		"Furnitures":      "furniture",
+		"moving boxes":    "household",
+		"misc.":           "other",
+		"something weird": "other",
+		"":                "other",
+	}
+	for value, expected := range cases {
+		assert.Equal(t, expected, mapCategory(value, slugs), value)
+	}
+}
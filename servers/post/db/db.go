@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"log"
 	"os"
 	"post/schema"
@@ -17,6 +18,7 @@ type Database interface {
 	First(dest interface{}, conds ...interface{}) *gorm.DB
 	Save(value interface{}) *gorm.DB
 	Delete(value interface{}, conds ...interface{}) *gorm.DB
+	Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error
 }
 
 // Ensure that *gorm.DB satisfies the Database interface
@@ -48,12 +50,39 @@ func ConnectPostgresDB() *gorm.DB {
 // AutoMigratePostgresDB migrates the database schema
 func AutoMigratePostgresDB(db *gorm.DB) error {
 	// Migrate the schema
-	err := db.AutoMigrate(&schema.Post{})
+	err := db.AutoMigrate(&schema.Post{}, &schema.Category{}, &schema.PostStatusHistory{}, &schema.PostImage{}, &schema.PostRevision{}, &schema.Report{}, &schema.Bookmark{}, &schema.SavedSearch{}, &schema.SavedSearchMatch{}, &schema.PostSeries{}, &schema.PostView{}, &schema.FeedToken{})
 	if err != nil {
 		log.Fatalf("Error migrating PostgreSQL schema: %v", err)
 		return err
 	}
 
+	// weighted full text search vector over title and description, kept up to date by postgres
+	err = db.Exec(`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')
+		) STORED`).Error
+	if err == nil {
+		err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector)`).Error
+	}
+	if err != nil {
+		log.Fatalf("Error creating post search index: %v", err)
+		return err
+	}
+
+	// posts from before expiry get the default lifetime, with a few days' grace so the owner is warned first
+	err = db.Exec(`UPDATE posts SET expires_at = GREATEST(date_posted + make_interval(days => ?), now() + interval '3 days')
+		WHERE expires_at IS NULL`, schema.DefaultPostLifetimeDays).Error
+	if err != nil {
+		log.Fatalf("Error backfilling post expiry: %v", err)
+		return err
+	}
+
+	if err := MigratePostCategories(db); err != nil {
+		log.Fatalf("Error migrating post categories: %v", err)
+		return err
+	}
+
 	log.Println("Successfully migrated PostgreSQL schema")
 	return nil
 }
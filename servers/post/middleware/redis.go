@@ -14,6 +14,9 @@ type RedisClientInterface interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
 	Get(ctx context.Context, key string) *redis.StringCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	SPopN(ctx context.Context, key string, count int64) *redis.StringSliceCmd
 }
 
 // func SetupRedis(url string) *redis.Client {
@@ -0,0 +1,45 @@
+package schema
+
+import "github.com/GiveGetGo/shared/types"
+
+// UserInfoResponse is the user service's /me payload, the shared user info plus the user's role
+type UserInfoResponse struct {
+	types.UserInfoResponse
+	Role string `json:"role"`
+}
+
+const (
+	AdminRole     = "admin"
+	ModeratorRole = "moderator"
+)
+
+// not part of the shared response codes yet
+const (
+	ContentRejectedCode = "40005"
+	ForbiddenCode       = "40301"
+	ConflictCode        = "40902"
+)
+
+// func Forbidden() Response
+func Forbidden() types.Response {
+	return types.Response{
+		Code: ForbiddenCode,
+		Msg:  "Forbidden",
+	}
+}
+
+// func Conflict() Response
+func Conflict() types.Response {
+	return types.Response{
+		Code: ConflictCode,
+		Msg:  "Conflict",
+	}
+}
+
+// func ContentRejected(reason error) Response - the text failed screening, the message says why
+func ContentRejected(reason error) types.Response {
+	return types.Response{
+		Code: ContentRejectedCode,
+		Msg:  "Content rejected: " + reason.Error(),
+	}
+}
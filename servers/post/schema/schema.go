@@ -9,8 +9,21 @@ const (
 	Matched PostStatus = "Matched"
 	Closed  PostStatus = "Closed"
 	Expired PostStatus = "Expired"
+	Hidden  PostStatus = "Hidden" // taken down by moderation
+	Draft   PostStatus = "Draft"  // not published yet, only its owner sees it
 )
 
+// PostType is whether the owner is asking for help or giving something away
+type PostType string
+
+const (
+	RequestPost PostType = "request"
+	OfferPost   PostType = "offer"
+)
+
+// DefaultPostLifetimeDays is how long a post stays active when neither the owner nor its category say otherwise
+const DefaultPostLifetimeDays = 14
+
 type Post struct {
 	PostID      uint `gorm:"primaryKey"`
 	UserID      uint `gorm:"index"`
@@ -18,22 +31,601 @@ type Post struct {
 	Title       string
 	Description string
 	Category    string
-	Status      PostStatus
-	DatePosted  time.Time
-	DateUpdated time.Time
+	Type        PostType   `gorm:"default:request;index"`
+	Quantity    int        `gorm:"default:0"` // how many are offered, 0 for requests
+	NeededBy    *time.Time // when a request has to be fulfilled by, nil for offers
+	// optional pickup location, rounded for privacy unless it's a campus building
+	Latitude  *float64 `gorm:"index:idx_posts_location"`
+	Longitude *float64 `gorm:"index:idx_posts_location"`
+	Building  string
+	// distance in km from the point a list was filtered by, only selected by those queries
+	Distance *float64 `gorm:"->;-:migration"`
+	// when the caller bookmarked the post, only selected by the bookmark list
+	BookmarkedAt *time.Time `gorm:"->;-:migration"`
+	Status       PostStatus
+	DatePosted   time.Time
+	DateUpdated  time.Time
+	ExpiresAt    time.Time `gorm:"index"`
+	// set once the owner has been warned about the upcoming expiry
+	ExpiryNotified bool `gorm:"default:false"`
+	// number of edits, 0 for a post that was never edited
+	Revision int `gorm:"default:0"`
+	// when a scheduled draft is published, nil for unscheduled drafts and published posts
+	PublishAt *time.Time `gorm:"index"`
+	// the recurring series the post belongs to, nil for one-off posts
+	SeriesID *uint `gorm:"index"`
 }
 
 type PostResponse struct {
-	PostID      uint       `json:"postID"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Category    string     `json:"category"`
-	Username    string     `json:"username"`
-	DatePosted  time.Time  `json:"date_posted"`
-	Status      PostStatus `json:"status"`
+	PostID        uint                `json:"postID"`
+	Title         string              `json:"title"`
+	Description   string              `json:"description"`
+	Category      string              `json:"category"`
+	Type          PostType            `json:"type"`
+	Quantity      int                 `json:"quantity,omitempty"`
+	NeededBy      *time.Time          `json:"needed_by,omitempty"`
+	Username      string              `json:"username"`
+	DatePosted    time.Time           `json:"date_posted"`
+	Status        PostStatus          `json:"status"`
+	ExpiresAt     time.Time           `json:"expires_at"`
+	Images        []PostImageResponse `json:"images"`
+	Location      *PostLocation       `json:"location,omitempty"`
+	DistanceKm    *float64            `json:"distance_km,omitempty"`
+	Revision      int                 `json:"revision"`
+	Edited        bool                `json:"edited"`
+	BookmarkCount int64               `json:"bookmark_count"`
+	PublishAt     *time.Time          `json:"publish_at,omitempty"`
+	SeriesID      *uint               `json:"seriesID,omitempty"`
+}
+
+type PostLocation struct {
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	Building     string  `json:"building,omitempty"`
+	BuildingName string  `json:"building_name,omitempty"`
+}
+
+// GeoPoint is a latitude and longitude in degrees
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// CampusBuilding is a named pickup spot from the configurable building list
+type CampusBuilding struct {
+	Slug      string  `json:"slug"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Bookmark is a post a user saved to come back to, they hear about its status changes and edits
+type Bookmark struct {
+	BookmarkID  uint `gorm:"primaryKey"`
+	UserID      uint `gorm:"uniqueIndex:idx_bookmarks_user_post"`
+	PostID      uint `gorm:"uniqueIndex:idx_bookmarks_user_post;index"`
+	DateCreated time.Time
+}
+
+// PostView is a viewer looking at a post on a day, however many times they opened it that day
+type PostView struct {
+	ViewID   uint      `gorm:"primaryKey"`
+	PostID   uint      `gorm:"uniqueIndex:idx_post_views_post_viewer_day"`
+	ViewerID uint      `gorm:"uniqueIndex:idx_post_views_post_viewer_day"`
+	Day      time.Time `gorm:"type:date;uniqueIndex:idx_post_views_post_viewer_day"`
+}
+
+// PostAnalyticsDay is a post's activity on one UTC day
+type PostAnalyticsDay struct {
+	Date      string `json:"date"`
+	Views     int64  `json:"views"`
+	Bookmarks int64  `json:"bookmarks"`
+	Bids      int64  `json:"bids"`
+}
+
+// PostAnalyticsResponse - the owner's view of how a post is doing, views count each viewer once a day
+type PostAnalyticsResponse struct {
+	PostID         uint               `json:"postID"`
+	Views          int64              `json:"views"`
+	UniqueViewers  int64              `json:"unique_viewers"`
+	Bookmarks      int64              `json:"bookmarks"`
+	Bids           int64              `json:"bids"`
+	TimeToFirstBid *int64             `json:"time_to_first_bid_seconds,omitempty"`
+	Daily          []PostAnalyticsDay `json:"daily"`
+}
+
+// PostImage is a photo attached to a post, stored as a display-size image and a thumbnail
+type PostImage struct {
+	ImageID      uint `gorm:"primaryKey"`
+	PostID       uint `gorm:"index"`
+	Position     int  // display order within the post
+	Key          string
+	ThumbnailKey string
+	Width        int
+	Height       int
+	DateCreated  time.Time
+}
+
+type PostImageResponse struct {
+	ImageID      uint   `json:"imageID"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// PostRequest - request body for creating or editing a post, expires_at defaults to the category's lifetime.
+// type defaults to request, quantity only applies to offers and needed_by only to requests.
+type PostRequest struct {
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description" binding:"required"`
+	Category    string     `json:"category" binding:"required"`
+	Type        PostType   `json:"type"`
+	Quantity    int        `json:"quantity"`
+	NeededBy    *time.Time `json:"needed_by"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	// pickup location, either coordinates or a campus building slug
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Building  string   `json:"building"`
+	// only read when the post is created, either keeps it a draft until it's published
+	Draft     bool       `json:"draft"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// PostPublishRequest publishes a draft, or schedules it when PublishAt is set
+type PostPublishRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// PostFilter narrows the post lists
+type PostFilter struct {
+	Type     PostType
+	Category string    // category slug
+	Near     *GeoPoint // only posts with a location within RadiusKm of Near
+	RadiusKm float64
+}
+
+type PostRenewRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Category is an entry in the managed category registry, posts store the category's slug
+type Category struct {
+	CategoryID uint   `gorm:"primaryKey"`
+	ParentID   *uint  `gorm:"index"`
+	Slug       string `gorm:"uniqueIndex"`
+	Name       string
+	Icon       string
+	Active     bool
+	SortOrder  int
+	// how long posts in this category stay active by default, 0 inherits from the parent
+	DefaultLifetimeDays int `gorm:"default:0"`
+	DateCreated         time.Time
+	DateUpdated         time.Time
+}
+
+type CategoryRequest struct {
+	ParentID  *uint  `json:"parentID"`
+	Slug      string `json:"slug"`
+	Name      string `json:"name" binding:"required"`
+	Icon      string `json:"icon"`
+	Active    *bool  `json:"active"`
+	SortOrder int    `json:"sort_order"`
+	// 0 inherits the parent's lifetime
+	DefaultLifetimeDays int `json:"default_lifetime_days"`
+}
+
+type CategoryResponse struct {
+	CategoryID          uint               `json:"categoryID"`
+	ParentID            *uint              `json:"parentID"`
+	Slug                string             `json:"slug"`
+	Name                string             `json:"name"`
+	Icon                string             `json:"icon"`
+	Active              bool               `json:"active"`
+	SortOrder           int                `json:"sort_order"`
+	DefaultLifetimeDays int                `json:"default_lifetime_days"`
+	PostCount           int64              `json:"post_count"`
+	Children            []CategoryResponse `json:"children"`
+}
+
+// StatusActor is who moved a post between statuses
+type StatusActor string
+
+const (
+	OwnerActor     StatusActor = "owner"
+	MatchActor     StatusActor = "match"
+	SchedulerActor StatusActor = "scheduler"
+	AdminActor     StatusActor = "admin"
+	ModeratorActor StatusActor = "moderator"
+	ReportsActor   StatusActor = "reports" // hidden automatically once enough users reported it
+)
+
+// PostStatusHistory records every status change of a post
+type PostStatusHistory struct {
+	HistoryID   uint `gorm:"primaryKey"`
+	PostID      uint `gorm:"index"`
+	FromStatus  PostStatus
+	ToStatus    PostStatus
+	Actor       StatusActor
+	ActorUserID uint // 0 when a service made the change
+	Reason      string
+	CreatedDate time.Time
+}
+
+type PostStatusHistoryResponse struct {
+	FromStatus  PostStatus  `json:"from_status"`
+	ToStatus    PostStatus  `json:"to_status"`
+	Actor       StatusActor `json:"actor"`
+	Reason      string      `json:"reason"`
+	CreatedDate time.Time   `json:"created_date"`
+}
+
+// PostRevision records one edit of a post, with the fields it changed
+type PostRevision struct {
+	RevisionID   uint `gorm:"primaryKey"`
+	PostID       uint `gorm:"index:idx_post_revisions_post,unique"`
+	Revision     int  `gorm:"index:idx_post_revisions_post,unique"`
+	EditorUserID uint
+	Changes      []PostFieldChange `gorm:"serializer:json"`
+	CreatedDate  time.Time
+}
+
+// PostFieldChange is one field of a post before and after an edit
+type PostFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type PostRevisionResponse struct {
+	Revision    int               `json:"revision"`
+	Changes     []PostFieldChange `json:"changes"`
+	CreatedDate time.Time         `json:"created_date"`
+}
+
+// PostBiddersResponse is the bid service's list of users with an open bid on a post
+type PostBiddersResponse struct {
+	PostID  uint   `json:"postID"`
+	UserIDs []uint `json:"userIDs"`
+}
+
+// UserProfilesRequest asks the user service for several users' profiles at once
+type UserProfilesRequest struct {
+	UserIDs []uint `json:"userIDs"`
+}
+
+// UserProfile is the public part of a user's profile, from the user service
+type UserProfile struct {
+	UserID          uint   `json:"userID"`
+	Username        string `json:"username"`
+	Class           string `json:"class"`
+	Major           string `json:"major"`
+	ReputationScore int    `json:"reputation_score"`
+}
+
+// BidCountsResponse is the bid service's number of live bids on each post
+type BidCountsResponse struct {
+	Counts map[uint]int64 `json:"counts"`
+}
+
+// PostBidDatesResponse is the bid service's list of when each bid on a post was submitted, oldest first
+type PostBidDatesResponse struct {
+	PostID    uint        `json:"postID"`
+	Submitted []time.Time `json:"submitted"`
+}
+
+// UserBidPostsResponse is the bid service's list of posts a user has bid on
+type UserBidPostsResponse struct {
+	UserID  uint   `json:"userID"`
+	PostIDs []uint `json:"postIDs"`
 }
 
 type PostStatusUpdateRequest struct {
 	PostID uint       `json:"postID"`
 	Status PostStatus `json:"status"`
+	Reason string     `json:"reason"`
+}
+
+// PostSearchQuery describes a full text search over posts
+type PostSearchQuery struct {
+	Text       string
+	Category   string
+	Type       PostType
+	Near       *GeoPoint
+	RadiusKm   float64
+	ByDistance bool // nearest first instead of best ranked first, needs Near
+	Status     PostStatus
+	Since      time.Time
+	Until      time.Time
+	After      *PostSearchCursor
+	Limit      int
+}
+
+// SavedSearchFrequency is how a saved search's subscriber hears about new matching posts
+type SavedSearchFrequency string
+
+const (
+	ImmediateAlerts SavedSearchFrequency = "immediate" // a notification for every matching post
+	DigestAlerts    SavedSearchFrequency = "digest"    // one notification per digest interval listing the matches
+)
+
+// SavedSearch is a query a user subscribed to, new posts matching it are sent to them.
+// Empty fields match every post.
+type SavedSearch struct {
+	SavedSearchID uint `gorm:"primaryKey"`
+	UserID        uint `gorm:"index"`
+	Name          string
+	Query         string // keywords, matched like a search
+	Category      string `gorm:"index"` // category slug
+	Type          PostType
+	Frequency     SavedSearchFrequency
+	DateCreated   time.Time
+	DateUpdated   time.Time
+}
+
+// SavedSearchMatch is a new post waiting for the next digest of a saved search
+type SavedSearchMatch struct {
+	MatchID       uint `gorm:"primaryKey"`
+	SavedSearchID uint `gorm:"uniqueIndex:idx_saved_search_matches_search_post"`
+	PostID        uint `gorm:"uniqueIndex:idx_saved_search_matches_search_post"`
+	DateCreated   time.Time
+}
+
+// SavedSearchRequest - request body for creating or editing a saved search, frequency defaults to immediate
+type SavedSearchRequest struct {
+	Name      string               `json:"name" binding:"required"`
+	Query     string               `json:"query"`
+	Category  string               `json:"category"`
+	Type      PostType             `json:"type"`
+	Frequency SavedSearchFrequency `json:"frequency"`
+}
+
+type SavedSearchResponse struct {
+	SavedSearchID uint                 `json:"savedSearchID"`
+	Name          string               `json:"name"`
+	Query         string               `json:"query"`
+	Category      string               `json:"category,omitempty"`
+	Type          PostType             `json:"type,omitempty"`
+	Frequency     SavedSearchFrequency `json:"frequency"`
+	DateCreated   time.Time            `json:"date_created"`
+	DateUpdated   time.Time            `json:"date_updated"`
+}
+
+// PostSummariesResponse tells another service who owns the posts it asked about and where they stand
+type PostSummariesResponse struct {
+	Posts []PostSummary `json:"posts"`
+}
+
+type PostSummary struct {
+	PostID   uint       `json:"postID"`
+	UserID   uint       `json:"userID"`
+	Username string     `json:"username"`
+	Title    string     `json:"title"`
+	Type     PostType   `json:"type"`
+	Status   PostStatus `json:"status"`
+}
+
+// FeedToken lets feed readers and calendars fetch a user's feeds without their session cookie.
+// Only a hash of the token is kept, the user sees the token once when it's made.
+type FeedToken struct {
+	FeedTokenID uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"uniqueIndex"`
+	TokenHash   string `gorm:"uniqueIndex"`
+	DateCreated time.Time
+}
+
+// FeedTokenResponse is a new feed token with the feed URLs it unlocks. Category and saved search
+// feeds live under FeedsURL at /category/{slug} and /saved-searches/{id}.
+type FeedTokenResponse struct {
+	Token      string `json:"token"`
+	FeedsURL   string `json:"feeds_url"`
+	RecentURL  string `json:"recent_url"`
+	MatchesURL string `json:"matches_url"`
+}
+
+// ScheduledMatchesResponse is the match service's list of a user's matches with an agreed time
+type ScheduledMatchesResponse struct {
+	UserID  uint             `json:"userID"`
+	Matches []ScheduledMatch `json:"matches"`
+}
+
+type ScheduledMatch struct {
+	MatchID            uint      `json:"matchID"`
+	PostID             uint      `json:"postID"`
+	PostUserID         uint      `json:"postUserID"`
+	HelperUserID       uint      `json:"helperUserID"`
+	RecipientUserID    uint      `json:"recipientUserID"`
+	AgreedTime         time.Time `json:"agreed_time"`
+	FulfillmentDetails string    `json:"fulfillment_details"`
+}
+
+// RecurrenceFrequency is how often a post series is reposted
+type RecurrenceFrequency string
+
+const (
+	WeeklyRecurrence   RecurrenceFrequency = "weekly"
+	BiweeklyRecurrence RecurrenceFrequency = "biweekly"
+	MonthlyRecurrence  RecurrenceFrequency = "monthly"
+)
+
+// SeriesStatus is where a post series is in its life
+type SeriesStatus string
+
+const (
+	SeriesActive    SeriesStatus = "active"
+	SeriesPaused    SeriesStatus = "paused"    // no occurrences until the owner resumes it
+	SeriesCancelled SeriesStatus = "cancelled" // stopped by the owner, or its post was deleted
+	SeriesEnded     SeriesStatus = "ended"     // the end date passed
+)
+
+// PostSeries reposts a post on a recurrence rule. Each occurrence is a copy of the latest one, so
+// the owner's edits carry over, and the previous occurrence expires when the next one goes up.
+type PostSeries struct {
+	SeriesID       uint `gorm:"primaryKey"`
+	UserID         uint `gorm:"index"`
+	LatestPostID   uint `gorm:"index"`
+	Frequency      RecurrenceFrequency
+	EndsAt         time.Time
+	NextOccurrence time.Time `gorm:"index"`
+	Occurrences    int       `gorm:"default:1"` // posts in the series so far, counting the first
+	Status         SeriesStatus
+	DateCreated    time.Time
+	DateUpdated    time.Time
+}
+
+// PostSeriesRequest - request body for making a post recurring
+type PostSeriesRequest struct {
+	Frequency RecurrenceFrequency `json:"frequency" binding:"required"`
+	EndsAt    *time.Time          `json:"ends_at" binding:"required"`
+}
+
+type PostSeriesResponse struct {
+	SeriesID       uint                `json:"seriesID"`
+	LatestPostID   uint                `json:"latestPostID"`
+	Frequency      RecurrenceFrequency `json:"frequency"`
+	EndsAt         time.Time           `json:"ends_at"`
+	NextOccurrence *time.Time          `json:"next_occurrence,omitempty"`
+	Occurrences    int                 `json:"occurrences"`
+	Status         SeriesStatus        `json:"status"`
+	DateCreated    time.Time           `json:"date_created"`
+}
+
+// PostSearchCursor is the position of the last hit on a page, hits are ordered by rank then post ID.
+// When sorting by distance Rank holds the distance.
+type PostSearchCursor struct {
+	Rank   float64 `json:"r"`
+	PostID uint    `json:"id"`
+}
+
+// FeedCursor is the position of the last post on a page of the ranked feed, and when the feed was ranked
+type FeedCursor struct {
+	Score    float64 `json:"s"`
+	PostID   uint    `json:"id"`
+	RankedAt int64   `json:"t"`
+}
+
+// RankedPostResponse is a post in the ranked feed with its score
+type RankedPostResponse struct {
+	PostResponse
+	Score float64 `json:"score"`
+}
+
+// PostSearchHit is a matching post with its rank and highlighted fragments
+type PostSearchHit struct {
+	Post                 `gorm:"embedded"`
+	Rank                 float64
+	TitleHighlight       string
+	DescriptionHighlight string
+}
+
+type PostSearchHitResponse struct {
+	PostResponse
+	Rank                 float64 `json:"rank"`
+	TitleHighlight       string  `json:"title_highlight"`
+	DescriptionHighlight string  `json:"description_highlight"`
+}
+
+// ReportTarget is the kind of content a report is about
+type ReportTarget string
+
+const (
+	PostReportTarget ReportTarget = "post"
+	BidReportTarget  ReportTarget = "bid"
+	UserReportTarget ReportTarget = "user"
+)
+
+type ReportReason string
+
+const (
+	SpamReportReason      ReportReason = "spam"
+	ScamReportReason      ReportReason = "scam"
+	AbuseReportReason     ReportReason = "abuse"
+	OffensiveReportReason ReportReason = "offensive"
+	OtherReportReason     ReportReason = "other"
+	// filed by the content screening rather than a user
+	ScreeningReportReason ReportReason = "screening"
+)
+
+type ReportStatus string
+
+const (
+	OpenReport      ReportStatus = "open"
+	DismissedReport ReportStatus = "dismissed"
+	ActionedReport  ReportStatus = "actioned"
+)
+
+// ModerationAction is what a moderator decided to do about a report
+type ModerationAction string
+
+const (
+	DismissAction     ModerationAction = "dismiss"
+	HidePostAction    ModerationAction = "hide_post"
+	RemoveBidAction   ModerationAction = "remove_bid"
+	SuspendUserAction ModerationAction = "suspend_user"
+)
+
+// Report is a user's complaint about a post, bid or user, waiting in the moderation queue until resolved
+type Report struct {
+	ReportID        uint         `gorm:"primaryKey"`
+	TargetType      ReportTarget `gorm:"index:idx_reports_target"`
+	TargetID        uint         `gorm:"index:idx_reports_target"`
+	ReporterUserID  uint         `gorm:"index"`
+	Reason          ReportReason
+	Details         string
+	Status          ReportStatus `gorm:"index"`
+	Action          ModerationAction
+	ModeratorUserID uint // 0 until resolved
+	Resolution      string
+	DateCreated     time.Time
+	DateResolved    *time.Time
+}
+
+type ReportRequest struct {
+	TargetType ReportTarget `json:"target_type" binding:"required"`
+	TargetID   uint         `json:"targetID" binding:"required"`
+	Reason     ReportReason `json:"reason" binding:"required"`
+	Details    string       `json:"details"`
+}
+
+// FlaggedContentRequest - another service's screening flagged content it stored
+type FlaggedContentRequest struct {
+	TargetType ReportTarget `json:"target_type" binding:"required"`
+	TargetID   uint         `json:"targetID" binding:"required"`
+	Matches    []string     `json:"matches" binding:"required"`
+}
+
+// ReportResolveRequest - a moderator's decision, it resolves every open report on the same target
+type ReportResolveRequest struct {
+	Action ModerationAction `json:"action" binding:"required"`
+	Note   string           `json:"note"`
+}
+
+// ReportFilter narrows the moderation queue
+type ReportFilter struct {
+	Status     ReportStatus
+	TargetType ReportTarget
+	TargetID   uint
+}
+
+type ReportResponse struct {
+	ReportID        uint             `json:"reportID"`
+	TargetType      ReportTarget     `json:"target_type"`
+	TargetID        uint             `json:"targetID"`
+	ReporterUserID  uint             `json:"reporterID"`
+	Reason          ReportReason     `json:"reason"`
+	Details         string           `json:"details"`
+	Status          ReportStatus     `json:"status"`
+	Action          ModerationAction `json:"action,omitempty"`
+	ModeratorUserID uint             `json:"moderatorID,omitempty"`
+	Resolution      string           `json:"resolution,omitempty"`
+	DateCreated     time.Time        `json:"date_created"`
+	DateResolved    *time.Time       `json:"date_resolved,omitempty"`
+}
+
+// UserSuspensionRequest is the user service's internal suspension request
+type UserSuspensionRequest struct {
+	UserID    uint   `json:"userID"`
+	Suspended bool   `json:"suspended"`
+	Reason    string `json:"reason"`
 }
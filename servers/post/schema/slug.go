@@ -0,0 +1,13 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify lowercases a name and joins its words with dashes, "School Supplies" becomes "school-supplies"
+func Slugify(name string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
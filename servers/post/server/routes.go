@@ -8,14 +8,11 @@ import (
 	sharedController "github.com/GiveGetGo/shared/controller"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
-	"gorm.io/gorm"
 )
 
-func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
+func NewRouter(postUtils *utils.PostUtils, redisClient *redis.Client) *gin.Engine {
 	r := gin.Default()
 
-	// Set up verification utils
-	postUtils := utils.NewPostUtils(DB, redisClient)
 	defaultRateLimiter := middleware.SetupRateLimiter(redisClient, "60-M")
 	sensitiveRateLimiter := middleware.SetupRateLimiter(redisClient, "10-M")
 
@@ -24,6 +21,19 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 	unAuthGroup.Use(defaultRateLimiter)
 	{
 		unAuthGroup.GET("/post/health", sharedController.HealthCheckHandler())
+		unAuthGroup.GET("/post/categories", controller.GetCategoriesHandler(postUtils))
+		unAuthGroup.GET("/post/buildings", controller.GetCampusBuildingsHandler(postUtils))
+
+		// feed readers and calendars can't log in, the feed token in the path stands in for the session
+		unAuthGroup.GET("/post/feeds/:token/recent", controller.GetRecentFeedHandler(postUtils))
+		unAuthGroup.GET("/post/feeds/:token/category/:slug", controller.GetCategoryFeedHandler(postUtils))
+		unAuthGroup.GET("/post/feeds/:token/saved-searches/:id", controller.GetSavedSearchFeedHandler(postUtils))
+		unAuthGroup.GET("/post/feeds/:token/matches", controller.GetMatchCalendarHandler(postUtils))
+
+		// images on local disk are served by the post service, S3 images come straight from the bucket
+		if storage, ok := postUtils.Storage.(*utils.LocalImageStorage); ok {
+			unAuthGroup.Static("/post/media", storage.Dir)
+		}
 	}
 
 	// Public routes - with auth middleware
@@ -34,8 +44,18 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 		defaultPostAuthGroup := postAuthGroup.Group("")
 		{
 			defaultPostAuthGroup.GET("/post", controller.GetPostHandler(postUtils))
+			defaultPostAuthGroup.GET("/post/search", controller.SearchPostsHandler(postUtils))
 			defaultPostAuthGroup.GET("/post/:id", controller.GetPostByPostIdHandler(postUtils))
 			defaultPostAuthGroup.GET("/post/by-user", controller.GetPostByUserIdHandler(postUtils))
+			defaultPostAuthGroup.GET("/post/:id/history", controller.GetPostStatusHistoryHandler(postUtils))
+			defaultPostAuthGroup.GET("/post/:id/revisions", controller.GetPostRevisionsHandler(postUtils))
+			defaultPostAuthGroup.GET("/post/:id/analytics", controller.GetPostAnalyticsHandler(postUtils))
+			defaultPostAuthGroup.GET("/post/moderation/reports", controller.GetModerationQueueHandler(postUtils))
+			defaultPostAuthGroup.GET("/post/bookmarks", controller.GetBookmarksHandler(postUtils))
+			defaultPostAuthGroup.GET("/post/saved-searches", controller.GetSavedSearchesHandler(postUtils))
+			defaultPostAuthGroup.GET("/post/series", controller.GetSeriesHandler(postUtils))
+			defaultPostAuthGroup.POST("/post/:id/bookmark", controller.AddBookmarkHandler(postUtils))
+			defaultPostAuthGroup.DELETE("/post/:id/bookmark", controller.RemoveBookmarkHandler(postUtils))
 		}
 
 		sensitivePostAuthGroup := postAuthGroup.Group("")
@@ -45,6 +65,27 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 			sensitivePostAuthGroup.GET("/post/archive", controller.GetPostArchiveHandler(postUtils))
 			sensitivePostAuthGroup.PUT("/post/:id", controller.EditPostByIdHandler(postUtils))
 			sensitivePostAuthGroup.DELETE("/post/:id", controller.DeletePostHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/:id/renew", controller.RenewPostHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/:id/publish", controller.PublishPostHandler(postUtils))
+			sensitivePostAuthGroup.DELETE("/post/:id/publish", controller.UnschedulePostHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/:id/series", controller.StartSeriesHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/series/:id/pause", controller.PauseSeriesHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/series/:id/resume", controller.ResumeSeriesHandler(postUtils))
+			sensitivePostAuthGroup.DELETE("/post/series/:id", controller.CancelSeriesHandler(postUtils))
+			sensitivePostAuthGroup.PUT("/post/:id/status", controller.EditPostStatusHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/:id/images", controller.AddPostImagesHandler(postUtils))
+			sensitivePostAuthGroup.DELETE("/post/:id/images/:imageid", controller.DeletePostImageHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/reports", controller.AddReportHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/saved-searches", controller.AddSavedSearchHandler(postUtils))
+			sensitivePostAuthGroup.PUT("/post/saved-searches/:id", controller.EditSavedSearchHandler(postUtils))
+			sensitivePostAuthGroup.DELETE("/post/saved-searches/:id", controller.DeleteSavedSearchHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/feed-token", controller.CreateFeedTokenHandler(postUtils))
+			sensitivePostAuthGroup.DELETE("/post/feed-token", controller.RevokeFeedTokenHandler(postUtils))
+			sensitivePostAuthGroup.PUT("/post/moderation/reports/:id", controller.ResolveReportHandler(postUtils))
+			sensitivePostAuthGroup.GET("/post/admin/categories", controller.AdminGetCategoriesHandler(postUtils))
+			sensitivePostAuthGroup.POST("/post/admin/categories", controller.AdminAddCategoryHandler(postUtils))
+			sensitivePostAuthGroup.PUT("/post/admin/categories/:id", controller.AdminEditCategoryHandler(postUtils))
+			sensitivePostAuthGroup.DELETE("/post/admin/categories/:id", controller.AdminDeleteCategoryHandler(postUtils))
 		}
 	}
 
@@ -53,6 +94,8 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 	postInternalGroup.Use(middleware.InternalAuthMiddleware())
 	{
 		postInternalGroup.PUT("/post/status", controller.UpdatePostStatusHandler(postUtils))
+		postInternalGroup.GET("/post/summaries", controller.GetPostSummariesHandler(postUtils))
+		postInternalGroup.POST("/post/reports/flagged", controller.AddFlaggedContentHandler(postUtils))
 	}
 
 	return r
@@ -1,14 +1,25 @@
 package server
 
 import (
+	"context"
 	"post/db"
 	"post/middleware"
+	"post/utils"
 )
 
 func StartServer() {
 	DB := db.InitDB()                      // Initialize the database
 	redisClient := middleware.SetupRedis() // Set up Redis
 
-	r := NewRouter(DB, redisClient) // Set up the router and v1 routes
-	r.Run(":8080")                  // Start the server
+	// Expire overdue posts, publish scheduled drafts, repost recurring posts, send saved search
+	// digests and write post views in the background, safe to run on every replica
+	postUtils := utils.NewPostUtils(DB, redisClient)
+	go utils.RunExpiryWorker(context.Background(), postUtils, postUtils.Expiry.Interval)
+	go utils.RunPublishWorker(context.Background(), postUtils, postUtils.Publish.Interval)
+	go utils.RunRecurrenceWorker(context.Background(), postUtils, postUtils.Recurrence.Interval)
+	go utils.RunSavedSearchDigestWorker(context.Background(), postUtils, postUtils.Searches.DigestInterval)
+	go utils.RunViewFlushWorker(context.Background(), postUtils, postUtils.Views.FlushInterval)
+
+	r := NewRouter(postUtils, redisClient) // Set up the router and v1 routes
+	r.Run(":8080")                         // Start the server
 }
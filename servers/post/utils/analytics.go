@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"post/schema"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// a viewer's first look at a post each day is marked under this prefix
+	viewedKeyPrefix = "post:viewed"
+	// views waiting for the flush worker, as "postID:viewerID:day" members
+	pendingViewsKey = "post:views:pending"
+	// how long a viewed marker is kept, long enough to outlive the day it's for
+	viewedMarkerTTL = 48 * time.Hour
+
+	// views written per flush, the rest wait for the next one
+	viewFlushBatchSize = 500
+
+	// views are counted per UTC day
+	viewDayLayout = "2006-01-02"
+)
+
+// ViewConfig controls how post views are written to the database
+type ViewConfig struct {
+	FlushInterval time.Duration // how often the views waiting in redis are written
+}
+
+// ViewConfigFromEnv reads POST_VIEW_FLUSH_INTERVAL
+func ViewConfigFromEnv() ViewConfig {
+	return ViewConfig{
+		FlushInterval: envDuration("POST_VIEW_FLUSH_INTERVAL", time.Minute),
+	}
+}
+
+// ViewStats are a post's views, each viewer counted once a day
+type ViewStats struct {
+	Views         int64
+	UniqueViewers int64
+	Daily         map[string]int64 // views by day, only days since the requested one
+}
+
+// RecordView counts the viewer's first look at the post on the UTC day of at. The view waits in
+// redis until the flush worker writes it, so viewing a post never waits on the database.
+func (pu *PostUtils) RecordView(ctx context.Context, postID uint, viewerID uint, at time.Time) error {
+	day := at.UTC().Format(viewDayLayout)
+	marker := fmt.Sprintf("%s:%d:%d:%s", viewedKeyPrefix, postID, viewerID, day)
+
+	first, err := pu.RedisClient.SetNX(ctx, marker, 1, viewedMarkerTTL).Result()
+	if err != nil || !first {
+		return err
+	}
+	return pu.RedisClient.SAdd(ctx, pendingViewsKey, fmt.Sprintf("%d:%d:%s", postID, viewerID, day)).Err()
+}
+
+// FlushViews writes a batch of the views waiting in redis and returns how many it took. SPOP hands
+// each view to a single replica, and a batch that can't be written is put back for the next flush.
+func (pu *PostUtils) FlushViews(ctx context.Context) (int, error) {
+	members, err := pu.RedisClient.SPopN(ctx, pendingViewsKey, viewFlushBatchSize).Result()
+	if err != nil || len(members) == 0 {
+		return 0, err
+	}
+
+	views := make([]schema.PostView, 0, len(members))
+	for _, member := range members {
+		view, err := parsePendingView(member)
+		if err != nil {
+			log.Printf("Dropping malformed pending view %q: %v", member, err)
+			continue
+		}
+		views = append(views, view)
+	}
+	if len(views) == 0 {
+		return len(members), nil
+	}
+
+	err = pu.DB.Model(&schema.PostView{}).Clauses(clause.OnConflict{DoNothing: true}).Create(&views).Error
+	if err != nil {
+		pending := make([]interface{}, 0, len(members))
+		for _, member := range members {
+			pending = append(pending, member)
+		}
+		if restoreErr := pu.RedisClient.SAdd(ctx, pendingViewsKey, pending...).Err(); restoreErr != nil {
+			log.Printf("Error putting back %d pending views: %v", len(pending), restoreErr)
+		}
+		return 0, err
+	}
+
+	return len(members), nil
+}
+
+func parsePendingView(member string) (schema.PostView, error) {
+	parts := strings.Split(member, ":")
+	if len(parts) != 3 {
+		return schema.PostView{}, fmt.Errorf("expected 3 fields, got %d", len(parts))
+	}
+
+	postID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return schema.PostView{}, err
+	}
+	viewerID, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return schema.PostView{}, err
+	}
+	day, err := time.Parse(viewDayLayout, parts[2])
+	if err != nil {
+		return schema.PostView{}, err
+	}
+
+	return schema.PostView{PostID: uint(postID), ViewerID: uint(viewerID), Day: day}, nil
+}
+
+// GetViewStats counts a post's views and viewers, with the views of each day since since
+func (pu *PostUtils) GetViewStats(postID uint, since time.Time) (ViewStats, error) {
+	var totals struct {
+		Views         int64
+		UniqueViewers int64
+	}
+	err := pu.DB.Model(&schema.PostView{}).
+		Select("COUNT(*) AS views, COUNT(DISTINCT viewer_id) AS unique_viewers").
+		Where("post_id = ?", postID).
+		Scan(&totals).Error
+	if err != nil {
+		return ViewStats{}, err
+	}
+
+	var rows []struct {
+		Day   time.Time
+		Count int64
+	}
+	err = pu.DB.Model(&schema.PostView{}).
+		Select("day, COUNT(*) AS count").
+		Where("post_id = ? AND day >= ?", postID, since.UTC().Format(viewDayLayout)).
+		Group("day").
+		Scan(&rows).Error
+	if err != nil {
+		return ViewStats{}, err
+	}
+
+	stats := ViewStats{Views: totals.Views, UniqueViewers: totals.UniqueViewers, Daily: map[string]int64{}}
+	for _, row := range rows {
+		stats.Daily[row.Day.UTC().Format(viewDayLayout)] = row.Count
+	}
+	return stats, nil
+}
+
+// GetDailyBookmarks counts the bookmarks a post got on each day since since
+func (pu *PostUtils) GetDailyBookmarks(postID uint, since time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Day   time.Time
+		Count int64
+	}
+	err := pu.DB.Model(&schema.Bookmark{}).
+		Select("DATE(date_created) AS day, COUNT(*) AS count").
+		Where("post_id = ? AND date_created >= ?", postID, since).
+		Group("DATE(date_created)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	daily := map[string]int64{}
+	for _, row := range rows {
+		daily[row.Day.UTC().Format(viewDayLayout)] = row.Count
+	}
+	return daily, nil
+}
+
+// GetBidDates asks the bid service when each bid on the post was submitted, oldest first
+func (pu *PostUtils) GetBidDates(postID uint) ([]time.Time, error) {
+	bidServiceURL := os.Getenv("BID_SERVICE_URL") + fmt.Sprintf("/v1/internal/bid/post/%d/dates", postID)
+	req, err := http.NewRequest("GET", bidServiceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var dates schema.PostBidDatesResponse
+	if err := fetchInternalData(req, &dates); err != nil {
+		return nil, err
+	}
+	return dates.Submitted, nil
+}
+
+// BuildPostAnalytics puts a post's activity together, with a row for every UTC day from since, or
+// from the day it was posted if that's later, until now
+func BuildPostAnalytics(post schema.Post, since time.Time, now time.Time, views ViewStats, bookmarks int64, dailyBookmarks map[string]int64, bidDates []time.Time) schema.PostAnalyticsResponse {
+	analytics := schema.PostAnalyticsResponse{
+		PostID:        post.PostID,
+		Views:         views.Views,
+		UniqueViewers: views.UniqueViewers,
+		Bookmarks:     bookmarks,
+		Bids:          int64(len(bidDates)),
+		Daily:         []schema.PostAnalyticsDay{},
+	}
+
+	dailyBids := map[string]int64{}
+	for _, submitted := range bidDates {
+		dailyBids[submitted.UTC().Format(viewDayLayout)]++
+	}
+	if len(bidDates) > 0 {
+		seconds := int64(max(bidDates[0].Sub(post.DatePosted), 0) / time.Second)
+		analytics.TimeToFirstBid = &seconds
+	}
+
+	start := since.UTC()
+	if posted := post.DatePosted.UTC(); posted.After(start) {
+		start = posted
+	}
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+
+	for day := start; !day.After(now.UTC()); day = day.AddDate(0, 0, 1) {
+		date := day.Format(viewDayLayout)
+		analytics.Daily = append(analytics.Daily, schema.PostAnalyticsDay{
+			Date:      date,
+			Views:     views.Daily[date],
+			Bookmarks: dailyBookmarks[date],
+			Bids:      dailyBids[date],
+		})
+	}
+
+	return analytics
+}
+
+// RunViewFlushWorker writes the views waiting in redis to the database every interval until ctx is done
+func RunViewFlushWorker(ctx context.Context, postUtils IPostUtils, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			flushViews(ctx, postUtils)
+		}
+	}
+}
+
+func flushViews(ctx context.Context, postUtils IPostUtils) {
+	for {
+		flushed, err := postUtils.FlushViews(ctx)
+		if err != nil {
+			log.Printf("Error flushing post views: %v", err)
+			return
+		}
+
+		// a short batch means every waiting view was written
+		if flushed < viewFlushBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis keeps the keys and sets the view tracking uses in memory
+type fakeRedis struct {
+	keys map[string]bool
+	sets map[string][]string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{keys: map[string]bool{}, sets: map[string][]string{}}
+}
+
+func (f *fakeRedis) Ping(ctx context.Context) *redis.StatusCmd {
+	return redis.NewStatusResult("PONG", nil)
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	f.keys[key] = true
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	return redis.NewStringResult("", redis.Nil)
+}
+
+func (f *fakeRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	for _, key := range keys {
+		delete(f.keys, key)
+	}
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+
+func (f *fakeRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	if f.keys[key] {
+		return redis.NewBoolResult(false, nil)
+	}
+	f.keys[key] = true
+	return redis.NewBoolResult(true, nil)
+}
+
+func (f *fakeRedis) SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	for _, member := range members {
+		f.sets[key] = append(f.sets[key], member.(string))
+	}
+	return redis.NewIntResult(int64(len(members)), nil)
+}
+
+func (f *fakeRedis) SPopN(ctx context.Context, key string, count int64) *redis.StringSliceCmd {
+	members := f.sets[key]
+	n := min(int(count), len(members))
+	f.sets[key] = members[n:]
+	return redis.NewStringSliceResult(members[:n], nil)
+}
+
+func TestRecordViewOncePerDay(t *testing.T) {
+	fake := newFakeRedis()
+	postUtils := &PostUtils{RedisClient: fake}
+	ctx := context.Background()
+	morning := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+	require.NoError(t, postUtils.RecordView(ctx, 7, 3, morning))
+	require.NoError(t, postUtils.RecordView(ctx, 7, 3, morning.Add(5*time.Hour)))
+	require.NoError(t, postUtils.RecordView(ctx, 7, 4, morning))
+	require.NoError(t, postUtils.RecordView(ctx, 7, 3, morning.Add(24*time.Hour)))
+
+	assert.Equal(t, []string{"7:3:2024-03-04", "7:4:2024-03-04", "7:3:2024-03-05"}, fake.sets[pendingViewsKey])
+}
+
+func TestFlushViews(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	fake := newFakeRedis()
+	fake.sets[pendingViewsKey] = []string{"7:3:2024-03-04", "bogus", "7:4:2024-03-04"}
+	postUtils := &PostUtils{DB: db, RedisClient: fake}
+	day := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "post_views" \("post_id","viewer_id","day"\) VALUES \(\$1,\$2,\$3\),\(\$4,\$5,\$6\) ON CONFLICT DO NOTHING RETURNING "view_id"`).
+		WithArgs(7, 3, day, 7, 4, day).
+		WillReturnRows(sqlmock.NewRows([]string{"view_id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	flushed, err := postUtils.FlushViews(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, flushed)
+	assert.Empty(t, fake.sets[pendingViewsKey])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFlushViewsPutsBackOnFailure(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	fake := newFakeRedis()
+	fake.sets[pendingViewsKey] = []string{"7:3:2024-03-04"}
+	postUtils := &PostUtils{DB: db, RedisClient: fake}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "post_views"`).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, err := postUtils.FlushViews(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []string{"7:3:2024-03-04"}, fake.sets[pendingViewsKey])
+}
+
+func TestBuildPostAnalytics(t *testing.T) {
+	posted := time.Date(2024, time.March, 2, 15, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.March, 4, 10, 0, 0, 0, time.UTC)
+	since := time.Date(2024, time.February, 27, 0, 0, 0, 0, time.UTC)
+	post := schema.Post{PostID: 7, DatePosted: posted}
+
+	views := ViewStats{Views: 5, UniqueViewers: 3, Daily: map[string]int64{"2024-03-02": 1, "2024-03-04": 4}}
+	bookmarks := map[string]int64{"2024-03-03": 2}
+	bids := []time.Time{posted.Add(90 * time.Minute), now.Add(-time.Hour)}
+
+	analytics := BuildPostAnalytics(post, since, now, views, 2, bookmarks, bids)
+
+	assert.Equal(t, int64(5), analytics.Views)
+	assert.Equal(t, int64(3), analytics.UniqueViewers)
+	assert.Equal(t, int64(2), analytics.Bookmarks)
+	assert.Equal(t, int64(2), analytics.Bids)
+	require.NotNil(t, analytics.TimeToFirstBid)
+	assert.Equal(t, int64(90*60), *analytics.TimeToFirstBid)
+
+	// the days start when the post went up, not at since
+	assert.Equal(t, []schema.PostAnalyticsDay{
+		{Date: "2024-03-02", Views: 1, Bids: 1},
+		{Date: "2024-03-03", Bookmarks: 2},
+		{Date: "2024-03-04", Views: 4, Bids: 1},
+	}, analytics.Daily)
+}
+
+func TestBuildPostAnalyticsWithoutBids(t *testing.T) {
+	now := time.Date(2024, time.March, 4, 10, 0, 0, 0, time.UTC)
+	post := schema.Post{PostID: 7, DatePosted: now.Add(-time.Hour)}
+
+	analytics := BuildPostAnalytics(post, now.Truncate(24*time.Hour), now, ViewStats{}, 0, nil, nil)
+
+	assert.Nil(t, analytics.TimeToFirstBid)
+	assert.Equal(t, []schema.PostAnalyticsDay{{Date: "2024-03-04"}}, analytics.Daily)
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"pagination"
+	"post/schema"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BookmarkedPostNotification tells a user a post they bookmarked changed
+const BookmarkedPostNotification types.NotificationType = "bookmarkedpost"
+
+var ErrAlreadyBookmarked = errors.New("post is already bookmarked")
+
+// AddBookmark saves the post to the user's bookmarks
+func (pu *PostUtils) AddBookmark(userID uint, postID uint) error {
+	result := pu.DB.Model(&schema.Bookmark{}).Clauses(clause.OnConflict{DoNothing: true}).Create(&schema.Bookmark{
+		UserID:      userID,
+		PostID:      postID,
+		DateCreated: time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAlreadyBookmarked
+	}
+	return nil
+}
+
+// RemoveBookmark takes the post out of the user's bookmarks
+func (pu *PostUtils) RemoveBookmark(userID uint, postID uint) error {
+	result := pu.DB.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&schema.Bookmark{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetBookmarkedPosts retrieves a page of the posts the user bookmarked, leaving out hidden ones
+func (pu *PostUtils) GetBookmarkedPosts(userID uint, page pagination.Page) ([]schema.Post, error) {
+	query := pu.DB.Model(&schema.Post{}).
+		Select("posts.*, bookmarks.date_created AS bookmarked_at").
+		Joins("JOIN bookmarks ON bookmarks.post_id = posts.post_id").
+		Where("bookmarks.user_id = ? AND posts.status <> ?", userID, schema.Hidden)
+
+	var posts []schema.Post
+	if err := page.Apply(query).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// CountBookmarks counts the bookmarks of each post, posts nobody bookmarked are left out
+func (pu *PostUtils) CountBookmarks(postIDs []uint) (map[uint]int64, error) {
+	counts := map[uint]int64{}
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID uint
+		Count  int64
+	}
+	err := pu.DB.Model(&schema.Bookmark{}).
+		Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetBookmarkerIDs lists the users who bookmarked the post
+func (pu *PostUtils) GetBookmarkerIDs(postID uint) ([]uint, error) {
+	userIDs := []uint{}
+	if err := pu.DB.Model(&schema.Bookmark{}).Where("post_id = ?", postID).Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// NotifyBookmarkersOfStatus tells the users who bookmarked the post that it was matched, closed or expired
+func NotifyBookmarkersOfStatus(postUtils IPostUtils, post schema.Post) {
+	var description string
+	switch post.Status {
+	case schema.Matched:
+		description = fmt.Sprintf("\"%s\", a post you bookmarked, has been matched.", post.Title)
+	case schema.Closed:
+		description = fmt.Sprintf("\"%s\", a post you bookmarked, has been closed.", post.Title)
+	case schema.Expired:
+		description = fmt.Sprintf("\"%s\", a post you bookmarked, has expired.", post.Title)
+	default:
+		return
+	}
+
+	notifyBookmarkers(postUtils, post, description, nil)
+}
+
+// notifyBookmarkers sends the description to everyone who bookmarked the post, except the users in skip
+func notifyBookmarkers(postUtils IPostUtils, post schema.Post, description string, skip map[uint]bool) {
+	bookmarkers, err := postUtils.GetBookmarkerIDs(post.PostID)
+	if err != nil {
+		log.Printf("Error fetching the bookmarkers of post %d: %v", post.PostID, err)
+		return
+	}
+
+	for _, userID := range bookmarkers {
+		if skip[userID] {
+			continue
+		}
+		if err := postUtils.CreateNotification(userID, BookmarkedPostNotification, description); err != nil {
+			log.Printf("Error notifying user %d about bookmarked post %d: %v", userID, post.PostID, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddBookmarkTwice(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "bookmarks" \("user_id","post_id","date_created"\) VALUES \(\$1,\$2,\$3\) ON CONFLICT DO NOTHING RETURNING "bookmark_id"`).
+		WithArgs(4, 12, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"bookmark_id"}).AddRow(1))
+	mock.ExpectCommit()
+	// the unique index turns the second bookmark into a no-op
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "bookmarks" .+ ON CONFLICT DO NOTHING`).
+		WithArgs(4, 12, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"bookmark_id"}))
+	mock.ExpectCommit()
+
+	require.NoError(t, postUtils.AddBookmark(4, 12))
+	assert.ErrorIs(t, postUtils.AddBookmark(4, 12), ErrAlreadyBookmarked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountBookmarks(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db}
+
+	mock.ExpectQuery(`SELECT post_id, COUNT\(\*\) AS count FROM "bookmarks" WHERE post_id IN \(\$1,\$2,\$3\) GROUP BY "post_id"`).
+		WithArgs(3, 7, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "count"}).AddRow(3, 2).AddRow(9, 5))
+
+	counts, err := postUtils.CountBookmarks([]uint{3, 7, 9})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int64{3: 2, 9: 5}, counts)
+	assert.Zero(t, counts[7])
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// no posts, no query
+	counts, err = postUtils.CountBookmarks(nil)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"errors"
+	"post/schema"
+	"regexp"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCategory = errors.New("category is not in the registry or is inactive")
+	ErrCategoryCycle   = errors.New("category can't be its own ancestor")
+	ErrCategoryInUse   = errors.New("category still has posts or subcategories")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidCategorySlug reports whether slug is lowercase words joined by dashes
+func ValidCategorySlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+// GetCategories retrieves the registry ordered for display, optionally including inactive categories
+func (pu *PostUtils) GetCategories(includeInactive bool) ([]schema.Category, error) {
+	query := pu.DB.Model(&schema.Category{})
+	if !includeInactive {
+		query = query.Where("active = ?", true)
+	}
+
+	var categories []schema.Category
+	if err := query.Order("sort_order, name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (pu *PostUtils) GetCategoryByID(categoryID uint) (schema.Category, error) {
+	var category schema.Category
+	if err := pu.DB.First(&category, categoryID).Error; err != nil {
+		return schema.Category{}, err
+	}
+	return category, nil
+}
+
+func (pu *PostUtils) GetCategoryBySlug(slug string) (schema.Category, error) {
+	var category schema.Category
+	if err := pu.DB.Where("slug = ?", slug).First(&category).Error; err != nil {
+		return schema.Category{}, err
+	}
+	return category, nil
+}
+
+// ResolveCategory maps a post's category value to an active registry category.
+// The value may be the slug or the display name, and every ancestor must be active too.
+func (pu *PostUtils) ResolveCategory(value string) (schema.Category, error) {
+	category, err := pu.GetCategoryBySlug(schema.Slugify(value))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return schema.Category{}, ErrInvalidCategory
+		}
+		return schema.Category{}, err
+	}
+
+	for current := category; ; {
+		if !current.Active {
+			return schema.Category{}, ErrInvalidCategory
+		}
+		if current.ParentID == nil {
+			break
+		}
+		if current, err = pu.GetCategoryByID(*current.ParentID); err != nil {
+			return schema.Category{}, err
+		}
+	}
+
+	return category, nil
+}
+
+func (pu *PostUtils) AddCategory(category schema.Category) (schema.Category, error) {
+	if err := pu.checkCategoryParent(category); err != nil {
+		return schema.Category{}, err
+	}
+
+	if err := pu.DB.Create(&category).Error; err != nil {
+		return schema.Category{}, err
+	}
+	return category, nil
+}
+
+// UpdateCategory saves a category, moving its posts along when the slug changes
+func (pu *PostUtils) UpdateCategory(category schema.Category) error {
+	if err := pu.checkCategoryParent(category); err != nil {
+		return err
+	}
+
+	existing, err := pu.GetCategoryByID(category.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	return pu.DB.Transaction(func(tx *gorm.DB) error {
+		if existing.Slug != category.Slug {
+			err := tx.Model(&schema.Post{}).Where("category = ?", existing.Slug).Update("category", category.Slug).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Save(&category).Error
+	})
+}
+
+// DeleteCategory removes an unused category, categories with posts or children should be deactivated instead
+func (pu *PostUtils) DeleteCategory(categoryID uint) error {
+	category, err := pu.GetCategoryByID(categoryID)
+	if err != nil {
+		return err
+	}
+
+	var posts, children int64
+	if err := pu.DB.Model(&schema.Post{}).Where("category = ?", category.Slug).Count(&posts).Error; err != nil {
+		return err
+	}
+	if err := pu.DB.Model(&schema.Category{}).Where("parent_id = ?", categoryID).Count(&children).Error; err != nil {
+		return err
+	}
+	if posts > 0 || children > 0 {
+		return ErrCategoryInUse
+	}
+
+	return pu.DB.Delete(&category).Error
+}
+
+// CountActivePostsByCategory returns the number of active posts per category slug
+func (pu *PostUtils) CountActivePostsByCategory() (map[string]int64, error) {
+	var rows []struct {
+		Category string
+		Count    int64
+	}
+	err := pu.DB.Model(&schema.Post{}).
+		Select("category, count(*) AS count").
+		Where("status = ?", schema.Active).
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts, nil
+}
+
+// checkCategoryParent makes sure the parent exists and that reparenting doesn't create a cycle
+func (pu *PostUtils) checkCategoryParent(category schema.Category) error {
+	for parentID := category.ParentID; parentID != nil; {
+		if category.CategoryID != 0 && *parentID == category.CategoryID {
+			return ErrCategoryCycle
+		}
+
+		parent, err := pu.GetCategoryByID(*parentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidCategory
+			}
+			return err
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// BuildCategoryTree nests categories under their parents. A category's post count
+// includes the posts in its subcategories.
+func BuildCategoryTree(categories []schema.Category, counts map[string]int64) []schema.CategoryResponse {
+	children := make(map[uint][]schema.Category)
+	known := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		known[category.CategoryID] = true
+	}
+
+	var roots []schema.Category
+	for _, category := range categories {
+		// a category whose parent was filtered out is left out with it
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else if known[*category.ParentID] {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var build func(categories []schema.Category) []schema.CategoryResponse
+	build = func(categories []schema.Category) []schema.CategoryResponse {
+		sort.SliceStable(categories, func(i, j int) bool {
+			return categories[i].SortOrder < categories[j].SortOrder
+		})
+
+		nodes := []schema.CategoryResponse{}
+		for _, category := range categories {
+			node := schema.CategoryResponse{
+				CategoryID:          category.CategoryID,
+				ParentID:            category.ParentID,
+				Slug:                category.Slug,
+				Name:                category.Name,
+				Icon:                category.Icon,
+				Active:              category.Active,
+				SortOrder:           category.SortOrder,
+				DefaultLifetimeDays: category.DefaultLifetimeDays,
+				PostCount:           counts[category.Slug],
+				Children:            build(children[category.CategoryID]),
+			}
+			for _, child := range node.Children {
+				node.PostCount += child.PostCount
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+
+	return build(roots)
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCategoryTree(t *testing.T) {
+	books, electronics := uint(1), uint(3)
+	categories := []schema.Category{
+		{CategoryID: 3, Slug: "electronics", SortOrder: 1},
+		{CategoryID: 1, Slug: "books", SortOrder: 0},
+		{CategoryID: 2, Slug: "textbooks", ParentID: &books},
+		{CategoryID: 4, Slug: "calculators", ParentID: &electronics},
+		{CategoryID: 5, Slug: "orphan", ParentID: new(uint)},
+	}
+	counts := map[string]int64{"books": 2, "textbooks": 5, "calculators": 1}
+
+	tree := BuildCategoryTree(categories, counts)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "books", tree[0].Slug)
+	assert.Equal(t, int64(7), tree[0].PostCount)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, int64(5), tree[0].Children[0].PostCount)
+	assert.Equal(t, "electronics", tree[1].Slug)
+	assert.Equal(t, int64(1), tree[1].PostCount)
+}
+
+func TestValidCategorySlug(t *testing.T) {
+	assert.True(t, ValidCategorySlug("school-supplies"))
+	assert.False(t, ValidCategorySlug("School Supplies"))
+	assert.False(t, ValidCategorySlug("-books"))
+	assert.False(t, ValidCategorySlug(""))
+}
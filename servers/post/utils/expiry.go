@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"post/schema"
+	"strconv"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PostExpiringNotification types.NotificationType = "postexpiring"
+	PostExpiredNotification  types.NotificationType = "postexpired"
+
+	// posts handled per worker pass, the rest wait for the next tick
+	expiryWorkerBatchSize = 100
+)
+
+var (
+	ErrInvalidExpiry    = errors.New("expiry must be in the future and within the maximum post lifetime")
+	ErrPostNotRenewable = errors.New("only active or expired posts can be renewed")
+)
+
+// ExpiryConfig controls post lifetimes and the expiry worker
+type ExpiryConfig struct {
+	Interval        time.Duration // how often the worker runs
+	Notice          time.Duration // how long before expiry the owner is warned
+	DefaultLifetime time.Duration // lifetime when neither the owner nor the category choose one
+	MaxLifetime     time.Duration // the furthest an owner may push the expiry
+}
+
+// ExpiryConfigFromEnv reads POST_EXPIRY_INTERVAL, POST_EXPIRY_NOTICE, POST_DEFAULT_LIFETIME_DAYS and POST_MAX_LIFETIME_DAYS
+func ExpiryConfigFromEnv() ExpiryConfig {
+	return ExpiryConfig{
+		Interval:        envDuration("POST_EXPIRY_INTERVAL", time.Minute),
+		Notice:          envDuration("POST_EXPIRY_NOTICE", 48*time.Hour),
+		DefaultLifetime: envDays("POST_DEFAULT_LIFETIME_DAYS", schema.DefaultPostLifetimeDays),
+		MaxLifetime:     envDays("POST_MAX_LIFETIME_DAYS", 60),
+	}
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func envDays(name string, fallback int) time.Duration {
+	days, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || days < 1 {
+		days = fallback
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PostLifetime is the category's default lifetime, inherited from the closest ancestor that sets one
+func (pu *PostUtils) PostLifetime(category schema.Category) (time.Duration, error) {
+	for current := category; ; {
+		if current.DefaultLifetimeDays > 0 {
+			return time.Duration(current.DefaultLifetimeDays) * 24 * time.Hour, nil
+		}
+		if current.ParentID == nil {
+			return pu.Expiry.DefaultLifetime, nil
+		}
+
+		var err error
+		if current, err = pu.GetCategoryByID(*current.ParentID); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// ResolveExpiry validates the owner's requested expiry, or defaults it from the category
+func (pu *PostUtils) ResolveExpiry(category schema.Category, requested *time.Time) (time.Time, error) {
+	now := time.Now()
+	if requested == nil {
+		lifetime, err := pu.PostLifetime(category)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(lifetime), nil
+	}
+
+	if !requested.After(now) || requested.After(now.Add(pu.Expiry.MaxLifetime)) {
+		return time.Time{}, ErrInvalidExpiry
+	}
+	return *requested, nil
+}
+
+// RenewPost extends an active post or reopens an expired one until expiresAt
+func (pu *PostUtils) RenewPost(postID uint, ownerID uint, expiresAt time.Time) error {
+	return pu.DB.Transaction(func(tx *gorm.DB) error {
+		var post schema.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"expires_at":      expiresAt,
+			"expiry_notified": false,
+		}
+
+		switch post.Status {
+		case schema.Active:
+			updates["date_updated"] = time.Now()
+			return tx.Model(&post).Updates(updates).Error
+		case schema.Expired:
+			return transitionPost(tx, &post, schema.Active, schema.OwnerActor, ownerID, "renewed", updates)
+		default:
+			return ErrPostNotRenewable
+		}
+	})
+}
+
+// ExpireOverduePosts moves a batch of overdue active posts to Expired. Rows locked by
+// another replica are skipped, so each post is expired by exactly one worker.
+func (pu *PostUtils) ExpireOverduePosts() ([]schema.Post, error) {
+	return pu.claimPosts(func(tx *gorm.DB, posts []schema.Post) error {
+		for i := range posts {
+			if err := transitionPost(tx, &posts[i], schema.Expired, schema.SchedulerActor, 0, "expired", nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, "status = ? AND expires_at <= ?", schema.Active, time.Now())
+}
+
+// ClaimExpiringPosts marks a batch of posts that expire within the notice period as notified and returns them
+func (pu *PostUtils) ClaimExpiringPosts() ([]schema.Post, error) {
+	now := time.Now()
+	return pu.claimPosts(func(tx *gorm.DB, posts []schema.Post) error {
+		postIDs := make([]uint, 0, len(posts))
+		for _, post := range posts {
+			postIDs = append(postIDs, post.PostID)
+		}
+		return tx.Model(&schema.Post{}).Where("post_id IN ?", postIDs).Update("expiry_notified", true).Error
+	}, "status = ? AND expiry_notified = ? AND expires_at > ? AND expires_at <= ?",
+		schema.Active, false, now, now.Add(pu.Expiry.Notice))
+}
+
+// claimPosts locks a batch of posts matching the condition with FOR UPDATE SKIP LOCKED and hands them to apply
+func (pu *PostUtils) claimPosts(apply func(tx *gorm.DB, posts []schema.Post) error, condition string, args ...interface{}) ([]schema.Post, error) {
+	var posts []schema.Post
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(condition, args...).
+			Order("expires_at").
+			Limit(expiryWorkerBatchSize).
+			Find(&posts).Error
+		if err != nil || len(posts) == 0 {
+			return err
+		}
+
+		return apply(tx, posts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// RunExpiryWorker warns owners about posts that are about to expire and expires overdue ones until ctx is done
+func RunExpiryWorker(ctx context.Context, postUtils IPostUtils, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runExpiry(postUtils)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runExpiry(postUtils IPostUtils) {
+	expiring, err := postUtils.ClaimExpiringPosts()
+	if err != nil {
+		log.Printf("Error claiming expiring posts: %v", err)
+	}
+	for _, post := range expiring {
+		description := fmt.Sprintf("Your post \"%s\" expires on %s. Renew it to keep it active.", post.Title, post.ExpiresAt.Format("Jan 2 15:04"))
+		if err := postUtils.CreateNotification(post.UserID, PostExpiringNotification, description); err != nil {
+			log.Printf("Error notifying user %d about expiring post %d: %v", post.UserID, post.PostID, err)
+		}
+	}
+
+	expired, err := postUtils.ExpireOverduePosts()
+	if err != nil {
+		log.Printf("Error expiring posts: %v", err)
+	}
+	for _, post := range expired {
+		description := fmt.Sprintf("Your post \"%s\" has expired. You can renew it from your posts.", post.Title)
+		if err := postUtils.CreateNotification(post.UserID, PostExpiredNotification, description); err != nil {
+			log.Printf("Error notifying user %d about expired post %d: %v", post.UserID, post.PostID, err)
+		}
+		NotifyBookmarkersOfStatus(postUtils, post)
+	}
+}
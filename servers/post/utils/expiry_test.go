@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveExpiry(t *testing.T) {
+	postUtils := &PostUtils{Expiry: ExpiryConfig{DefaultLifetime: 14 * 24 * time.Hour, MaxLifetime: 30 * 24 * time.Hour}}
+
+	t.Run("category lifetime", func(t *testing.T) {
+		expiresAt, err := postUtils.ResolveExpiry(schema.Category{DefaultLifetimeDays: 3}, nil)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(3*24*time.Hour), expiresAt, time.Minute)
+	})
+
+	t.Run("default lifetime", func(t *testing.T) {
+		expiresAt, err := postUtils.ResolveExpiry(schema.Category{}, nil)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(14*24*time.Hour), expiresAt, time.Minute)
+	})
+
+	t.Run("owner choice", func(t *testing.T) {
+		requested := time.Now().Add(20 * 24 * time.Hour)
+		expiresAt, err := postUtils.ResolveExpiry(schema.Category{DefaultLifetimeDays: 3}, &requested)
+		assert.NoError(t, err)
+		assert.Equal(t, requested, expiresAt)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		_, err := postUtils.ResolveExpiry(schema.Category{}, &past)
+		assert.ErrorIs(t, err, ErrInvalidExpiry)
+
+		tooFar := time.Now().Add(31 * 24 * time.Hour)
+		_, err = postUtils.ResolveExpiry(schema.Category{}, &tooFar)
+		assert.ErrorIs(t, err, ErrInvalidExpiry)
+	})
+}
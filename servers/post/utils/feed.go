@@ -0,0 +1,409 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"post/schema"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+)
+
+// FeedWeights are how much each signal counts towards a post's score in the ranked feed.
+// Every signal is scaled to between 0 and 1 before it's weighted.
+type FeedWeights struct {
+	Recency    float64 // newer posts first, halving every RecencyHalfLife
+	Major      float64 // the poster studies the viewer's major
+	Class      float64 // the poster is in the viewer's class
+	Category   float64 // the viewer bid in the post's category before
+	Reputation float64 // the poster's reputation
+	Bids       float64 // the post has few bids yet and needs helpers more
+}
+
+// FeedConfig controls the ranked feed
+type FeedConfig struct {
+	Ranker          string // "weighted" or "recency"
+	Weights         FeedWeights
+	RecencyHalfLife time.Duration
+	Candidates      int // the most recent posts that are ranked, older ones don't make the ranked feed
+}
+
+// FeedConfigFromEnv reads POST_FEED_RANKER, POST_FEED_WEIGHT_*, POST_FEED_RECENCY_HALF_LIFE and POST_FEED_CANDIDATES
+func FeedConfigFromEnv() FeedConfig {
+	candidates, err := strconv.Atoi(os.Getenv("POST_FEED_CANDIDATES"))
+	if err != nil || candidates < 1 {
+		candidates = 300
+	}
+
+	return FeedConfig{
+		Ranker: os.Getenv("POST_FEED_RANKER"),
+		Weights: FeedWeights{
+			Recency:    envWeight("POST_FEED_WEIGHT_RECENCY", 3),
+			Major:      envWeight("POST_FEED_WEIGHT_MAJOR", 1),
+			Class:      envWeight("POST_FEED_WEIGHT_CLASS", 0.5),
+			Category:   envWeight("POST_FEED_WEIGHT_CATEGORY", 1.5),
+			Reputation: envWeight("POST_FEED_WEIGHT_REPUTATION", 1),
+			Bids:       envWeight("POST_FEED_WEIGHT_BIDS", 1),
+		},
+		RecencyHalfLife: envDuration("POST_FEED_RECENCY_HALF_LIFE", 48*time.Hour),
+		Candidates:      candidates,
+	}
+}
+
+// envWeight reads a weight, which may be 0 to turn a signal off or negative to turn it around
+func envWeight(name string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// FeedRanker orders the candidate posts of a viewer's feed
+type FeedRanker interface {
+	Rank(posts []schema.Post, signals FeedSignals) []RankedPost
+}
+
+// Ensure both rankers implement FeedRanker
+var _ FeedRanker = WeightedFeedRanker{}
+var _ FeedRanker = RecencyFeedRanker{}
+
+// NewFeedRanker picks the ranker from the config, the weighted one unless set to "recency"
+func NewFeedRanker(config FeedConfig) FeedRanker {
+	if config.Ranker == "recency" {
+		return RecencyFeedRanker{HalfLife: config.RecencyHalfLife}
+	}
+	return WeightedFeedRanker{Weights: config.Weights, HalfLife: config.RecencyHalfLife}
+}
+
+// FeedRanker is the ranker the personalized feed uses
+func (pu *PostUtils) FeedRanker() FeedRanker {
+	return pu.Ranker
+}
+
+// FeedSignals is what the ranker knows about the viewer and the candidate posts
+type FeedSignals struct {
+	Now           time.Time
+	Viewer        schema.UserInfoResponse
+	Posters       map[uint]schema.UserProfile // by user ID
+	BidCounts     map[uint]int64              // by post ID
+	BidCategories map[string]int              // how many of the viewer's bids were in each category
+}
+
+// RankedPost is a post with its score in the viewer's feed
+type RankedPost struct {
+	Post  schema.Post
+	Score float64
+}
+
+// reputationScale is the reputation that scores half of the reputation weight
+const reputationScale = 10.0
+
+// WeightedFeedRanker scores posts by a weighted sum of the signals
+type WeightedFeedRanker struct {
+	Weights  FeedWeights
+	HalfLife time.Duration
+}
+
+func (r WeightedFeedRanker) Rank(posts []schema.Post, signals FeedSignals) []RankedPost {
+	mostBidCategory := 0
+	for _, count := range signals.BidCategories {
+		mostBidCategory = max(mostBidCategory, count)
+	}
+
+	ranked := make([]RankedPost, 0, len(posts))
+	for _, post := range posts {
+		poster := signals.Posters[post.UserID]
+
+		score := r.Weights.Recency * recencyScore(post, signals.Now, r.HalfLife)
+		if sameField(poster.Major, signals.Viewer.Major) {
+			score += r.Weights.Major
+		}
+		if sameField(poster.Class, signals.Viewer.Class) {
+			score += r.Weights.Class
+		}
+		if mostBidCategory > 0 {
+			score += r.Weights.Category * float64(signals.BidCategories[post.Category]) / float64(mostBidCategory)
+		}
+		if poster.ReputationScore > 0 {
+			reputation := float64(poster.ReputationScore)
+			score += r.Weights.Reputation * reputation / (reputation + reputationScale)
+		}
+		score += r.Weights.Bids / float64(1+signals.BidCounts[post.PostID])
+
+		ranked = append(ranked, RankedPost{Post: post, Score: roundScore(score)})
+	}
+
+	sortRanked(ranked)
+	return ranked
+}
+
+// RecencyFeedRanker scores posts by age alone, a ranked feed that ignores the viewer
+type RecencyFeedRanker struct {
+	HalfLife time.Duration
+}
+
+func (r RecencyFeedRanker) Rank(posts []schema.Post, signals FeedSignals) []RankedPost {
+	ranked := make([]RankedPost, 0, len(posts))
+	for _, post := range posts {
+		ranked = append(ranked, RankedPost{Post: post, Score: roundScore(recencyScore(post, signals.Now, r.HalfLife))})
+	}
+
+	sortRanked(ranked)
+	return ranked
+}
+
+// recencyScore is 1 for a post made now, halving every half life
+func recencyScore(post schema.Post, now time.Time, halfLife time.Duration) float64 {
+	age := now.Sub(post.DatePosted)
+	if age < 0 || halfLife <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+func sameField(a, b string) bool {
+	return a != "" && strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// roundScore drops the noise below the sixth decimal, so equal scores compare equal across pages
+func roundScore(score float64) float64 {
+	return math.Round(score*1e6) / 1e6
+}
+
+// sortRanked orders by score, ties go to the newer post
+func sortRanked(ranked []RankedPost) {
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Post.PostID > ranked[j].Post.PostID
+	})
+}
+
+// RankFeed ranks the candidate posts for the viewer as of now. Signals another service couldn't provide are
+// left out rather than failing the feed.
+func RankFeed(postUtils IPostUtils, viewer schema.UserInfoResponse, posts []schema.Post, now time.Time) []RankedPost {
+	signals := FeedSignals{Now: now, Viewer: viewer}
+
+	postIDs := make([]uint, 0, len(posts))
+	var posterIDs []uint
+	seen := map[uint]bool{}
+	for _, post := range posts {
+		postIDs = append(postIDs, post.PostID)
+		if !seen[post.UserID] {
+			seen[post.UserID] = true
+			posterIDs = append(posterIDs, post.UserID)
+		}
+	}
+
+	var err error
+	if signals.Posters, err = postUtils.GetUserProfiles(posterIDs); err != nil {
+		log.Printf("Error fetching poster profiles for the feed: %v", err)
+	}
+	if signals.BidCounts, err = postUtils.GetBidCounts(postIDs); err != nil {
+		log.Printf("Error fetching bid counts for the feed: %v", err)
+	}
+
+	bidPostIDs, err := postUtils.GetBidPostIDs(viewer.UserID)
+	if err == nil {
+		signals.BidCategories, err = postUtils.CountPostCategories(bidPostIDs)
+	}
+	if err != nil {
+		log.Printf("Error fetching the categories user %d bid in: %v", viewer.UserID, err)
+	}
+
+	return postUtils.FeedRanker().Rank(posts, signals)
+}
+
+// GetFeedCandidates retrieves the most recent active posts of the last days, the ones the ranked feed orders
+func (pu *PostUtils) GetFeedCandidates(days int, filter schema.PostFilter) ([]schema.Post, error) {
+	since := time.Now().AddDate(0, 0, -days)
+
+	var posts []schema.Post
+	query := pu.filterPosts(pu.DB.Where("date_posted >= ? AND status = ?", since, schema.Active), filter)
+	if err := query.Order("date_posted desc").Order("post_id desc").Limit(pu.Feed.Candidates).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// CountPostCategories counts the posts in each category
+func (pu *PostUtils) CountPostCategories(postIDs []uint) (map[string]int, error) {
+	counts := map[string]int{}
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		Category string
+		Count    int
+	}
+	err := pu.DB.Model(&schema.Post{}).
+		Select("category, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts, nil
+}
+
+// GetUserProfiles looks up the public profiles of the users through the user service
+func (pu *PostUtils) GetUserProfiles(userIDs []uint) (map[uint]schema.UserProfile, error) {
+	profiles := map[uint]schema.UserProfile{}
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+
+	body, err := json.Marshal(schema.UserProfilesRequest{UserIDs: userIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	userServiceURL := os.Getenv("USER_SERVICE_URL") + "/v1/internal/user/profiles"
+	req, err := http.NewRequest("POST", userServiceURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var found []schema.UserProfile
+	if err := fetchInternalData(req, &found); err != nil {
+		return nil, err
+	}
+
+	for _, profile := range found {
+		profiles[profile.UserID] = profile
+	}
+	return profiles, nil
+}
+
+// GetBidCounts asks the bid service how many live bids each post has
+func (pu *PostUtils) GetBidCounts(postIDs []uint) (map[uint]int64, error) {
+	if len(postIDs) == 0 {
+		return map[uint]int64{}, nil
+	}
+
+	ids := make([]string, 0, len(postIDs))
+	for _, postID := range postIDs {
+		ids = append(ids, strconv.FormatUint(uint64(postID), 10))
+	}
+
+	bidServiceURL := os.Getenv("BID_SERVICE_URL") + "/v1/internal/bid/counts?post_ids=" + strings.Join(ids, ",")
+	req, err := http.NewRequest("GET", bidServiceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var counts schema.BidCountsResponse
+	if err := fetchInternalData(req, &counts); err != nil {
+		return nil, err
+	}
+	if counts.Counts == nil {
+		counts.Counts = map[uint]int64{}
+	}
+	return counts.Counts, nil
+}
+
+// GetBidPostIDs asks the bid service which posts the user has bid on
+func (pu *PostUtils) GetBidPostIDs(userID uint) ([]uint, error) {
+	bidServiceURL := os.Getenv("BID_SERVICE_URL") + fmt.Sprintf("/v1/internal/bid/user/%d/posts", userID)
+	req, err := http.NewRequest("GET", bidServiceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var posts schema.UserBidPostsResponse
+	if err := fetchInternalData(req, &posts); err != nil {
+		return nil, err
+	}
+	if posts.UserID != userID {
+		return nil, errors.New("bid service answered for another user")
+	}
+	return posts.PostIDs, nil
+}
+
+// fetchInternalData sends a request to another service as the post service and decodes the data of its 200 response
+func fetchInternalData(req *http.Request, data interface{}) error {
+	req.Header.Set("X-Service", "POST")
+	req.Header.Set("X-Api-Key", os.Getenv("POST_API_KEY"))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status: %d", req.URL.Host, resp.StatusCode)
+	}
+
+	var fullResponse types.FullResponseWithData
+	if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(fullResponse.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, data)
+}
+
+// EncodeFeedCursor makes the opaque next_cursor for the post that ends a page of the ranked feed.
+// The cursor keeps the time the feed was ranked at so later pages are ranked the same way.
+func EncodeFeedCursor(ranked RankedPost, rankedAt time.Time) string {
+	data, _ := json.Marshal(schema.FeedCursor{Score: ranked.Score, PostID: ranked.Post.PostID, RankedAt: rankedAt.Unix()})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeFeedCursor reverses EncodeFeedCursor
+func DecodeFeedCursor(cursor string) (*schema.FeedCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid feed cursor")
+	}
+
+	var decoded schema.FeedCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.PostID == 0 || decoded.RankedAt == 0 {
+		return nil, errors.New("invalid feed cursor")
+	}
+
+	return &decoded, nil
+}
+
+// PageRankedFeed returns the ranked posts that follow the cursor, up to limit, and whether more follow
+func PageRankedFeed(ranked []RankedPost, after *schema.FeedCursor, limit int) ([]RankedPost, bool) {
+	start := 0
+	if after != nil {
+		start = len(ranked)
+		for i, post := range ranked {
+			if post.Score < after.Score || (post.Score == after.Score && post.Post.PostID < after.PostID) {
+				start = i
+				break
+			}
+		}
+	}
+
+	ranked = ranked[start:]
+	if len(ranked) > limit {
+		return ranked[:limit], true
+	}
+	return ranked, false
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feedFixture is a viewer, the posts in their feed and what the other services know about them, as of a fixed time
+func feedFixture() ([]schema.Post, FeedSignals) {
+	now := time.Date(2024, 4, 10, 12, 0, 0, 0, time.UTC)
+	posts := []schema.Post{
+		{PostID: 1, UserID: 11, Title: "Old couch", Category: "furniture", DatePosted: now.Add(-96 * time.Hour)},
+		{PostID: 2, UserID: 12, Title: "CS 251 notes", Category: "textbooks", DatePosted: now.Add(-48 * time.Hour)},
+		{PostID: 3, UserID: 13, Title: "Desk lamp", Category: "furniture", DatePosted: now.Add(-1 * time.Hour)},
+		{PostID: 4, UserID: 14, Title: "Ride to Chicago", Category: "rides", DatePosted: now.Add(-2 * time.Hour)},
+		{PostID: 5, UserID: 12, Title: "MA 261 textbook", Category: "textbooks", DatePosted: now.Add(-2 * time.Hour)},
+	}
+
+	signals := FeedSignals{
+		Now:    now,
+		Viewer: schema.UserInfoResponse{UserInfoResponse: types.UserInfoResponse{UserID: 20, Major: "Computer Science", Class: "2026"}},
+		Posters: map[uint]schema.UserProfile{
+			11: {UserID: 11, Major: "History", ReputationScore: 40},
+			12: {UserID: 12, Major: "computer science", Class: "2026", ReputationScore: 10},
+			13: {UserID: 13, Major: "Biology", Class: "2025"},
+			14: {UserID: 14, Major: "Biology", Class: "2026", ReputationScore: -3},
+		},
+		BidCounts:     map[uint]int64{3: 4, 4: 1},
+		BidCategories: map[string]int{"textbooks": 2, "rides": 1},
+	}
+	return posts, signals
+}
+
+func TestWeightedFeedRanker(t *testing.T) {
+	posts, signals := feedFixture()
+	ranker := WeightedFeedRanker{
+		Weights:  FeedWeights{Recency: 3, Major: 1, Class: 0.5, Category: 1.5, Reputation: 1, Bids: 1},
+		HalfLife: 48 * time.Hour,
+	}
+
+	ranked := ranker.Rank(posts, signals)
+	require.Len(t, ranked, 5)
+
+	order := make([]uint, 0, len(ranked))
+	for _, post := range ranked {
+		order = append(order, post.Post.PostID)
+	}
+	// the textbook from a classmate in the viewer's major beats newer posts, the old couch comes last
+	assert.Equal(t, []uint{5, 2, 4, 3, 1}, order)
+
+	// recency 3*0.5^(2/48) + major 1 + class 0.5 + category 1.5 + reputation 10/20 + no bids 1
+	assert.InDelta(t, 7.414596, ranked[0].Score, 1e-6)
+	// the same signals rank the same way every time
+	assert.Equal(t, ranked, ranker.Rank(posts, signals))
+}
+
+func TestRecencyFeedRanker(t *testing.T) {
+	posts, signals := feedFixture()
+
+	ranked := RecencyFeedRanker{HalfLife: 48 * time.Hour}.Rank(posts, signals)
+
+	order := make([]uint, 0, len(ranked))
+	for _, post := range ranked {
+		order = append(order, post.Post.PostID)
+	}
+	// posts of the same age are ordered newest ID first
+	assert.Equal(t, []uint{3, 5, 4, 2, 1}, order)
+	assert.Equal(t, 0.5, ranked[3].Score)
+}
+
+func TestPageRankedFeed(t *testing.T) {
+	posts, signals := feedFixture()
+	rankedAt := signals.Now
+	ranked := RecencyFeedRanker{HalfLife: 48 * time.Hour}.Rank(posts, signals)
+
+	page, hasMore := PageRankedFeed(ranked, nil, 2)
+	assert.True(t, hasMore)
+	require.Len(t, page, 2)
+
+	cursor, err := DecodeFeedCursor(EncodeFeedCursor(page[1], rankedAt))
+	require.NoError(t, err)
+	assert.Equal(t, rankedAt.Unix(), cursor.RankedAt)
+
+	page, hasMore = PageRankedFeed(ranked, cursor, 2)
+	assert.True(t, hasMore)
+	assert.Equal(t, []RankedPost{ranked[2], ranked[3]}, page)
+
+	page, hasMore = PageRankedFeed(ranked, &schema.FeedCursor{Score: ranked[3].Score, PostID: ranked[3].Post.PostID}, 2)
+	assert.False(t, hasMore)
+	assert.Equal(t, []RankedPost{ranked[4]}, page)
+
+	_, err = DecodeFeedCursor("not a cursor")
+	assert.Error(t, err)
+}
@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+	"net/http"
+	"os"
+	"post/schema"
+	"strconv"
+	"time"
+
+	// decoders for the accepted upload formats
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"golang.org/x/image/draw"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidImage  = errors.New("not a jpeg, png, gif or webp image")
+	ErrImageTooLarge = errors.New("image is too large")
+	ErrTooManyImages = errors.New("post already has the maximum number of images")
+)
+
+// uploads are sniffed rather than trusting the client's content type
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ImageConfig limits post image uploads and sets the stored sizes
+type ImageConfig struct {
+	MaxPerPost    int
+	MaxBytes      int64
+	MaxPixels     int // width times height, guards against decompression bombs
+	DisplaySize   int // longest side of the stored image
+	ThumbnailSize int // longest side of the thumbnail
+}
+
+// ImageConfigFromEnv reads POST_MAX_IMAGES and POST_MAX_IMAGE_BYTES
+func ImageConfigFromEnv() ImageConfig {
+	config := ImageConfig{
+		MaxPerPost:    5,
+		MaxBytes:      5 << 20,
+		MaxPixels:     40_000_000,
+		DisplaySize:   1600,
+		ThumbnailSize: 320,
+	}
+	if n, err := strconv.Atoi(os.Getenv("POST_MAX_IMAGES")); err == nil && n > 0 {
+		config.MaxPerPost = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("POST_MAX_IMAGE_BYTES"), 10, 64); err == nil && n > 0 {
+		config.MaxBytes = n
+	}
+	return config
+}
+
+// ProcessedImage is an upload re-encoded as a display-size JPEG and a JPEG thumbnail
+type ProcessedImage struct {
+	Display   []byte
+	Thumbnail []byte
+	Width     int // of the display image
+	Height    int
+}
+
+// ProcessImage validates an upload and re-encodes it. Re-encoding also drops metadata
+// such as the GPS position cameras write into EXIF.
+func ProcessImage(data []byte, config ImageConfig) (ProcessedImage, error) {
+	if int64(len(data)) > config.MaxBytes {
+		return ProcessedImage{}, ErrImageTooLarge
+	}
+	if !allowedImageTypes[http.DetectContentType(data)] {
+		return ProcessedImage{}, ErrInvalidImage
+	}
+
+	// check the dimensions before decoding the pixels
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, ErrInvalidImage
+	}
+	if imageConfig.Width*imageConfig.Height > config.MaxPixels {
+		return ProcessedImage{}, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, ErrInvalidImage
+	}
+
+	display := fitImage(src, config.DisplaySize)
+	processed := ProcessedImage{
+		Width:  display.Bounds().Dx(),
+		Height: display.Bounds().Dy(),
+	}
+	if processed.Display, err = encodeJPEG(display); err != nil {
+		return ProcessedImage{}, err
+	}
+	if processed.Thumbnail, err = encodeJPEG(fitImage(display, config.ThumbnailSize)); err != nil {
+		return ProcessedImage{}, err
+	}
+
+	return processed, nil
+}
+
+// fitImage scales src down so its longest side is at most size, flattening transparency onto white
+func fitImage(src image.Image, size int) image.Image {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if longest := max(width, height); longest > size {
+		width = max(1, width*size/longest)
+		height = max(1, height*size/longest)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+	return dst
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// AddPostImage stores a processed upload and attaches it to the post, up to ImageConfig.MaxPerPost images
+func (pu *PostUtils) AddPostImage(ctx context.Context, postID uint, processed ProcessedImage) (schema.PostImage, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return schema.PostImage{}, err
+	}
+	base := fmt.Sprintf("posts/%d/%s", postID, hex.EncodeToString(name))
+
+	postImage := schema.PostImage{
+		PostID:       postID,
+		Key:          base + ".jpg",
+		ThumbnailKey: base + "_thumb.jpg",
+		Width:        processed.Width,
+		Height:       processed.Height,
+		DateCreated:  time.Now(),
+	}
+
+	// files first, the row only exists once both are stored
+	if err := pu.Storage.Put(ctx, postImage.Key, processed.Display, "image/jpeg"); err != nil {
+		return schema.PostImage{}, err
+	}
+	if err := pu.Storage.Put(ctx, postImage.ThumbnailKey, processed.Thumbnail, "image/jpeg"); err != nil {
+		pu.deleteImageFiles(ctx, postImage)
+		return schema.PostImage{}, err
+	}
+
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		// lock the post so concurrent uploads can't go over the limit
+		var post schema.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&schema.PostImage{}).Where("post_id = ?", postID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(pu.Images.MaxPerPost) {
+			return ErrTooManyImages
+		}
+
+		var position struct{ Next int }
+		err := tx.Model(&schema.PostImage{}).Select("COALESCE(MAX(position), 0) + 1 AS next").
+			Where("post_id = ?", postID).Scan(&position).Error
+		if err != nil {
+			return err
+		}
+		postImage.Position = position.Next
+
+		return tx.Create(&postImage).Error
+	})
+	if err != nil {
+		pu.deleteImageFiles(ctx, postImage)
+		return schema.PostImage{}, err
+	}
+
+	return postImage, nil
+}
+
+// CountPostImages returns how many images a post has
+func (pu *PostUtils) CountPostImages(postID uint) (int64, error) {
+	var count int64
+	err := pu.DB.Model(&schema.PostImage{}).Where("post_id = ?", postID).Count(&count).Error
+	return count, err
+}
+
+// GetPostImages retrieves the images of several posts at once, keyed by post ID
+func (pu *PostUtils) GetPostImages(postIDs []uint) (map[uint][]schema.PostImage, error) {
+	images := make(map[uint][]schema.PostImage, len(postIDs))
+	if len(postIDs) == 0 {
+		return images, nil
+	}
+
+	var rows []schema.PostImage
+	if err := pu.DB.Where("post_id IN ?", postIDs).Order("post_id, position").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		images[row.PostID] = append(images[row.PostID], row)
+	}
+	return images, nil
+}
+
+// DeletePostImage removes one image from a post along with its files
+func (pu *PostUtils) DeletePostImage(ctx context.Context, postID, imageID uint) error {
+	var postImage schema.PostImage
+	if err := pu.DB.Where("post_id = ?", postID).First(&postImage, imageID).Error; err != nil {
+		return err
+	}
+
+	if err := pu.DB.Delete(&postImage).Error; err != nil {
+		return err
+	}
+
+	pu.deleteImageFiles(ctx, postImage)
+	return nil
+}
+
+// ImageURL is where clients fetch a stored image from
+func (pu *PostUtils) ImageURL(key string) string {
+	return pu.Storage.URL(key)
+}
+
+// deleteImageFiles removes an image's files, a failure only leaves an orphaned file behind so it's logged
+func (pu *PostUtils) deleteImageFiles(ctx context.Context, postImage schema.PostImage) {
+	for _, key := range []string{postImage.Key, postImage.ThumbnailKey} {
+		if err := pu.Storage.Delete(ctx, key); err != nil {
+			log.Printf("Error deleting image file %s: %v", key, err)
+		}
+	}
+}
+
+// ImageLimits returns the upload limits
+func (pu *PostUtils) ImageLimits() ImageConfig {
+	return pu.Images
+}
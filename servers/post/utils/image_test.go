@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, height/2, color.NRGBA{R: 200, A: 255})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestProcessImage(t *testing.T) {
+	config := ImageConfig{MaxPerPost: 5, MaxBytes: 1 << 20, MaxPixels: 4_000_000, DisplaySize: 400, ThumbnailSize: 100}
+
+	processed, err := ProcessImage(encodeTestPNG(t, 800, 200), config)
+	require.NoError(t, err)
+	assert.Equal(t, 400, processed.Width)
+	assert.Equal(t, 100, processed.Height)
+
+	thumbnail, format, err := image.DecodeConfig(bytes.NewReader(processed.Thumbnail))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 100, thumbnail.Width)
+	assert.Equal(t, 25, thumbnail.Height)
+
+	// small images keep their size
+	processed, err = ProcessImage(encodeTestPNG(t, 50, 30), config)
+	require.NoError(t, err)
+	assert.Equal(t, 50, processed.Width)
+	assert.Equal(t, 30, processed.Height)
+
+	_, err = ProcessImage([]byte("<html>definitely a photo</html>"), config)
+	assert.ErrorIs(t, err, ErrInvalidImage)
+
+	_, err = ProcessImage(encodeTestPNG(t, 3000, 2000), config)
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+
+	config.MaxBytes = 10
+	_, err = ProcessImage(encodeTestPNG(t, 50, 30), config)
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
+
+func TestLocalImageStorage(t *testing.T) {
+	dir := t.TempDir()
+	storage := &LocalImageStorage{Dir: dir, BaseURL: "/v1/post/media/"}
+	ctx := context.Background()
+
+	require.NoError(t, storage.Put(ctx, "posts/7/abc.jpg", []byte("jpeg"), "image/jpeg"))
+	data, err := os.ReadFile(filepath.Join(dir, "posts", "7", "abc.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", string(data))
+	assert.Equal(t, "/v1/post/media/posts/7/abc.jpg", storage.URL("posts/7/abc.jpg"))
+
+	// keys can't climb out of the storage directory
+	require.NoError(t, storage.Put(ctx, "../../escape.jpg", []byte("jpeg"), "image/jpeg"))
+	_, err = os.Stat(filepath.Join(dir, "escape.jpg"))
+	assert.NoError(t, err)
+
+	require.NoError(t, storage.Delete(ctx, "posts/7/abc.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "posts", "7", "abc.jpg"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// deleting a missing file isn't an error
+	assert.NoError(t, storage.Delete(ctx, "posts/7/abc.jpg"))
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"post/schema"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidLocation = errors.New("location must be a valid latitude and longitude or a known campus building")
+
+const earthRadiusKm = 6371.0
+
+// defaultCampusBuildings are used unless POST_CAMPUS_BUILDINGS points at a JSON list of buildings
+var defaultCampusBuildings = []schema.CampusBuilding{
+	{Slug: "pmu", Name: "Purdue Memorial Union", Latitude: 40.4247, Longitude: -86.9109},
+	{Slug: "walc", Name: "Wilmeth Active Learning Center", Latitude: 40.4274, Longitude: -86.9132},
+	{Slug: "hicks", Name: "Hicks Undergraduate Library", Latitude: 40.4246, Longitude: -86.9125},
+	{Slug: "lwsn", Name: "Lawson Computer Science Building", Latitude: 40.4277, Longitude: -86.9170},
+	{Slug: "arms", Name: "Armstrong Hall of Engineering", Latitude: 40.4311, Longitude: -86.9149},
+	{Slug: "corec", Name: "Cordova Recreational Sports Center", Latitude: 40.4286, Longitude: -86.9223},
+	{Slug: "earhart", Name: "Earhart Residence Hall", Latitude: 40.4256, Longitude: -86.9252},
+	{Slug: "windsor", Name: "Windsor Residence Halls", Latitude: 40.4267, Longitude: -86.9206},
+}
+
+// LocationConfig controls post locations and distance queries
+type LocationConfig struct {
+	Precision       int // decimal places coordinates are rounded to, 3 is about 100m
+	DefaultRadiusKm float64
+	MaxRadiusKm     float64
+	Buildings       []schema.CampusBuilding
+	PostGIS         bool // compute distances with PostGIS instead of haversine
+}
+
+// LocationConfigFromEnv reads POST_LOCATION_PRECISION and the building list in POST_CAMPUS_BUILDINGS
+func LocationConfigFromEnv() LocationConfig {
+	config := LocationConfig{
+		Precision:       3,
+		DefaultRadiusKm: 2,
+		MaxRadiusKm:     50,
+		Buildings:       defaultCampusBuildings,
+	}
+
+	if precision, err := strconv.Atoi(os.Getenv("POST_LOCATION_PRECISION")); err == nil && precision >= 0 && precision <= 6 {
+		config.Precision = precision
+	}
+
+	if path := os.Getenv("POST_CAMPUS_BUILDINGS"); path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &config.Buildings)
+		}
+		if err != nil {
+			log.Fatalf("Error loading campus buildings from %s: %v", path, err)
+		}
+	}
+
+	return config
+}
+
+// postGISAvailable reports whether the PostGIS extension is installed in the post database
+func postGISAvailable(DB *gorm.DB) bool {
+	var count int64
+	if err := DB.Table("pg_extension").Where("extname = ?", "postgis").Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// RoundCoordinate rounds to the given number of decimal places
+func RoundCoordinate(value float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Round(value*scale) / scale
+}
+
+// LocationLimits returns the radius limits for distance queries
+func (pu *PostUtils) LocationLimits() LocationConfig {
+	return pu.Location
+}
+
+// CampusBuildings lists the buildings a post can be picked up at
+func (pu *PostUtils) CampusBuildings() []schema.CampusBuilding {
+	return pu.Location.Buildings
+}
+
+// CampusBuilding looks a building up by its slug
+func (pu *PostUtils) CampusBuilding(slug string) (schema.CampusBuilding, bool) {
+	for _, building := range pu.Location.Buildings {
+		if building.Slug == slug {
+			return building, true
+		}
+	}
+	return schema.CampusBuilding{}, false
+}
+
+// ApplyLocation validates the requested pickup location and sets it on the post.
+// Buildings are public places and keep their coordinates, anything else is rounded.
+func (pu *PostUtils) ApplyLocation(post *schema.Post, req schema.PostRequest) error {
+	post.Latitude, post.Longitude, post.Building = nil, nil, ""
+
+	switch {
+	case req.Building != "":
+		building, ok := pu.CampusBuilding(req.Building)
+		if !ok || req.Latitude != nil || req.Longitude != nil {
+			return ErrInvalidLocation
+		}
+		post.Latitude, post.Longitude = &building.Latitude, &building.Longitude
+		post.Building = building.Slug
+	case req.Latitude != nil || req.Longitude != nil:
+		if req.Latitude == nil || req.Longitude == nil || !ValidGeoPoint(*req.Latitude, *req.Longitude) {
+			return ErrInvalidLocation
+		}
+		latitude := RoundCoordinate(*req.Latitude, pu.Location.Precision)
+		longitude := RoundCoordinate(*req.Longitude, pu.Location.Precision)
+		post.Latitude, post.Longitude = &latitude, &longitude
+	}
+
+	return nil
+}
+
+// ValidGeoPoint reports whether the coordinates are on the globe
+func ValidGeoPoint(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180 &&
+		!math.IsNaN(latitude) && !math.IsNaN(longitude)
+}
+
+// HaversineKm is the great circle distance between two points
+func HaversineKm(a, b schema.GeoPoint) float64 {
+	dLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(a.Latitude*math.Pi/180)*math.Cos(b.Latitude*math.Pi/180)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+// DistanceSQL is an SQL expression for a post's distance in km from point
+func (pu *PostUtils) DistanceSQL(point schema.GeoPoint) string {
+	return pu.Location.DistanceSQL(point)
+}
+
+// DistanceSQL is an SQL expression for a post's distance in km from point. The point's
+// coordinates are numbers formatted by us, so they're safe to inline.
+func (config LocationConfig) DistanceSQL(point schema.GeoPoint) string {
+	lat := strconv.FormatFloat(point.Latitude, 'f', -1, 64)
+	lon := strconv.FormatFloat(point.Longitude, 'f', -1, 64)
+
+	if config.PostGIS {
+		return fmt.Sprintf("(ST_DistanceSphere(ST_MakePoint(longitude, latitude), ST_MakePoint(%s, %s)) / 1000)", lon, lat)
+	}
+	return fmt.Sprintf("(%g * 2 * asin(sqrt(least(1, power(sin(radians(latitude - %s) / 2), 2) + "+
+		"cos(radians(%s)) * cos(radians(latitude)) * power(sin(radians(longitude - %s) / 2), 2)))))",
+		earthRadiusKm, lat, lat, lon)
+}
+
+// withinRadius keeps the posts within radiusKm of point.
+// The bounding box lets the location index discard far away posts before the distance is computed.
+func (config LocationConfig) withinRadius(query *gorm.DB, point schema.GeoPoint, radiusKm float64) *gorm.DB {
+	distance := config.DistanceSQL(point)
+
+	latDelta := radiusKm / 111.045
+	lonDelta := 180.0
+	if cos := math.Cos(point.Latitude * math.Pi / 180); cos > 0.01 {
+		lonDelta = math.Min(180, radiusKm/(111.045*cos))
+	}
+
+	return query.
+		Where("latitude BETWEEN ? AND ?", point.Latitude-latDelta, point.Latitude+latDelta).
+		Where("longitude BETWEEN ? AND ?", point.Longitude-lonDelta, point.Longitude+lonDelta).
+		Where(distance+" <= ?", radiusKm)
+}
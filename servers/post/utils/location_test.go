@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"pagination"
+	"post/schema"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHaversineKm(t *testing.T) {
+	// one degree of longitude on the equator
+	assert.InDelta(t, 111.195, HaversineKm(schema.GeoPoint{}, schema.GeoPoint{Longitude: 1}), 0.001)
+
+	pmu := schema.GeoPoint{Latitude: 40.4247, Longitude: -86.9109}
+	lwsn := schema.GeoPoint{Latitude: 40.4277, Longitude: -86.9170}
+	assert.InDelta(t, 0.610, HaversineKm(pmu, lwsn), 0.005)
+	assert.Equal(t, HaversineKm(pmu, lwsn), HaversineKm(lwsn, pmu))
+	assert.Zero(t, HaversineKm(pmu, pmu))
+}
+
+func TestApplyLocation(t *testing.T) {
+	postUtils := &PostUtils{Location: LocationConfig{Precision: 3, Buildings: defaultCampusBuildings}}
+	lat, lon := 40.42471234, -86.91095678
+	outside := 91.0
+
+	tests := []struct {
+		name     string
+		req      schema.PostRequest
+		wantErr  bool
+		wantLat  float64
+		wantLon  float64
+		building string
+	}{
+		{name: "no location"},
+		{name: "coordinates are rounded", req: schema.PostRequest{Latitude: &lat, Longitude: &lon}, wantLat: 40.425, wantLon: -86.911},
+		{name: "building keeps its coordinates", req: schema.PostRequest{Building: "lwsn"}, wantLat: 40.4277, wantLon: -86.9170, building: "lwsn"},
+		{name: "unknown building", req: schema.PostRequest{Building: "nowhere"}, wantErr: true},
+		{name: "building and coordinates", req: schema.PostRequest{Building: "pmu", Latitude: &lat, Longitude: &lon}, wantErr: true},
+		{name: "latitude only", req: schema.PostRequest{Latitude: &lat}, wantErr: true},
+		{name: "out of range", req: schema.PostRequest{Latitude: &outside, Longitude: &lon}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := schema.Post{Building: "hicks"}
+			err := postUtils.ApplyLocation(&post, tt.req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidLocation)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.wantLat == 0 {
+				assert.Nil(t, post.Latitude)
+				assert.Nil(t, post.Longitude)
+			} else {
+				assert.Equal(t, tt.wantLat, *post.Latitude)
+				assert.Equal(t, tt.wantLon, *post.Longitude)
+			}
+			assert.Equal(t, tt.building, post.Building)
+		})
+	}
+}
+
+func TestGetRecentPostsNear(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db, Location: LocationConfig{Precision: 3}}
+
+	// bounding box first, then the exact distance, which is also selected for the response
+	mock.ExpectQuery(`SELECT posts\.\*, \(6371 \* 2 \* asin\(.+\)\) AS distance FROM "posts" `+
+		`WHERE \(date_posted >= \$1 AND status = \$2\) AND \(latitude BETWEEN \$3 AND \$4\) AND \(longitude BETWEEN \$5 AND \$6\) `+
+		`AND \(6371 \* 2 \* asin\(.+\)\) <= \$7 ORDER BY date_posted DESC,post_id DESC LIMIT \$8`).
+		WithArgs(sqlmock.AnyArg(), schema.Active, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 2.0, 26).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "title", "distance"}).AddRow(4, "Desk lamp", 0.61))
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/post", nil)
+	page, err := pagination.Parse(c, pagination.Options{
+		DefaultLimit: 25,
+		MaxLimit:     100,
+		Sorts:        map[string]pagination.Field{"date_posted": {Column: "date_posted", Kind: pagination.TimeField}},
+		DefaultSort:  "-date_posted",
+		IDColumn:     "post_id",
+	})
+	require.NoError(t, err)
+
+	posts, err := postUtils.GetRecentPosts(7, schema.PostFilter{
+		Near:     &schema.GeoPoint{Latitude: 40.4247, Longitude: -86.9109},
+		RadiusKm: 2,
+	}, page)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	require.NotNil(t, posts[0].Distance)
+	assert.Equal(t, 0.61, *posts[0].Distance)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
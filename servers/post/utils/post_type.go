@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"errors"
+	"post/schema"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPostType = errors.New("invalid post type or type-specific fields")
+
+// ValidPostType reports whether t is one of the post types
+func ValidPostType(t schema.PostType) bool {
+	return t == schema.RequestPost || t == schema.OfferPost
+}
+
+// NormalizePostType fills in the type defaults and checks the type-specific fields:
+// offers carry a quantity of at least one and no needed-by date, requests carry no quantity
+// and an optional needed-by date in the future.
+func NormalizePostType(req *schema.PostRequest) error {
+	if req.Type == "" {
+		req.Type = schema.RequestPost
+	}
+
+	switch req.Type {
+	case schema.OfferPost:
+		if req.Quantity == 0 {
+			req.Quantity = 1
+		}
+		if req.Quantity < 1 || req.NeededBy != nil {
+			return ErrInvalidPostType
+		}
+	case schema.RequestPost:
+		if req.Quantity != 0 || (req.NeededBy != nil && !req.NeededBy.After(time.Now())) {
+			return ErrInvalidPostType
+		}
+	default:
+		return ErrInvalidPostType
+	}
+
+	return nil
+}
+
+// filterPosts narrows a post query to the filter
+func (pu *PostUtils) filterPosts(query *gorm.DB, filter schema.PostFilter) *gorm.DB {
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Near != nil {
+		query = pu.Location.withinRadius(query, *filter.Near, filter.RadiusKm).
+			Select("posts.*, " + pu.DistanceSQL(*filter.Near) + " AS distance")
+	}
+	return query
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePostType(t *testing.T) {
+	future := time.Now().Add(48 * time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	req := schema.PostRequest{}
+	assert.NoError(t, NormalizePostType(&req))
+	assert.Equal(t, schema.RequestPost, req.Type)
+
+	req = schema.PostRequest{Type: schema.OfferPost}
+	assert.NoError(t, NormalizePostType(&req))
+	assert.Equal(t, 1, req.Quantity)
+
+	req = schema.PostRequest{Type: schema.RequestPost, NeededBy: &future}
+	assert.NoError(t, NormalizePostType(&req))
+
+	for name, req := range map[string]schema.PostRequest{
+		"unknown type":           {Type: "trade"},
+		"offer with needed_by":   {Type: schema.OfferPost, NeededBy: &future},
+		"offer with no items":    {Type: schema.OfferPost, Quantity: -1},
+		"request with quantity":  {Type: schema.RequestPost, Quantity: 2},
+		"request needed in past": {Type: schema.RequestPost, NeededBy: &past},
+	} {
+		assert.ErrorIs(t, NormalizePostType(&req), ErrInvalidPostType, name)
+	}
+}
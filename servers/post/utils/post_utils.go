@@ -1,31 +1,137 @@
 package utils
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"pagination"
 	"post/db"
 	"post/middleware"
 	"post/schema"
+	"screening"
 	"time"
 
 	"github.com/GiveGetGo/shared/types"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type IPostUtils interface {
 	GetPostByID(postID uint) (schema.Post, error)
-	GetPostByUserID(userid uint) ([]schema.Post, error)
+	GetPostByUserID(userid uint, filter schema.PostFilter, page pagination.Page) ([]schema.Post, error)
 	AddPost(post schema.Post) (schema.Post, error)
-	GetRecentPosts(days int, count int) ([]schema.Post, error)
-	GetArchivePosts(days int, count int) ([]schema.Post, error)
-	UpdatePost(postID uint, updateReq types.PostRequest) error
-	UpdatePostStatus(postID uint, status schema.PostStatus) error
+	GetRecentPosts(days int, filter schema.PostFilter, page pagination.Page) ([]schema.Post, error)
+	GetArchivePosts(days int, filter schema.PostFilter, page pagination.Page) ([]schema.Post, error)
+	UpdatePost(postID uint, editorUserID uint, updateReq schema.PostRequest) (schema.Post, schema.PostRevision, error)
+	GetPostRevisions(postID uint, page pagination.Page) ([]schema.PostRevision, error)
+	GetOpenBidders(postID uint) ([]uint, error)
+	TransitionPostStatus(postID uint, to schema.PostStatus, actor schema.StatusActor, actorUserID uint, reason string) (schema.Post, error)
+	GetPostStatusHistory(postID uint, page pagination.Page) ([]schema.PostStatusHistory, error)
 	DeletePost(postID uint) error
-	GetUserInfo(c *gin.Context) (types.UserInfoResponse, error)
+	SearchPosts(query schema.PostSearchQuery) ([]schema.PostSearchHit, error)
+	GetUserInfo(c *gin.Context) (schema.UserInfoResponse, error)
+
+	// Categories
+	GetCategories(includeInactive bool) ([]schema.Category, error)
+	GetCategoryByID(categoryID uint) (schema.Category, error)
+	GetCategoryBySlug(slug string) (schema.Category, error)
+	ResolveCategory(value string) (schema.Category, error)
+	AddCategory(category schema.Category) (schema.Category, error)
+	UpdateCategory(category schema.Category) error
+	DeleteCategory(categoryID uint) error
+	CountActivePostsByCategory() (map[string]int64, error)
+
+	// Expiry
+	PostLifetime(category schema.Category) (time.Duration, error)
+	ResolveExpiry(category schema.Category, requested *time.Time) (time.Time, error)
+	RenewPost(postID uint, ownerID uint, expiresAt time.Time) error
+	ExpireOverduePosts() ([]schema.Post, error)
+	ClaimExpiringPosts() ([]schema.Post, error)
+	ValidatePublishAt(publishAt time.Time) error
+	PublishPost(postID uint, ownerID uint) (schema.Post, error)
+	SchedulePost(postID uint, publishAt *time.Time) error
+	PublishScheduledPosts() ([]schema.Post, error)
+
+	// Recurrence
+	StartSeries(postID uint, ownerID uint, frequency schema.RecurrenceFrequency, endsAt time.Time) (schema.PostSeries, error)
+	GetSeries(userID uint) ([]schema.PostSeries, error)
+	GetSeriesByID(seriesID uint) (schema.PostSeries, error)
+	SetSeriesStatus(seriesID uint, status schema.SeriesStatus) (schema.PostSeries, error)
+	PostSeriesOccurrences() ([]SeriesOccurrence, error)
+	CreateNotification(userID uint, notificationType types.NotificationType, description string) error
+
+	// Images
+	AddPostImage(ctx context.Context, postID uint, processed ProcessedImage) (schema.PostImage, error)
+	CountPostImages(postID uint) (int64, error)
+	GetPostImages(postIDs []uint) (map[uint][]schema.PostImage, error)
+	DeletePostImage(ctx context.Context, postID, imageID uint) error
+	ImageURL(key string) string
+	ImageLimits() ImageConfig
+
+	// Reports
+	AddReport(report schema.Report) (schema.Report, bool, error)
+	GetReportByID(reportID uint) (schema.Report, error)
+	GetReports(filter schema.ReportFilter, page pagination.Page) ([]schema.Report, error)
+	ResolveReports(reportID uint, moderatorUserID uint, action schema.ModerationAction, note string) ([]schema.Report, error)
+	ModeratePost(postID uint, moderatorUserID uint, action schema.ModerationAction, note string) error
+	RemoveBid(bidID uint) error
+	SuspendUser(userID uint, reason string) error
+
+	// Bookmarks
+	AddBookmark(userID uint, postID uint) error
+	RemoveBookmark(userID uint, postID uint) error
+	GetBookmarkedPosts(userID uint, page pagination.Page) ([]schema.Post, error)
+	CountBookmarks(postIDs []uint) (map[uint]int64, error)
+	GetBookmarkerIDs(postID uint) ([]uint, error)
+
+	// Views and analytics
+	RecordView(ctx context.Context, postID uint, viewerID uint, at time.Time) error
+	FlushViews(ctx context.Context) (int, error)
+	GetViewStats(postID uint, since time.Time) (ViewStats, error)
+	GetDailyBookmarks(postID uint, since time.Time) (map[string]int64, error)
+	GetBidDates(postID uint) ([]time.Time, error)
+
+	// Feed
+	GetFeedCandidates(days int, filter schema.PostFilter) ([]schema.Post, error)
+	CountPostCategories(postIDs []uint) (map[string]int, error)
+	GetUserProfiles(userIDs []uint) (map[uint]schema.UserProfile, error)
+	GetBidCounts(postIDs []uint) (map[uint]int64, error)
+	GetBidPostIDs(userID uint) ([]uint, error)
+	FeedRanker() FeedRanker
+
+	// Syndication
+	CreateFeedToken(userID uint) (string, error)
+	RevokeFeedToken(userID uint) error
+	GetFeedTokenUserID(token string) (uint, error)
+	GetPostsByIDs(postIDs []uint) (map[uint]schema.Post, error)
+	GetScheduledMatches(userID uint) ([]schema.ScheduledMatch, error)
+	SyndicationLinks() SyndicationConfig
+
+	// Saved searches
+	AddSavedSearch(search schema.SavedSearch) (schema.SavedSearch, error)
+	GetSavedSearches(userID uint) ([]schema.SavedSearch, error)
+	GetSavedSearchByID(savedSearchID uint) (schema.SavedSearch, error)
+	UpdateSavedSearch(search schema.SavedSearch) error
+	DeleteSavedSearch(savedSearchID uint) error
+	FindMatchingSavedSearches(post schema.Post) ([]schema.SavedSearch, error)
+	QueueSavedSearchMatches(savedSearchIDs []uint, postID uint) error
+	ClaimSavedSearchDigests() ([]SavedSearchDigest, error)
+
+	// Screening
+	ScreenText(text string, limit screening.Limit) (screening.Result, error)
+	FlagContent(targetType schema.ReportTarget, targetID uint, matches []screening.MatchType) error
+
+	// Location
+	CampusBuildings() []schema.CampusBuilding
+	CampusBuilding(slug string) (schema.CampusBuilding, bool)
+	ApplyLocation(post *schema.Post, req schema.PostRequest) error
+	DistanceSQL(point schema.GeoPoint) string
+	LocationLimits() LocationConfig
 }
 
 // Ensure PostUtils implements IPostUtils
@@ -34,13 +140,48 @@ var _ IPostUtils = (*PostUtils)(nil)
 type PostUtils struct {
 	DB          db.Database
 	RedisClient middleware.RedisClientInterface
+	SearchIndex PostSearchIndex
+	Expiry      ExpiryConfig
+	Publish     PublishConfig
+	Recurrence  RecurrenceConfig
+	Views       ViewConfig
+	Storage     ImageStorage
+	Images      ImageConfig
+	Location    LocationConfig
+	Reports     ReportConfig
+	Screener    *screening.Screener
+	Searches    SavedSearchConfig
+	Feed        FeedConfig
+	Ranker      FeedRanker
+	Syndication SyndicationConfig
 }
 
 // NewPostUtils creates a new PostUtils
 func NewPostUtils(DB db.Database, redisClient middleware.RedisClientInterface) *PostUtils {
+	location := LocationConfigFromEnv()
+	if gormDB, ok := DB.(*gorm.DB); ok {
+		location.PostGIS = postGISAvailable(gormDB)
+	}
+
+	feed := FeedConfigFromEnv()
+
 	return &PostUtils{
 		DB:          DB,
 		RedisClient: redisClient,
+		SearchIndex: NewPostSearchIndexFromEnv(),
+		Expiry:      ExpiryConfigFromEnv(),
+		Publish:     PublishConfigFromEnv(),
+		Recurrence:  RecurrenceConfigFromEnv(),
+		Views:       ViewConfigFromEnv(),
+		Storage:     NewImageStorageFromEnv(),
+		Images:      ImageConfigFromEnv(),
+		Location:    location,
+		Reports:     ReportConfigFromEnv(),
+		Screener:    screening.NewFromEnv(),
+		Searches:    SavedSearchConfigFromEnv(),
+		Feed:        feed,
+		Ranker:      NewFeedRanker(feed),
+		Syndication: SyndicationConfigFromEnv(),
 	}
 }
 
@@ -55,17 +196,22 @@ func (pu *PostUtils) GetPostByID(postID uint) (schema.Post, error) {
 	return post, nil
 }
 
-func (pu *PostUtils) GetPostByUserID(userid uint) ([]schema.Post, error) {
+func (pu *PostUtils) GetPostByUserID(userid uint, filter schema.PostFilter, page pagination.Page) ([]schema.Post, error) {
 	var posts []schema.Post
-	if err := pu.DB.Where("user_id = ?", userid).Find(&posts).Error; err != nil {
+	if err := page.Apply(pu.filterPosts(pu.DB.Where("user_id = ?", userid), filter)).Find(&posts).Error; err != nil {
 		return nil, err
 	}
 	return posts, nil
 }
 
-// func AddPost adds a post to the database
+// func AddPost adds a post to the database and starts its status history
 func (pu *PostUtils) AddPost(post schema.Post) (schema.Post, error) {
-	err := pu.DB.Create(&post).Error
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		return recordStatusChange(tx, post.PostID, "", post.Status, schema.OwnerActor, post.UserID, "created")
+	})
 	if err != nil {
 		return schema.Post{}, err
 	}
@@ -73,45 +219,29 @@ func (pu *PostUtils) AddPost(post schema.Post) (schema.Post, error) {
 	return post, nil
 }
 
-func (pu *PostUtils) GetRecentPosts(days int, count int) ([]schema.Post, error) {
-	if count == 0 {
-		count = 25 // Default value if none is provided and not overridden
-	}
-
+// GetRecentPosts retrieves a page of the active posts from the last days
+func (pu *PostUtils) GetRecentPosts(days int, filter schema.PostFilter, page pagination.Page) ([]schema.Post, error) {
 	var posts []schema.Post
 	since := time.Now().AddDate(0, 0, -days)
-	log.Printf("Retrieving posts from the last %d days with a limit of %d posts.", days, count)
-	log.Printf("Querying posts since: %v", since)
-
-	result := pu.DB.Where("date_posted >= ? AND status = ?", since, schema.Active).
-		Order("date_posted desc").
-		Limit(count).
-		Find(&posts)
 
+	query := pu.filterPosts(pu.DB.Where("date_posted >= ? AND status = ?", since, schema.Active), filter)
+	result := page.Apply(query).Find(&posts)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 
-	if len(posts) == 0 {
-		log.Println("No posts found with the given criteria")
-	} else {
-		log.Printf("Found %d posts", len(posts))
-	}
-
 	return posts, nil
 }
 
-func (pu *PostUtils) GetArchivePosts(days int, count int) ([]schema.Post, error) {
+// GetArchivePosts retrieves a page of the inactive posts older than days
+func (pu *PostUtils) GetArchivePosts(days int, filter schema.PostFilter, page pagination.Page) ([]schema.Post, error) {
 	var posts []schema.Post
 	// Calculate the date limit to fetch posts that are older than 'days' days
 	since := time.Now().AddDate(0, 0, -days)
 
-	// Adjust the query to exclude posts with 'Active' status
-	result := pu.DB.Where("date_posted <= ? AND status != ?", since, schema.Active).
-		Order("date_posted desc").
-		Limit(count).
-		Find(&posts)
-
+	// Adjust the query to exclude active posts, hidden ones which only moderators see and unpublished drafts
+	query := pu.filterPosts(pu.DB.Where("date_posted <= ? AND status NOT IN ?", since, []schema.PostStatus{schema.Active, schema.Hidden, schema.Draft}), filter)
+	result := page.Apply(query).Find(&posts)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -119,23 +249,6 @@ func (pu *PostUtils) GetArchivePosts(days int, count int) ([]schema.Post, error)
 	return posts, nil
 }
 
-func (pu *PostUtils) UpdatePost(postID uint, update types.PostRequest) error {
-	// Fetch the existing post from the database
-	var post schema.Post
-	if err := pu.DB.First(&post, postID).Error; err != nil {
-		return err
-	}
-
-	// Update fields
-	post.PostID = postID
-	post.Title = update.Title
-	post.Description = update.Description
-	post.Category = update.Category
-	post.DateUpdated = time.Now()
-
-	return pu.DB.Save(&post).Error
-}
-
 // DeletePost deletes a post from the database by its ID.
 func (pu *PostUtils) DeletePost(postID uint) error {
 	// Attempt to first fetch the post to ensure it exists.
@@ -145,70 +258,138 @@ func (pu *PostUtils) DeletePost(postID uint) error {
 		return result.Error
 	}
 
-	// If the post exists, proceed to delete it.
-	if err := pu.DB.Delete(&post).Error; err != nil {
+	var images []schema.PostImage
+	if err := pu.DB.Where("post_id = ?", postID).Find(&images).Error; err != nil {
 		return err
 	}
 
+	// If the post exists, proceed to delete it, its images, bookmarks and views, and stop its series.
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", postID).Delete(&schema.PostImage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&schema.Bookmark{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&schema.PostView{}).Error; err != nil {
+			return err
+		}
+		// a series can't repost a post that's gone
+		err := tx.Model(&schema.PostSeries{}).
+			Where("latest_post_id = ? AND status IN ?", postID, []schema.SeriesStatus{schema.SeriesActive, schema.SeriesPaused}).
+			Updates(map[string]interface{}{"status": schema.SeriesCancelled, "date_updated": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&post).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		pu.deleteImageFiles(context.Background(), image)
+	}
+
 	return nil
 }
 
-func (pu *PostUtils) GetUserInfo(c *gin.Context) (types.UserInfoResponse, error) {
+func (pu *PostUtils) GetUserInfo(c *gin.Context) (schema.UserInfoResponse, error) {
 	userServiceURL := os.Getenv("USER_SERVICE_URL") + "/v1/user/me"
 
 	// Extract the session cookie from the incoming request
 	cookie, err := c.Request.Cookie("givegetgo")
 	if err != nil {
-		return types.UserInfoResponse{}, errors.New("session cookie is missing")
+		return schema.UserInfoResponse{}, errors.New("session cookie is missing")
 	}
 
 	// Create a new request to the user service
 	req, err := http.NewRequest("GET", userServiceURL, nil)
 	if err != nil {
-		return types.UserInfoResponse{}, err
+		return schema.UserInfoResponse{}, err
 	}
 	req.Header.Set("Cookie", cookie.String()) // Forward the session cookie
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return types.UserInfoResponse{}, err
+		return schema.UserInfoResponse{}, err
 	}
 	defer resp.Body.Close()
 
 	// Check response status code
 	if resp.StatusCode != http.StatusOK {
-		return types.UserInfoResponse{}, errors.New("failed to verify session or session not found")
+		return schema.UserInfoResponse{}, errors.New("failed to verify session or session not found")
 	}
 
 	// Decode the JSON response into a struct
 	var fullResponse types.FullResponseWithData
 	if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
-		return types.UserInfoResponse{}, err
+		return schema.UserInfoResponse{}, err
 	}
 
 	// Convert the Data field from map to UserInfoResponse
 	dataMap, ok := fullResponse.Data.(map[string]interface{})
 	if !ok {
 		log.Println("Data type assertion to map failed")
-		return types.UserInfoResponse{}, fmt.Errorf("response data is not a map")
+		return schema.UserInfoResponse{}, fmt.Errorf("response data is not a map")
 	}
 
 	jsonData, err := json.Marshal(dataMap)
 	if err != nil {
 		log.Println("Error marshaling data map to JSON:", err)
-		return types.UserInfoResponse{}, err
+		return schema.UserInfoResponse{}, err
 	}
 
-	var userInfo types.UserInfoResponse
+	var userInfo schema.UserInfoResponse
 	if err := json.Unmarshal(jsonData, &userInfo); err != nil {
 		log.Println("Error unmarshaling JSON to UserInfoResponse:", err)
-		return types.UserInfoResponse{}, err
+		return schema.UserInfoResponse{}, err
 	}
 
 	return userInfo, nil
 }
 
-func (pu *PostUtils) UpdatePostStatus(postID uint, status schema.PostStatus) error {
-	return pu.DB.Model(&schema.Post{}).Where("post_id = ?", postID).Update("status", status).Error
+// SearchPosts runs a text search through the configured search index
+func (pu *PostUtils) SearchPosts(query schema.PostSearchQuery) ([]schema.PostSearchHit, error) {
+	return pu.SearchIndex.Search(pu.DB, query, pu.Location)
+}
+
+// CreateNotification sends a notification to a user through the notification service
+func (pu *PostUtils) CreateNotification(userID uint, notificationType types.NotificationType, description string) error {
+	notificationReqBody, err := json.Marshal(types.CreateNotificationRequest{
+		UserID:           userID,
+		Description:      description,
+		NotificationType: notificationType,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Create the HTTP client and request
+	client := &http.Client{Timeout: 10 * time.Second}
+	notificationServiceURL := os.Getenv("NOTIFICATION_SERVICE_URL") + "/v1/internal/notification"
+	req, err := http.NewRequest("POST", notificationServiceURL, bytes.NewBuffer(notificationReqBody))
+	if err != nil {
+		return err
+	}
+
+	// Set the headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service", "POST")
+	req.Header.Set("X-Api-Key", os.Getenv("POST_API_KEY"))
+
+	// Send the request
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Check the response status
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("notification service responded with status: %d", resp.StatusCode)
+	}
+
+	return nil
 }
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"post/schema"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// PostPublishedNotification tells the owner their scheduled draft went live
+	PostPublishedNotification types.NotificationType = "postpublished"
+
+	// drafts published per worker pass, the rest wait for the next one
+	publishWorkerBatchSize = 100
+)
+
+var (
+	ErrInvalidPublishTime = errors.New("publish time must be in the future and within the scheduling window")
+	ErrPostNotDraft       = errors.New("only drafts can be published or scheduled")
+)
+
+// PublishConfig controls scheduled publishing
+type PublishConfig struct {
+	Interval    time.Duration // how often the publisher runs
+	MaxSchedule time.Duration // the furthest ahead a draft may be scheduled
+}
+
+// PublishConfigFromEnv reads POST_PUBLISH_INTERVAL and POST_MAX_SCHEDULE_DAYS
+func PublishConfigFromEnv() PublishConfig {
+	return PublishConfig{
+		Interval:    envDuration("POST_PUBLISH_INTERVAL", time.Minute),
+		MaxSchedule: envDays("POST_MAX_SCHEDULE_DAYS", 30),
+	}
+}
+
+// ValidatePublishAt checks a requested publish time is in the future and within the scheduling window
+func (pu *PostUtils) ValidatePublishAt(publishAt time.Time) error {
+	now := time.Now()
+	if !publishAt.After(now) || publishAt.After(now.Add(pu.Publish.MaxSchedule)) {
+		return ErrInvalidPublishTime
+	}
+	return nil
+}
+
+// PublishPost makes a draft active right away
+func (pu *PostUtils) PublishPost(postID uint, ownerID uint) (schema.Post, error) {
+	var post schema.Post
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+			return err
+		}
+		if post.Status != schema.Draft {
+			return ErrPostNotDraft
+		}
+
+		return publishPost(tx, &post, schema.OwnerActor, ownerID, "published")
+	})
+	if err != nil {
+		return schema.Post{}, err
+	}
+
+	return post, nil
+}
+
+// SchedulePost sets when a draft is published, nil leaves it unscheduled
+func (pu *PostUtils) SchedulePost(postID uint, publishAt *time.Time) error {
+	return pu.DB.Transaction(func(tx *gorm.DB) error {
+		var post schema.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+			return err
+		}
+		if post.Status != schema.Draft {
+			return ErrPostNotDraft
+		}
+
+		return tx.Model(&post).Updates(map[string]interface{}{
+			"publish_at":   publishAt,
+			"date_updated": time.Now(),
+		}).Error
+	})
+}
+
+// PublishScheduledPosts publishes a batch of drafts whose publish time has come. Rows locked by
+// another replica are skipped, so each draft is published by exactly one worker.
+func (pu *PostUtils) PublishScheduledPosts() ([]schema.Post, error) {
+	var posts []schema.Post
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND publish_at <= ?", schema.Draft, time.Now()).
+			Order("publish_at").
+			Limit(publishWorkerBatchSize).
+			Find(&posts).Error
+		if err != nil || len(posts) == 0 {
+			return err
+		}
+
+		for i := range posts {
+			if err := publishPost(tx, &posts[i], schema.SchedulerActor, 0, "scheduled"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// publishPost moves a draft locked in tx to Active. The post keeps the lifetime it was given as a
+// draft, counted from now, so time spent unpublished doesn't eat into it.
+func publishPost(tx *gorm.DB, post *schema.Post, actor schema.StatusActor, actorUserID uint, reason string) error {
+	now := time.Now()
+	lifetime := post.ExpiresAt.Sub(post.DatePosted)
+
+	updates := map[string]interface{}{
+		"date_posted":     now,
+		"expires_at":      now.Add(lifetime),
+		"expiry_notified": false,
+		"publish_at":      nil,
+	}
+	if err := transitionPost(tx, post, schema.Active, actor, actorUserID, reason, updates); err != nil {
+		return err
+	}
+
+	post.DatePosted = now
+	post.ExpiresAt = now.Add(lifetime)
+	post.ExpiryNotified = false
+	post.PublishAt = nil
+	return nil
+}
+
+// RunPublishWorker publishes scheduled drafts as their time comes until ctx is done
+func RunPublishWorker(ctx context.Context, postUtils IPostUtils, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runPublish(postUtils)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runPublish(postUtils IPostUtils) {
+	for {
+		published, err := postUtils.PublishScheduledPosts()
+		if err != nil {
+			log.Printf("Error publishing scheduled posts: %v", err)
+			return
+		}
+
+		for _, post := range published {
+			description := fmt.Sprintf("Your post \"%s\" has been published.", post.Title)
+			if err := postUtils.CreateNotification(post.UserID, PostPublishedNotification, description); err != nil {
+				log.Printf("Error notifying user %d about published post %d: %v", post.UserID, post.PostID, err)
+			}
+
+			// subscribers hear about a scheduled post when it goes live, not when it was written
+			MatchSavedSearches(postUtils, post)
+		}
+
+		// a short batch means every due draft went out
+		if len(published) < publishWorkerBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePublishAt(t *testing.T) {
+	postUtils := &PostUtils{Publish: PublishConfig{MaxSchedule: 30 * 24 * time.Hour}}
+
+	assert.NoError(t, postUtils.ValidatePublishAt(time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, postUtils.ValidatePublishAt(time.Now().Add(-time.Minute)), ErrInvalidPublishTime)
+	assert.ErrorIs(t, postUtils.ValidatePublishAt(time.Now().Add(31*24*time.Hour)), ErrInvalidPublishTime)
+}
+
+func TestPublishScheduledPosts(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db}
+
+	// written a week ago with a three day lifetime
+	written := time.Now().Add(-7 * 24 * time.Hour)
+	publishAt := time.Now().Add(-time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE status = \$1 AND publish_at <= \$2 ORDER BY publish_at LIMIT \$3 FOR UPDATE SKIP LOCKED`).
+		WithArgs(schema.Draft, sqlmock.AnyArg(), publishWorkerBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "title", "status", "date_posted", "expires_at", "publish_at"}).
+			AddRow(5, 2, "Desk lamp", schema.Draft, written, written.Add(3*24*time.Hour), publishAt))
+	mock.ExpectExec(`UPDATE "posts" SET "date_posted"=\$1,"date_updated"=\$2,"expires_at"=\$3,"expiry_notified"=\$4,"publish_at"=\$5,"status"=\$6 WHERE post_id = \$7`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), false, nil, schema.Active, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "post_status_histories"`).
+		WithArgs(5, schema.Draft, schema.Active, schema.SchedulerActor, 0, "scheduled", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"history_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	posts, err := postUtils.PublishScheduledPosts()
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, schema.Active, posts[0].Status)
+	assert.Nil(t, posts[0].PublishAt)
+	// the lifetime starts when the post is published, not when it was written
+	assert.WithinDuration(t, time.Now(), posts[0].DatePosted, time.Minute)
+	assert.WithinDuration(t, time.Now().Add(3*24*time.Hour), posts[0].ExpiresAt, time.Minute)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
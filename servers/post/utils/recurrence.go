@@ -0,0 +1,331 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"post/schema"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// RecurringPostNotification tells the owner their series reposted its post
+	RecurringPostNotification types.NotificationType = "recurringpost"
+
+	// series handled per worker pass, the rest wait for the next one
+	recurrenceWorkerBatchSize = 100
+)
+
+var (
+	ErrInvalidRecurrence = errors.New("recurrence needs a known frequency and an end date after its first repeat, within the maximum span")
+	ErrAlreadyRecurring  = errors.New("post already belongs to a series")
+	ErrPostNotRecurrable = errors.New("only active posts can be made recurring")
+	ErrSeriesFinished    = errors.New("series was cancelled or has ended")
+)
+
+// RecurrenceConfig controls post series and the recurrence worker
+type RecurrenceConfig struct {
+	Interval time.Duration // how often the worker runs
+	MaxSpan  time.Duration // the furthest ahead a series may end
+}
+
+// RecurrenceConfigFromEnv reads POST_RECURRENCE_INTERVAL and POST_MAX_RECURRENCE_DAYS
+func RecurrenceConfigFromEnv() RecurrenceConfig {
+	return RecurrenceConfig{
+		Interval: envDuration("POST_RECURRENCE_INTERVAL", time.Minute),
+		MaxSpan:  envDays("POST_MAX_RECURRENCE_DAYS", 365),
+	}
+}
+
+// SeriesOccurrence is a post a series reposted, along with the previous occurrence when it was
+// expired to make way for it
+type SeriesOccurrence struct {
+	Series  schema.PostSeries
+	Post    schema.Post
+	Expired *schema.Post
+}
+
+// NextOccurrence steps a recurrence one period on from a time, false for an unknown frequency
+func NextOccurrence(frequency schema.RecurrenceFrequency, from time.Time) (time.Time, bool) {
+	switch frequency {
+	case schema.WeeklyRecurrence:
+		return from.AddDate(0, 0, 7), true
+	case schema.BiweeklyRecurrence:
+		return from.AddDate(0, 0, 14), true
+	case schema.MonthlyRecurrence:
+		return from.AddDate(0, 1, 0), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// nextOccurrenceAfter is the first occurrence following from that is later than now, so a series
+// that fell behind skips the occurrences it missed rather than reposting them all at once
+func nextOccurrenceAfter(frequency schema.RecurrenceFrequency, from time.Time, now time.Time) time.Time {
+	next, _ := NextOccurrence(frequency, from)
+	for !next.After(now) {
+		next, _ = NextOccurrence(frequency, next)
+	}
+	return next
+}
+
+// StartSeries makes an active post recurring until endsAt, its first repeat is one period after it was posted
+func (pu *PostUtils) StartSeries(postID uint, ownerID uint, frequency schema.RecurrenceFrequency, endsAt time.Time) (schema.PostSeries, error) {
+	if _, ok := NextOccurrence(frequency, time.Now()); !ok {
+		return schema.PostSeries{}, ErrInvalidRecurrence
+	}
+
+	var series schema.PostSeries
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		var post schema.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+			return err
+		}
+		if post.SeriesID != nil {
+			return ErrAlreadyRecurring
+		}
+		if post.Status != schema.Active {
+			return ErrPostNotRecurrable
+		}
+
+		now := time.Now()
+		next := nextOccurrenceAfter(frequency, post.DatePosted, now)
+		if next.After(endsAt) || endsAt.After(now.Add(pu.Recurrence.MaxSpan)) {
+			return ErrInvalidRecurrence
+		}
+
+		series = schema.PostSeries{
+			UserID:         ownerID,
+			LatestPostID:   post.PostID,
+			Frequency:      frequency,
+			EndsAt:         endsAt,
+			NextOccurrence: next,
+			Occurrences:    1,
+			Status:         schema.SeriesActive,
+			DateCreated:    now,
+			DateUpdated:    now,
+		}
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		return tx.Model(&schema.Post{}).Where("post_id = ?", post.PostID).Update("series_id", series.SeriesID).Error
+	})
+	if err != nil {
+		return schema.PostSeries{}, err
+	}
+
+	return series, nil
+}
+
+// GetSeries lists the user's post series, newest first
+func (pu *PostUtils) GetSeries(userID uint) ([]schema.PostSeries, error) {
+	series := []schema.PostSeries{}
+	if err := pu.DB.Where("user_id = ?", userID).Order("series_id desc").Find(&series).Error; err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// GetSeriesByID retrieves a post series by its ID
+func (pu *PostUtils) GetSeriesByID(seriesID uint) (schema.PostSeries, error) {
+	var series schema.PostSeries
+	if err := pu.DB.First(&series, seriesID).Error; err != nil {
+		return schema.PostSeries{}, err
+	}
+	return series, nil
+}
+
+// SetSeriesStatus pauses, resumes or cancels a series. A resumed series picks up at its next
+// occurrence after now, and ends right away if that's past its end date.
+func (pu *PostUtils) SetSeriesStatus(seriesID uint, status schema.SeriesStatus) (schema.PostSeries, error) {
+	var series schema.PostSeries
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&series, seriesID).Error; err != nil {
+			return err
+		}
+		if series.Status == schema.SeriesCancelled || series.Status == schema.SeriesEnded {
+			return ErrSeriesFinished
+		}
+
+		now := time.Now()
+		series.Status = status
+		if status == schema.SeriesActive && !series.NextOccurrence.After(now) {
+			series.NextOccurrence = nextOccurrenceAfter(series.Frequency, series.NextOccurrence, now)
+		}
+		if status == schema.SeriesActive && series.NextOccurrence.After(series.EndsAt) {
+			series.Status = schema.SeriesEnded
+		}
+		series.DateUpdated = now
+		return tx.Save(&series).Error
+	})
+	if err != nil {
+		return schema.PostSeries{}, err
+	}
+
+	return series, nil
+}
+
+// PostSeriesOccurrences reposts a batch of series whose next occurrence has come and expires their
+// previous posts. Series locked by another replica are skipped, so each occurrence is posted once.
+func (pu *PostUtils) PostSeriesOccurrences() ([]SeriesOccurrence, error) {
+	var occurrences []SeriesOccurrence
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		var due []schema.PostSeries
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_occurrence <= ?", schema.SeriesActive, time.Now()).
+			Order("next_occurrence").
+			Limit(recurrenceWorkerBatchSize).
+			Find(&due).Error
+		if err != nil {
+			return err
+		}
+
+		for _, series := range due {
+			occurrence, err := postOccurrence(tx, series)
+			if err != nil {
+				return err
+			}
+			if occurrence != nil {
+				occurrences = append(occurrences, *occurrence)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return occurrences, nil
+}
+
+// postOccurrence copies the series' latest post into a new active post and moves the series on.
+// A series whose post was taken down by moderation is paused instead, and one whose post is gone
+// is cancelled, nil is returned then.
+func postOccurrence(tx *gorm.DB, series schema.PostSeries) (*SeriesOccurrence, error) {
+	now := time.Now()
+
+	var latest schema.Post
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&latest, series.LatestPostID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		series.Status = schema.SeriesCancelled
+		series.DateUpdated = now
+		return nil, tx.Save(&series).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	if latest.Status == schema.Hidden {
+		series.Status = schema.SeriesPaused
+		series.DateUpdated = now
+		return nil, tx.Save(&series).Error
+	}
+
+	post := cloneOccurrence(latest, now)
+	if err := tx.Create(&post).Error; err != nil {
+		return nil, err
+	}
+	if err := recordStatusChange(tx, post.PostID, "", post.Status, schema.SchedulerActor, 0, "recurring"); err != nil {
+		return nil, err
+	}
+
+	occurrence := &SeriesOccurrence{Post: post}
+	if CanTransition(latest.Status, schema.Expired, schema.SchedulerActor) {
+		if err := transitionPost(tx, &latest, schema.Expired, schema.SchedulerActor, 0, "replaced by the next occurrence", nil); err != nil {
+			return nil, err
+		}
+		occurrence.Expired = &latest
+	}
+
+	series.LatestPostID = post.PostID
+	series.Occurrences++
+	series.NextOccurrence = nextOccurrenceAfter(series.Frequency, series.NextOccurrence, now)
+	if series.NextOccurrence.After(series.EndsAt) {
+		series.Status = schema.SeriesEnded
+	}
+	series.DateUpdated = now
+	if err := tx.Save(&series).Error; err != nil {
+		return nil, err
+	}
+
+	occurrence.Series = series
+	return occurrence, nil
+}
+
+// cloneOccurrence copies a post into the next occurrence of its series, posted now with the same
+// lifetime. A needed by date moves along with the post. Images stay with the post they were uploaded to.
+func cloneOccurrence(latest schema.Post, now time.Time) schema.Post {
+	post := schema.Post{
+		UserID:      latest.UserID,
+		Username:    latest.Username,
+		Title:       latest.Title,
+		Description: latest.Description,
+		Category:    latest.Category,
+		Type:        latest.Type,
+		Quantity:    latest.Quantity,
+		Latitude:    latest.Latitude,
+		Longitude:   latest.Longitude,
+		Building:    latest.Building,
+		Status:      schema.Active,
+		DatePosted:  now,
+		DateUpdated: now,
+		ExpiresAt:   now.Add(latest.ExpiresAt.Sub(latest.DatePosted)),
+		SeriesID:    latest.SeriesID,
+	}
+	if latest.NeededBy != nil {
+		neededBy := latest.NeededBy.Add(now.Sub(latest.DatePosted))
+		post.NeededBy = &neededBy
+	}
+	return post
+}
+
+// RunRecurrenceWorker reposts series as their occurrences come until ctx is done
+func RunRecurrenceWorker(ctx context.Context, postUtils IPostUtils, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runRecurrence(postUtils)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runRecurrence(postUtils IPostUtils) {
+	for {
+		occurrences, err := postUtils.PostSeriesOccurrences()
+		if err != nil {
+			log.Printf("Error posting series occurrences: %v", err)
+			return
+		}
+
+		for _, occurrence := range occurrences {
+			post := occurrence.Post
+			description := fmt.Sprintf("Your recurring post \"%s\" has been posted again.", post.Title)
+			if occurrence.Series.Status == schema.SeriesEnded {
+				description = fmt.Sprintf("Your recurring post \"%s\" has been posted for the last time.", post.Title)
+			}
+			if err := postUtils.CreateNotification(post.UserID, RecurringPostNotification, description); err != nil {
+				log.Printf("Error notifying user %d about recurring post %d: %v", post.UserID, post.PostID, err)
+			}
+
+			if occurrence.Expired != nil {
+				NotifyBookmarkersOfStatus(postUtils, *occurrence.Expired)
+			}
+			MatchSavedSearches(postUtils, post)
+		}
+
+		// a short batch means every due series was handled, or the ones left wait for the next tick
+		if len(occurrences) < recurrenceWorkerBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	from := time.Date(2024, time.January, 6, 9, 0, 0, 0, time.UTC)
+
+	next, ok := NextOccurrence(schema.WeeklyRecurrence, from)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, time.January, 13, 9, 0, 0, 0, time.UTC), next)
+
+	next, ok = NextOccurrence(schema.BiweeklyRecurrence, from)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, time.January, 20, 9, 0, 0, 0, time.UTC), next)
+
+	next, ok = NextOccurrence(schema.MonthlyRecurrence, from)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, time.February, 6, 9, 0, 0, 0, time.UTC), next)
+
+	_, ok = NextOccurrence("daily", from)
+	assert.False(t, ok)
+}
+
+func TestNextOccurrenceAfterSkipsMissed(t *testing.T) {
+	from := time.Date(2024, time.January, 6, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.January, 25, 12, 0, 0, 0, time.UTC)
+
+	// the 13th and 20th were missed while the series was paused
+	assert.Equal(t, time.Date(2024, time.January, 27, 9, 0, 0, 0, time.UTC), nextOccurrenceAfter(schema.WeeklyRecurrence, from, now))
+}
+
+func TestCloneOccurrence(t *testing.T) {
+	seriesID := uint(3)
+	posted := time.Date(2024, time.January, 6, 9, 0, 0, 0, time.UTC)
+	neededBy := posted.Add(24 * time.Hour)
+	latest := schema.Post{
+		PostID:      11,
+		UserID:      2,
+		Username:    "pantry",
+		Title:       "Volunteers for Saturday food pantry",
+		Description: "Two hours of sorting donations",
+		Category:    "volunteering",
+		Type:        schema.RequestPost,
+		NeededBy:    &neededBy,
+		Building:    "union",
+		Status:      schema.Active,
+		DatePosted:  posted,
+		ExpiresAt:   posted.Add(5 * 24 * time.Hour),
+		Revision:    2,
+		SeriesID:    &seriesID,
+	}
+
+	now := posted.Add(7 * 24 * time.Hour)
+	post := cloneOccurrence(latest, now)
+
+	assert.Zero(t, post.PostID)
+	assert.Zero(t, post.Revision)
+	assert.Equal(t, latest.Title, post.Title)
+	assert.Equal(t, latest.Building, post.Building)
+	assert.Equal(t, schema.Active, post.Status)
+	assert.Equal(t, now, post.DatePosted)
+	assert.Equal(t, now.Add(5*24*time.Hour), post.ExpiresAt)
+	assert.Equal(t, now.Add(24*time.Hour), *post.NeededBy)
+	assert.Equal(t, &seriesID, post.SeriesID)
+}
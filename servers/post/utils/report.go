@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"pagination"
+	"post/schema"
+	"strconv"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReportResolvedNotification tells a reporter what the moderators decided
+const ReportResolvedNotification types.NotificationType = "reportresolved"
+
+var (
+	ErrDuplicateReport = errors.New("you already have an open report about this")
+	ErrReportResolved  = errors.New("report was already resolved")
+)
+
+// ReportConfig controls automatic moderation
+type ReportConfig struct {
+	HideThreshold int // distinct open reports that hide an active post until a moderator looks at it
+}
+
+// ReportConfigFromEnv reads POST_REPORT_HIDE_THRESHOLD
+func ReportConfigFromEnv() ReportConfig {
+	threshold, err := strconv.Atoi(os.Getenv("POST_REPORT_HIDE_THRESHOLD"))
+	if err != nil || threshold < 1 {
+		threshold = 3
+	}
+	return ReportConfig{HideThreshold: threshold}
+}
+
+// ValidReport reports whether the target and reason are known
+func ValidReport(target schema.ReportTarget, reason schema.ReportReason) bool {
+	switch target {
+	case schema.PostReportTarget, schema.BidReportTarget, schema.UserReportTarget:
+	default:
+		return false
+	}
+
+	switch reason {
+	case schema.SpamReportReason, schema.ScamReportReason, schema.AbuseReportReason,
+		schema.OffensiveReportReason, schema.OtherReportReason:
+		return true
+	}
+	return false
+}
+
+// ActionApplies reports whether a moderator can take the action on the reported content.
+// Suspending a post's author is allowed from a post report.
+func ActionApplies(action schema.ModerationAction, target schema.ReportTarget) bool {
+	switch action {
+	case schema.DismissAction:
+		return true
+	case schema.HidePostAction:
+		return target == schema.PostReportTarget
+	case schema.RemoveBidAction:
+		return target == schema.BidReportTarget
+	case schema.SuspendUserAction:
+		return target == schema.UserReportTarget || target == schema.PostReportTarget
+	}
+	return false
+}
+
+// AddReport files a report. A post reported by enough different users is hidden until a moderator
+// reviews it, the returned bool says whether this report hid it.
+func (pu *PostUtils) AddReport(report schema.Report) (schema.Report, bool, error) {
+	hidden := false
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		var post schema.Post
+		if report.TargetType == schema.PostReportTarget {
+			// the lock also serializes concurrent reports on the post, so the threshold is counted once
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, report.TargetID).Error; err != nil {
+				return err
+			}
+		}
+
+		var duplicates int64
+		err := tx.Model(&schema.Report{}).
+			Where("target_type = ? AND target_id = ? AND reporter_user_id = ? AND status = ?",
+				report.TargetType, report.TargetID, report.ReporterUserID, schema.OpenReport).
+			Count(&duplicates).Error
+		if err != nil {
+			return err
+		}
+		if duplicates > 0 {
+			return ErrDuplicateReport
+		}
+
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+
+		if report.TargetType != schema.PostReportTarget || !CanTransition(post.Status, schema.Hidden, schema.ReportsActor) {
+			return nil
+		}
+
+		var reporters int64
+		err = tx.Model(&schema.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, schema.OpenReport).
+			Distinct("reporter_user_id").
+			Count(&reporters).Error
+		if err != nil || reporters < int64(pu.Reports.HideThreshold) {
+			return err
+		}
+
+		hidden = true
+		return transitionPost(tx, &post, schema.Hidden, schema.ReportsActor, 0, fmt.Sprintf("reported by %d users", reporters), nil)
+	})
+	if err != nil {
+		return schema.Report{}, false, err
+	}
+
+	return report, hidden, nil
+}
+
+// GetReportByID retrieves a report by its ID
+func (pu *PostUtils) GetReportByID(reportID uint) (schema.Report, error) {
+	var report schema.Report
+	if err := pu.DB.First(&report, reportID).Error; err != nil {
+		return schema.Report{}, err
+	}
+	return report, nil
+}
+
+// GetReports retrieves a page of the moderation queue
+func (pu *PostUtils) GetReports(filter schema.ReportFilter, page pagination.Page) ([]schema.Report, error) {
+	query := pu.DB.Where("status = ?", filter.Status)
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+
+	var reports []schema.Report
+	if err := page.Apply(query).Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// ResolveReports closes the report and every other open report on the same content with the moderator's decision,
+// returning the reports it closed so their reporters can be told
+func (pu *PostUtils) ResolveReports(reportID uint, moderatorUserID uint, action schema.ModerationAction, note string) ([]schema.Report, error) {
+	status := schema.ActionedReport
+	if action == schema.DismissAction {
+		status = schema.DismissedReport
+	}
+
+	var resolved []schema.Report
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		var report schema.Report
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&report, reportID).Error; err != nil {
+			return err
+		}
+		if report.Status != schema.OpenReport {
+			return ErrReportResolved
+		}
+
+		now := time.Now()
+		err := tx.Model(&resolved).
+			Clauses(clause.Returning{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, schema.OpenReport).
+			Updates(map[string]interface{}{
+				"status":            status,
+				"action":            action,
+				"moderator_user_id": moderatorUserID,
+				"resolution":        note,
+				"date_resolved":     now,
+			}).Error
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// ModeratePost hides a reported post, or brings back a post the reports hid when they're dismissed
+func (pu *PostUtils) ModeratePost(postID uint, moderatorUserID uint, action schema.ModerationAction, note string) error {
+	post, err := pu.GetPostByID(postID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case action == schema.HidePostAction && post.Status != schema.Hidden:
+		_, err = pu.TransitionPostStatus(postID, schema.Hidden, schema.ModeratorActor, moderatorUserID, note)
+	case action == schema.DismissAction && post.Status == schema.Hidden:
+		err = pu.DB.Transaction(func(tx *gorm.DB) error {
+			var locked schema.Post
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, postID).Error; err != nil {
+				return err
+			}
+			if locked.Status != schema.Hidden {
+				return nil
+			}
+
+			restored, err := statusBeforeHidden(tx, postID)
+			if err != nil {
+				return err
+			}
+			return transitionPost(tx, &locked, restored, schema.ModeratorActor, moderatorUserID, note, nil)
+		})
+	}
+	return err
+}
+
+// statusBeforeHidden is the status a post had when it was last hidden, so a dismissal doesn't put a
+// matched or expired post back up for bids. Active when the history doesn't say.
+func statusBeforeHidden(tx *gorm.DB, postID uint) (schema.PostStatus, error) {
+	var history schema.PostStatusHistory
+	err := tx.Where("post_id = ? AND to_status = ?", postID, schema.Hidden).Order("history_id desc").First(&history).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return schema.Active, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch history.FromStatus {
+	case schema.Matched, schema.Expired:
+		return history.FromStatus, nil
+	default:
+		return schema.Active, nil
+	}
+}
+
+// RemoveBid deletes a reported bid through the bid service
+func (pu *PostUtils) RemoveBid(bidID uint) error {
+	bidServiceURL := os.Getenv("BID_SERVICE_URL") + fmt.Sprintf("/v1/internal/bid/%d", bidID)
+	req, err := http.NewRequest("DELETE", bidServiceURL, nil)
+	if err != nil {
+		return err
+	}
+
+	return sendInternalRequest(req)
+}
+
+// SuspendUser suspends a reported user through the user service
+func (pu *PostUtils) SuspendUser(userID uint, reason string) error {
+	body, err := json.Marshal(schema.UserSuspensionRequest{
+		UserID:    userID,
+		Suspended: true,
+		Reason:    reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	userServiceURL := os.Getenv("USER_SERVICE_URL") + "/v1/internal/user/suspension"
+	req, err := http.NewRequest("PUT", userServiceURL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return sendInternalRequest(req)
+}
+
+// sendInternalRequest sends a request to another service as the post service, expecting 200 back
+func sendInternalRequest(req *http.Request) error {
+	req.Header.Set("X-Service", "POST")
+	req.Header.Set("X-Api-Key", os.Getenv("POST_API_KEY"))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status: %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+// NotifyReporters tells everyone whose report was resolved what the moderators decided
+func NotifyReporters(postUtils IPostUtils, reports []schema.Report) {
+	for _, report := range reports {
+		var description string
+		switch report.Action {
+		case schema.DismissAction:
+			description = fmt.Sprintf("Thanks for your report about a %s. A moderator reviewed it and found no violation.", report.TargetType)
+		case schema.HidePostAction:
+			description = "Thanks for your report about a post. A moderator reviewed it and the post has been taken down."
+		case schema.RemoveBidAction:
+			description = "Thanks for your report about a bid. A moderator reviewed it and the bid has been removed."
+		default:
+			description = fmt.Sprintf("Thanks for your report about a %s. A moderator reviewed it and the account has been suspended.", report.TargetType)
+		}
+
+		if err := postUtils.CreateNotification(report.ReporterUserID, ReportResolvedNotification, description); err != nil {
+			log.Printf("Error notifying user %d about report %d: %v", report.ReporterUserID, report.ReportID, err)
+		}
+	}
+}
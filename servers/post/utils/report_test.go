@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionApplies(t *testing.T) {
+	assert.True(t, ActionApplies(schema.DismissAction, schema.BidReportTarget))
+	assert.True(t, ActionApplies(schema.HidePostAction, schema.PostReportTarget))
+	assert.False(t, ActionApplies(schema.HidePostAction, schema.UserReportTarget))
+	assert.True(t, ActionApplies(schema.RemoveBidAction, schema.BidReportTarget))
+	assert.False(t, ActionApplies(schema.RemoveBidAction, schema.PostReportTarget))
+	assert.True(t, ActionApplies(schema.SuspendUserAction, schema.PostReportTarget))
+	assert.False(t, ActionApplies(schema.SuspendUserAction, schema.BidReportTarget))
+	assert.False(t, ActionApplies("ban_forever", schema.UserReportTarget))
+}
+
+func TestAddReportHidesPostAtThreshold(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db, Reports: ReportConfig{HideThreshold: 3}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."post_id" = \$1 .+ FOR UPDATE`).
+		WithArgs(12, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "status"}).AddRow(12, 4, schema.Active))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "reports" WHERE target_type = \$1 AND target_id = \$2 AND reporter_user_id = \$3 AND status = \$4`).
+		WithArgs(schema.PostReportTarget, 12, 9, schema.OpenReport).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`INSERT INTO "reports"`).
+		WillReturnRows(sqlmock.NewRows([]string{"report_id"}).AddRow(30))
+	// the third different reporter hides the post
+	mock.ExpectQuery(`SELECT COUNT\(DISTINCT\("reporter_user_id"\)\) FROM "reports" WHERE target_type = \$1 AND target_id = \$2 AND status = \$3`).
+		WithArgs(schema.PostReportTarget, 12, schema.OpenReport).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec(`UPDATE "posts" SET "date_updated"=\$1,"status"=\$2 WHERE post_id = \$3`).
+		WithArgs(sqlmock.AnyArg(), schema.Hidden, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "post_status_histories"`).
+		WithArgs(12, schema.Active, schema.Hidden, schema.ReportsActor, 0, "reported by 3 users", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"history_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	report, hidden, err := postUtils.AddReport(schema.Report{
+		TargetType:     schema.PostReportTarget,
+		TargetID:       12,
+		ReporterUserID: 9,
+		Reason:         schema.ScamReportReason,
+		Status:         schema.OpenReport,
+		DateCreated:    time.Now(),
+	})
+	require.NoError(t, err)
+	assert.True(t, hidden)
+	assert.Equal(t, uint(30), report.ReportID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddReportRejectsDuplicate(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db, Reports: ReportConfig{HideThreshold: 3}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "reports"`).
+		WithArgs(schema.UserReportTarget, 5, 9, schema.OpenReport).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	_, _, err := postUtils.AddReport(schema.Report{
+		TargetType:     schema.UserReportTarget,
+		TargetID:       5,
+		ReporterUserID: 9,
+		Reason:         schema.AbuseReportReason,
+		Status:         schema.OpenReport,
+	})
+	assert.ErrorIs(t, err, ErrDuplicateReport)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestModeratePostDismissRestoresStatusBeforeHidden(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db}
+
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."post_id" = \$1`).
+		WithArgs(12, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "status"}).AddRow(12, 4, schema.Hidden))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."post_id" = \$1 .+ FOR UPDATE`).
+		WithArgs(12, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "status"}).AddRow(12, 4, schema.Hidden))
+	// the post was matched when a moderator hid it
+	mock.ExpectQuery(`SELECT \* FROM "post_status_histories" WHERE post_id = \$1 AND to_status = \$2 ORDER BY history_id desc`).
+		WithArgs(12, schema.Hidden, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"history_id", "post_id", "from_status", "to_status"}).AddRow(7, 12, schema.Matched, schema.Hidden))
+	mock.ExpectExec(`UPDATE "posts" SET "date_updated"=\$1,"status"=\$2 WHERE post_id = \$3`).
+		WithArgs(sqlmock.AnyArg(), schema.Matched, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "post_status_histories"`).
+		WithArgs(12, schema.Hidden, schema.Matched, schema.ModeratorActor, 2, "reports dismissed", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"history_id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	err := postUtils.ModeratePost(12, 2, schema.DismissAction, "reports dismissed")
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestModeratePostDismissDefaultsToActive(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db}
+
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."post_id" = \$1`).
+		WithArgs(12, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "status"}).AddRow(12, 4, schema.Hidden))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."post_id" = \$1 .+ FOR UPDATE`).
+		WithArgs(12, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "status"}).AddRow(12, 4, schema.Hidden))
+	// no record of how the post was hidden
+	mock.ExpectQuery(`SELECT \* FROM "post_status_histories"`).
+		WithArgs(12, schema.Hidden, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"history_id"}))
+	mock.ExpectExec(`UPDATE "posts" SET "date_updated"=\$1,"status"=\$2 WHERE post_id = \$3`).
+		WithArgs(sqlmock.AnyArg(), schema.Active, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "post_status_histories"`).
+		WillReturnRows(sqlmock.NewRows([]string{"history_id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	err := postUtils.ModeratePost(12, 2, schema.DismissAction, "reports dismissed")
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
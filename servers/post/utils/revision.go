@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"pagination"
+	"post/schema"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostEditedNotification tells the bidders a post changed after they bid on it
+const PostEditedNotification types.NotificationType = "postedited"
+
+// UpdatePost applies the owner's edit and records the changed fields as a new revision.
+// The returned revision is zero when the edit didn't change anything.
+func (pu *PostUtils) UpdatePost(postID uint, editorUserID uint, update schema.PostRequest) (schema.Post, schema.PostRevision, error) {
+	var post schema.Post
+	var revision schema.PostRevision
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+			return err
+		}
+		before := post
+
+		// Update fields
+		post.Title = update.Title
+		post.Description = update.Description
+		post.Category = update.Category
+		if err := pu.ApplyLocation(&post, update); err != nil {
+			return err
+		}
+		post.Type = update.Type
+		post.Quantity = update.Quantity
+		post.NeededBy = update.NeededBy
+		post.DateUpdated = time.Now()
+		if update.ExpiresAt != nil {
+			post.ExpiresAt = *update.ExpiresAt
+			post.ExpiryNotified = false
+			// a draft's lifetime runs from its date posted once it's published, so restart it here
+			if post.Status == schema.Draft {
+				post.DatePosted = post.DateUpdated
+			}
+		}
+
+		// a new expiry alone is a renewal, not a revision, and nobody saw a draft to compare against
+		if changes := diffPost(before, post); len(changes) > 0 && post.Status != schema.Draft {
+			post.Revision++
+			revision = schema.PostRevision{
+				PostID:       post.PostID,
+				Revision:     post.Revision,
+				EditorUserID: editorUserID,
+				Changes:      changes,
+				CreatedDate:  post.DateUpdated,
+			}
+			if err := tx.Create(&revision).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(&post).Error
+	})
+	if err != nil {
+		return schema.Post{}, schema.PostRevision{}, err
+	}
+
+	return post, revision, nil
+}
+
+// GetPostRevisions retrieves a page of a post's revisions
+func (pu *PostUtils) GetPostRevisions(postID uint, page pagination.Page) ([]schema.PostRevision, error) {
+	var revisions []schema.PostRevision
+	if err := page.Apply(pu.DB.Where("post_id = ?", postID)).Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// diffPost lists the fields that differ between two versions of a post, in a fixed order
+func diffPost(before, after schema.Post) []schema.PostFieldChange {
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"title", before.Title, after.Title},
+		{"description", before.Description, after.Description},
+		{"category", before.Category, after.Category},
+		{"type", string(before.Type), string(after.Type)},
+		{"quantity", strconv.Itoa(before.Quantity), strconv.Itoa(after.Quantity)},
+		{"needed_by", formatRevisionTime(before.NeededBy), formatRevisionTime(after.NeededBy)},
+		{"location", formatRevisionLocation(before), formatRevisionLocation(after)},
+	}
+
+	changes := []schema.PostFieldChange{}
+	for _, field := range fields {
+		if field.before != field.after {
+			changes = append(changes, schema.PostFieldChange{Field: field.name, From: field.before, To: field.after})
+		}
+	}
+	return changes
+}
+
+func formatRevisionTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339)
+}
+
+// formatRevisionLocation is the building slug, or the rounded coordinates for other locations
+func formatRevisionLocation(post schema.Post) string {
+	switch {
+	case post.Building != "":
+		return post.Building
+	case post.Latitude != nil && post.Longitude != nil:
+		return strconv.FormatFloat(*post.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(*post.Longitude, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// GetOpenBidders asks the bid service who has a bid on the post that is still waiting for an answer
+func (pu *PostUtils) GetOpenBidders(postID uint) ([]uint, error) {
+	bidServiceURL := os.Getenv("BID_SERVICE_URL") + fmt.Sprintf("/v1/internal/bid/post/%d/bidders", postID)
+
+	req, err := http.NewRequest("GET", bidServiceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the headers
+	req.Header.Set("X-Service", "POST")
+	req.Header.Set("X-Api-Key", os.Getenv("POST_API_KEY"))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check response status code
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bid service responded with status: %d", resp.StatusCode)
+	}
+
+	// Decode the JSON response into a struct
+	var fullResponse types.FullResponseWithData
+	if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.Marshal(fullResponse.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var bidders schema.PostBiddersResponse
+	if err := json.Unmarshal(jsonData, &bidders); err != nil {
+		return nil, err
+	}
+	if bidders.PostID != postID {
+		return nil, errors.New("bid service answered for another post")
+	}
+
+	return bidders.UserIDs, nil
+}
+
+// NotifyPostEdited tells everyone with an open bid on the post, and everyone who bookmarked it, what the edit changed
+func NotifyPostEdited(postUtils IPostUtils, post schema.Post, revision schema.PostRevision) {
+	fields := make([]string, 0, len(revision.Changes))
+	for _, change := range revision.Changes {
+		fields = append(fields, strings.ReplaceAll(change.Field, "_", " "))
+	}
+	changed := strings.Join(fields, ", ")
+
+	// bidders who also bookmarked the post hear about the edit once
+	notified := map[uint]bool{}
+	bidders, err := postUtils.GetOpenBidders(post.PostID)
+	if err != nil {
+		log.Printf("Error fetching the bidders of edited post %d: %v", post.PostID, err)
+	}
+	description := fmt.Sprintf("\"%s\" was edited after you bid on it, the %s changed.", post.Title, changed)
+	for _, bidder := range bidders {
+		notified[bidder] = true
+		if err := postUtils.CreateNotification(bidder, PostEditedNotification, description); err != nil {
+			log.Printf("Error notifying user %d about edited post %d: %v", bidder, post.PostID, err)
+		}
+	}
+
+	description = fmt.Sprintf("\"%s\", a post you bookmarked, was edited, the %s changed.", post.Title, changed)
+	notifyBookmarkers(postUtils, post, description, notified)
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffPost(t *testing.T) {
+	neededBy := time.Date(2024, 5, 1, 17, 0, 0, 0, time.FixedZone("EDT", -4*60*60))
+	lat, lon := 40.425, -86.911
+	before := schema.Post{
+		Title:       "Need a calculator",
+		Description: "for the MA 161 exam",
+		Category:    "electronics",
+		Type:        schema.RequestPost,
+		Building:    "walc",
+	}
+
+	assert.Empty(t, diffPost(before, before))
+
+	// expiry and bookkeeping fields aren't part of a revision
+	renewed := before
+	renewed.ExpiresAt = time.Now()
+	renewed.DateUpdated = time.Now()
+	assert.Empty(t, diffPost(before, renewed))
+
+	after := before
+	after.Title = "Need a graphing calculator"
+	after.NeededBy = &neededBy
+	after.Building = ""
+	after.Latitude, after.Longitude = &lat, &lon
+	assert.Equal(t, []schema.PostFieldChange{
+		{Field: "title", From: "Need a calculator", To: "Need a graphing calculator"},
+		{Field: "needed_by", From: "", To: "2024-05-01T21:00:00Z"},
+		{Field: "location", From: "walc", To: "40.425,-86.911"},
+	}, diffPost(before, after))
+}
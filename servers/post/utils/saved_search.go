@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"post/schema"
+	"strings"
+	"time"
+
+	"github.com/GiveGetGo/shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// SavedSearchNotification tells a subscriber about new posts matching their saved search
+	SavedSearchNotification types.NotificationType = "savedsearch"
+
+	// MaxSavedSearches is how many saved searches a user may have
+	MaxSavedSearches = 20
+
+	// saved searches whose digest is sent per worker pass, the rest wait for the next tick
+	savedSearchDigestBatchSize = 100
+	// post titles listed in a digest before it says how many more there are
+	savedSearchDigestTitles = 3
+)
+
+var ErrTooManySavedSearches = errors.New("saved search limit reached")
+
+// SavedSearchConfig controls the saved search digests
+type SavedSearchConfig struct {
+	DigestInterval time.Duration // how often digests are sent
+}
+
+// SavedSearchConfigFromEnv reads POST_SAVED_SEARCH_DIGEST_INTERVAL
+func SavedSearchConfigFromEnv() SavedSearchConfig {
+	return SavedSearchConfig{
+		DigestInterval: envDuration("POST_SAVED_SEARCH_DIGEST_INTERVAL", 24*time.Hour),
+	}
+}
+
+// SavedSearchDigest is a saved search with the posts that matched it since its last digest
+type SavedSearchDigest struct {
+	Search schema.SavedSearch
+	Posts  []schema.Post
+}
+
+// AddSavedSearch saves a search for its user, up to MaxSavedSearches each
+func (pu *PostUtils) AddSavedSearch(search schema.SavedSearch) (schema.SavedSearch, error) {
+	var count int64
+	if err := pu.DB.Model(&schema.SavedSearch{}).Where("user_id = ?", search.UserID).Count(&count).Error; err != nil {
+		return schema.SavedSearch{}, err
+	}
+	if count >= MaxSavedSearches {
+		return schema.SavedSearch{}, ErrTooManySavedSearches
+	}
+
+	if err := pu.DB.Create(&search).Error; err != nil {
+		return schema.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+// GetSavedSearches lists the user's saved searches, oldest first
+func (pu *PostUtils) GetSavedSearches(userID uint) ([]schema.SavedSearch, error) {
+	searches := []schema.SavedSearch{}
+	if err := pu.DB.Where("user_id = ?", userID).Order("saved_search_id").Find(&searches).Error; err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// GetSavedSearchByID retrieves a saved search by its ID
+func (pu *PostUtils) GetSavedSearchByID(savedSearchID uint) (schema.SavedSearch, error) {
+	var search schema.SavedSearch
+	if err := pu.DB.First(&search, savedSearchID).Error; err != nil {
+		return schema.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+// UpdateSavedSearch stores an edited saved search, matches already waiting for its digest are kept
+func (pu *PostUtils) UpdateSavedSearch(search schema.SavedSearch) error {
+	return pu.DB.Save(&search).Error
+}
+
+// DeleteSavedSearch deletes a saved search and the matches waiting for its digest
+func (pu *PostUtils) DeleteSavedSearch(savedSearchID uint) error {
+	return pu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("saved_search_id = ?", savedSearchID).Delete(&schema.SavedSearchMatch{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&schema.SavedSearch{}, savedSearchID).Error
+	})
+}
+
+// FindMatchingSavedSearches finds the other users' saved searches a new post matches
+func (pu *PostUtils) FindMatchingSavedSearches(post schema.Post) ([]schema.SavedSearch, error) {
+	return pu.SearchIndex.MatchSavedSearches(pu.DB, post)
+}
+
+// QueueSavedSearchMatches keeps the post for the next digest of each saved search
+func (pu *PostUtils) QueueSavedSearchMatches(savedSearchIDs []uint, postID uint) error {
+	if len(savedSearchIDs) == 0 {
+		return nil
+	}
+
+	matches := make([]schema.SavedSearchMatch, 0, len(savedSearchIDs))
+	for _, savedSearchID := range savedSearchIDs {
+		matches = append(matches, schema.SavedSearchMatch{
+			SavedSearchID: savedSearchID,
+			PostID:        postID,
+			DateCreated:   time.Now(),
+		})
+	}
+	return pu.DB.Model(&schema.SavedSearchMatch{}).Clauses(clause.OnConflict{DoNothing: true}).Create(&matches).Error
+}
+
+// ClaimSavedSearchDigests takes a batch of digest saved searches with waiting matches, along with
+// the matched posts that are still active, and clears their matches. A digest may end up without
+// posts. Saved searches locked by another replica are skipped.
+func (pu *PostUtils) ClaimSavedSearchDigests() ([]SavedSearchDigest, error) {
+	var digests []SavedSearchDigest
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		var searches []schema.SavedSearch
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("frequency = ? AND saved_search_id IN (SELECT saved_search_id FROM saved_search_matches)", schema.DigestAlerts).
+			Order("saved_search_id").
+			Limit(savedSearchDigestBatchSize).
+			Find(&searches).Error
+		if err != nil || len(searches) == 0 {
+			return err
+		}
+
+		searchIDs := make([]uint, 0, len(searches))
+		for _, search := range searches {
+			searchIDs = append(searchIDs, search.SavedSearchID)
+		}
+
+		var matches []schema.SavedSearchMatch
+		if err := tx.Where("saved_search_id IN ?", searchIDs).Order("match_id").Find(&matches).Error; err != nil {
+			return err
+		}
+
+		postIDs := make([]uint, 0, len(matches))
+		for _, match := range matches {
+			postIDs = append(postIDs, match.PostID)
+		}
+
+		// posts that were matched, closed or taken down since aren't worth mentioning
+		var posts []schema.Post
+		if err := tx.Where("post_id IN ? AND status = ?", postIDs, schema.Active).Find(&posts).Error; err != nil {
+			return err
+		}
+		postsByID := make(map[uint]schema.Post, len(posts))
+		for _, post := range posts {
+			postsByID[post.PostID] = post
+		}
+
+		matched := map[uint][]schema.Post{}
+		for _, match := range matches {
+			if post, ok := postsByID[match.PostID]; ok {
+				matched[match.SavedSearchID] = append(matched[match.SavedSearchID], post)
+			}
+		}
+		for _, search := range searches {
+			digests = append(digests, SavedSearchDigest{Search: search, Posts: matched[search.SavedSearchID]})
+		}
+
+		return tx.Where("saved_search_id IN ?", searchIDs).Delete(&schema.SavedSearchMatch{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return digests, nil
+}
+
+// MatchSavedSearches alerts the subscribers of the saved searches a new post matches, right away
+// or through their next digest
+func MatchSavedSearches(postUtils IPostUtils, post schema.Post) {
+	searches, err := postUtils.FindMatchingSavedSearches(post)
+	if err != nil {
+		log.Printf("Error matching post %d against saved searches: %v", post.PostID, err)
+		return
+	}
+
+	var digestIDs []uint
+	for _, search := range searches {
+		if search.Frequency == schema.DigestAlerts {
+			digestIDs = append(digestIDs, search.SavedSearchID)
+			continue
+		}
+
+		description := fmt.Sprintf("A new post matches your saved search \"%s\": \"%s\".", search.Name, post.Title)
+		if err := postUtils.CreateNotification(search.UserID, SavedSearchNotification, description); err != nil {
+			log.Printf("Error notifying user %d about saved search %d: %v", search.UserID, search.SavedSearchID, err)
+		}
+	}
+
+	if err := postUtils.QueueSavedSearchMatches(digestIDs, post.PostID); err != nil {
+		log.Printf("Error queueing post %d for saved search digests: %v", post.PostID, err)
+	}
+}
+
+// RunSavedSearchDigestWorker sends the saved search digests every interval until ctx is done
+func RunSavedSearchDigestWorker(ctx context.Context, postUtils IPostUtils, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sendSavedSearchDigests(postUtils)
+		}
+	}
+}
+
+func sendSavedSearchDigests(postUtils IPostUtils) {
+	for {
+		digests, err := postUtils.ClaimSavedSearchDigests()
+		if err != nil {
+			log.Printf("Error claiming saved search digests: %v", err)
+			return
+		}
+
+		for _, digest := range digests {
+			if len(digest.Posts) == 0 {
+				continue
+			}
+			description := FormatSavedSearchDigest(digest)
+			if err := postUtils.CreateNotification(digest.Search.UserID, SavedSearchNotification, description); err != nil {
+				log.Printf("Error sending the digest of saved search %d: %v", digest.Search.SavedSearchID, err)
+			}
+		}
+
+		// a short batch means every waiting digest went out
+		if len(digests) < savedSearchDigestBatchSize {
+			return
+		}
+	}
+}
+
+// FormatSavedSearchDigest describes the posts in a digest, naming the first few
+func FormatSavedSearchDigest(digest SavedSearchDigest) string {
+	if len(digest.Posts) == 1 {
+		return fmt.Sprintf("A new post matches your saved search \"%s\": \"%s\".", digest.Search.Name, digest.Posts[0].Title)
+	}
+
+	titles := make([]string, 0, savedSearchDigestTitles)
+	for _, post := range digest.Posts {
+		if len(titles) == savedSearchDigestTitles {
+			break
+		}
+		titles = append(titles, fmt.Sprintf("\"%s\"", post.Title))
+	}
+
+	listed := strings.Join(titles, ", ")
+	if more := len(digest.Posts) - len(titles); more > 0 {
+		listed += fmt.Sprintf(" and %d more", more)
+	}
+	return fmt.Sprintf("%d new posts match your saved search \"%s\": %s.", len(digest.Posts), digest.Search.Name, listed)
+}
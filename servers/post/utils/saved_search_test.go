@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestILikeMatchSavedSearches(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	post := schema.Post{
+		PostID:      21,
+		UserID:      4,
+		Title:       "CS 251 textbook",
+		Description: "Introduction to Algorithms, barely used",
+		Category:    "textbooks",
+		Type:        schema.OfferPost,
+	}
+
+	// the filters are checked by the query, the keywords by the index
+	mock.ExpectQuery(`SELECT \* FROM "saved_searches" WHERE user_id <> \$1 AND \(category = '' OR category = \$2\) AND \(type = '' OR type = \$3\)`).
+		WithArgs(4, "textbooks", schema.OfferPost).
+		WillReturnRows(sqlmock.NewRows([]string{"saved_search_id", "user_id", "query"}).
+			AddRow(1, 7, "cs 251").
+			AddRow(2, 8, "algorithms calculus").
+			AddRow(3, 9, ""))
+
+	searches, err := ILikeSearchIndex{}.MatchSavedSearches(db, post)
+	require.NoError(t, err)
+	require.Len(t, searches, 2)
+	assert.Equal(t, uint(1), searches[0].SavedSearchID)
+	assert.Equal(t, uint(3), searches[1].SavedSearchID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFormatSavedSearchDigest(t *testing.T) {
+	search := schema.SavedSearch{Name: "Textbooks"}
+	posts := []schema.Post{{Title: "CS 251"}, {Title: "MA 261"}, {Title: "PHYS 172"}, {Title: "CHM 115"}, {Title: "ECON 251"}}
+
+	assert.Equal(t, `A new post matches your saved search "Textbooks": "CS 251".`,
+		FormatSavedSearchDigest(SavedSearchDigest{Search: search, Posts: posts[:1]}))
+	assert.Equal(t, `2 new posts match your saved search "Textbooks": "CS 251", "MA 261".`,
+		FormatSavedSearchDigest(SavedSearchDigest{Search: search, Posts: posts[:2]}))
+	assert.Equal(t, `5 new posts match your saved search "Textbooks": "CS 251", "MA 261", "PHYS 172" and 2 more.`,
+		FormatSavedSearchDigest(SavedSearchDigest{Search: search, Posts: posts}))
+}
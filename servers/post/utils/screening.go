@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+	"post/schema"
+	"screening"
+	"strings"
+	"time"
+)
+
+// length limits of the post text, in characters
+var (
+	TitleLimit       = screening.Limit{Min: 1, Max: 120}
+	DescriptionLimit = screening.Limit{Min: 1, Max: 5000}
+)
+
+// ScreenText normalizes user text and checks it against the screening rules
+func (pu *PostUtils) ScreenText(text string, limit screening.Limit) (screening.Result, error) {
+	return pu.Screener.Screen(text, limit)
+}
+
+// FlagContent puts content the screening flagged in the moderation queue, reported by no user
+func (pu *PostUtils) FlagContent(targetType schema.ReportTarget, targetID uint, matches []screening.MatchType) error {
+	found := make([]string, 0, len(matches))
+	for _, match := range matches {
+		found = append(found, strings.ReplaceAll(string(match), "_", " "))
+	}
+
+	_, _, err := pu.AddReport(schema.Report{
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Reason:      schema.ScreeningReportReason,
+		Details:     fmt.Sprintf("automatically flagged: %s", strings.Join(found, ", ")),
+		Status:      schema.OpenReport,
+		DateCreated: time.Now(),
+	})
+	return err
+}
@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html"
+	"os"
+	"post/db"
+	"post/schema"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostSearchIndex matches, ranks and highlights posts for a text query
+type PostSearchIndex interface {
+	Search(DB db.Database, query schema.PostSearchQuery, location LocationConfig) ([]schema.PostSearchHit, error)
+	// MatchSavedSearches finds the other users' saved searches a new post matches
+	MatchSavedSearches(DB db.Database, post schema.Post) ([]schema.SavedSearch, error)
+}
+
+// Ensure both indexes implement PostSearchIndex
+var _ PostSearchIndex = PostgresSearchIndex{}
+var _ PostSearchIndex = ILikeSearchIndex{}
+
+// highlight delimiters, swapped for <mark> tags once the rest of the text has been escaped
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// NewPostSearchIndexFromEnv picks the index from POST_SEARCH_INDEX, full text search unless set to "ilike"
+func NewPostSearchIndexFromEnv() PostSearchIndex {
+	if os.Getenv("POST_SEARCH_INDEX") == "ilike" {
+		return ILikeSearchIndex{}
+	}
+	return PostgresSearchIndex{}
+}
+
+// PostgresSearchIndex uses the weighted search_vector column created in db.AutoMigratePostgresDB
+type PostgresSearchIndex struct{}
+
+func (PostgresSearchIndex) Search(DB db.Database, query schema.PostSearchQuery, location LocationConfig) ([]schema.PostSearchHit, error) {
+	tsQuery := "websearch_to_tsquery('english', ?)"
+	rankExpr := "ts_rank_cd(search_vector, " + tsQuery + ")::float8"
+	headlineOptions := "StartSel=" + highlightStart + ", StopSel=" + highlightStop
+
+	tx := DB.Model(&schema.Post{}).
+		Select("posts.*, "+rankExpr+" AS rank, "+
+			"ts_headline('english', title, "+tsQuery+", '"+headlineOptions+", HighlightAll=true') AS title_highlight, "+
+			"ts_headline('english', description, "+tsQuery+", '"+headlineOptions+", MaxFragments=2') AS description_highlight"+
+			distanceColumn(query, location),
+			query.Text, query.Text, query.Text).
+		Where("search_vector @@ "+tsQuery, query.Text)
+
+	hits, err := findSearchHits(tx, query, location, rankExpr, []interface{}{query.Text})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range hits {
+		hits[i].TitleHighlight = markHighlights(hits[i].TitleHighlight)
+		hits[i].DescriptionHighlight = markHighlights(hits[i].DescriptionHighlight)
+	}
+
+	return hits, nil
+}
+
+func (PostgresSearchIndex) MatchSavedSearches(DB db.Database, post schema.Post) ([]schema.SavedSearch, error) {
+	var searches []schema.SavedSearch
+	err := savedSearchCandidates(DB, post).
+		Where(`query = '' OR EXISTS (SELECT 1 FROM posts WHERE posts.post_id = ? AND
+			posts.search_vector @@ websearch_to_tsquery('english', saved_searches.query))`, post.PostID).
+		Find(&searches).Error
+	if err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// ILikeSearchIndex matches every word of the query as a substring, for databases without full text search.
+// A word found in the title counts twice as much as one found in the description.
+type ILikeSearchIndex struct{}
+
+func (ILikeSearchIndex) Search(DB db.Database, query schema.PostSearchQuery, location LocationConfig) ([]schema.PostSearchHit, error) {
+	terms := strings.Fields(query.Text)
+	if len(terms) == 0 {
+		return []schema.PostSearchHit{}, nil
+	}
+
+	var rankParts []string
+	var rankArgs []interface{}
+	tx := DB.Model(&schema.Post{})
+	for _, term := range terms {
+		pattern := "%" + escapeLike(term) + "%"
+		tx = tx.Where("(title ILIKE ? OR description ILIKE ?)", pattern, pattern)
+		rankParts = append(rankParts, "CASE WHEN title ILIKE ? THEN 2 ELSE 0 END + CASE WHEN description ILIKE ? THEN 1 ELSE 0 END")
+		rankArgs = append(rankArgs, pattern, pattern)
+	}
+	rankExpr := "(" + strings.Join(rankParts, " + ") + ")::float8"
+	tx = tx.Select("posts.*, "+rankExpr+" AS rank"+distanceColumn(query, location), rankArgs...)
+
+	hits, err := findSearchHits(tx, query, location, rankExpr, rankArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range hits {
+		hits[i].TitleHighlight = highlightTerms(hits[i].Title, terms)
+		hits[i].DescriptionHighlight = highlightTerms(hits[i].Description, terms)
+	}
+
+	return hits, nil
+}
+
+func (ILikeSearchIndex) MatchSavedSearches(DB db.Database, post schema.Post) ([]schema.SavedSearch, error) {
+	var candidates []schema.SavedSearch
+	if err := savedSearchCandidates(DB, post).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	text := strings.ToLower(post.Title + " " + post.Description)
+	searches := []schema.SavedSearch{}
+	for _, search := range candidates {
+		matches := true
+		for _, term := range strings.Fields(strings.ToLower(search.Query)) {
+			if !strings.Contains(text, term) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			searches = append(searches, search)
+		}
+	}
+	return searches, nil
+}
+
+// savedSearchCandidates selects the saved searches whose filters the post passes, leaving out the owner's own
+func savedSearchCandidates(DB db.Database, post schema.Post) *gorm.DB {
+	return DB.Model(&schema.SavedSearch{}).
+		Where("user_id <> ?", post.UserID).
+		Where("category = '' OR category = ?", post.Category).
+		Where("type = '' OR type = ?", post.Type)
+}
+
+// findSearchHits applies the filters and the cursor shared by every index and runs the query
+func findSearchHits(tx *gorm.DB, query schema.PostSearchQuery, location LocationConfig, rankExpr string, rankArgs []interface{}) ([]schema.PostSearchHit, error) {
+	if query.Category != "" {
+		tx = tx.Where("category = ?", query.Category)
+	}
+	if query.Type != "" {
+		tx = tx.Where("type = ?", query.Type)
+	}
+	if query.Status != "" {
+		tx = tx.Where("status = ?", query.Status)
+	}
+	if !query.Since.IsZero() {
+		tx = tx.Where("date_posted >= ?", query.Since)
+	}
+	if !query.Until.IsZero() {
+		tx = tx.Where("date_posted < ?", query.Until)
+	}
+
+	// only posts around the point, and when asked nearest first, paging through the distance instead of the rank
+	if query.Near != nil {
+		distanceExpr := location.DistanceSQL(*query.Near)
+		tx = location.withinRadius(tx, *query.Near, query.RadiusKm)
+
+		if query.ByDistance {
+			if query.After != nil {
+				tx = tx.Where(distanceExpr+" > ? OR ("+distanceExpr+" = ? AND post_id > ?)", query.After.Rank, query.After.Rank, query.After.PostID)
+			}
+			return scanSearchHits(tx.Order(distanceExpr+" asc").Order("post_id asc"), query.Limit)
+		}
+	}
+
+	// keyset pagination, continue after the last hit of the previous page
+	if query.After != nil {
+		args := append(append([]interface{}{}, rankArgs...), query.After.Rank)
+		args = append(append(args, rankArgs...), query.After.Rank, query.After.PostID)
+		tx = tx.Where(rankExpr+" < ? OR ("+rankExpr+" = ? AND post_id < ?)", args...)
+	}
+
+	return scanSearchHits(tx.Order("rank desc").Order("post_id desc"), query.Limit)
+}
+
+func scanSearchHits(tx *gorm.DB, limit int) ([]schema.PostSearchHit, error) {
+	hits := []schema.PostSearchHit{}
+	if err := tx.Limit(limit).Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// distanceColumn selects the distance from the search's point, if it has one
+func distanceColumn(query schema.PostSearchQuery, location LocationConfig) string {
+	if query.Near == nil {
+		return ""
+	}
+	return ", " + location.DistanceSQL(*query.Near) + " AS distance"
+}
+
+// markHighlights escapes a ts_headline fragment and turns its delimiters into <mark> tags
+func markHighlights(fragment string) string {
+	escaped := html.EscapeString(fragment)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
+
+// highlightTerms escapes text and wraps case insensitive occurrences of the terms in <mark> tags
+func highlightTerms(text string, terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	pattern := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[loc[0]:loc[1]]) + "</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+
+	return b.String()
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied term
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
+
+// EncodeSearchCursor makes the opaque next_cursor for the hit that ends a page
+func EncodeSearchCursor(hit schema.PostSearchHit, byDistance bool) string {
+	cursor := schema.PostSearchCursor{Rank: hit.Rank, PostID: hit.PostID}
+	if byDistance && hit.Distance != nil {
+		cursor.Rank = *hit.Distance
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeSearchCursor reverses EncodeSearchCursor
+func DecodeSearchCursor(cursor string) (*schema.PostSearchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid search cursor")
+	}
+
+	var decoded schema.PostSearchCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.PostID == 0 {
+		return nil, errors.New("invalid search cursor")
+	}
+
+	return &decoded, nil
+}
+
+// ParseSearchSince reads the since bound, either a date or a full RFC3339 timestamp, empty means no bound
+func ParseSearchSince(value string) (time.Time, error) {
+	since, _, err := parseSearchDate(value)
+	return since, err
+}
+
+// ParseSearchUntil reads the until bound like ParseSearchSince, a bare date still includes the
+// posts from that day, so the bound moves to the start of the next one
+func ParseSearchUntil(value string) (time.Time, error) {
+	until, dateOnly, err := parseSearchDate(value)
+	if dateOnly {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, err
+}
+
+func parseSearchDate(value string) (time.Time, bool, error) {
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, true, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	return timestamp, false, err
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newSearchTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB, DriverName: "postgres"}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+func TestILikeSearchIndex(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db, SearchIndex: ILikeSearchIndex{}}
+
+	posted := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"post_id", "title", "description", "category", "status", "date_posted", "rank"}).
+		AddRow(7, "TI-84 Calculator", "graphing <calculator> for MA 161", "electronics", "Active", posted, 3).
+		AddRow(3, "Moving boxes", "a calculator and some boxes", "other", "Active", posted, 1)
+
+	// every word has to match, title matches rank higher, and the filters and cursor are applied
+	mock.ExpectQuery(`SELECT posts\.\*, \(CASE WHEN title ILIKE \$1 THEN 2 ELSE 0 END \+ CASE WHEN description ILIKE \$2 THEN 1 ELSE 0 END\)::float8 AS rank FROM "posts" `+
+		`WHERE \(\(title ILIKE \$3 OR description ILIKE \$4\)\) AND category = \$5 AND status = \$6 AND date_posted >= \$7 `+
+		`AND \(.+ < \$10 OR \(.+ = \$13 AND post_id < \$14\)\) ORDER BY rank desc,post_id desc LIMIT \$15`).
+		WithArgs("%calc\\%%", "%calc\\%%", "%calc\\%%", "%calc\\%%", "electronics", schema.Active, posted,
+			"%calc\\%%", "%calc\\%%", 5.0, "%calc\\%%", "%calc\\%%", 5.0, 9, 3).
+		WillReturnRows(rows)
+
+	hits, err := postUtils.SearchPosts(schema.PostSearchQuery{
+		Text:     "calc%",
+		Category: "electronics",
+		Status:   schema.Active,
+		Since:    posted,
+		After:    &schema.PostSearchCursor{Rank: 5, PostID: 9},
+		Limit:    3,
+	})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, uint(7), hits[0].PostID)
+	assert.Equal(t, 3.0, hits[0].Rank)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestILikeSearchHighlighting(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db, SearchIndex: ILikeSearchIndex{}}
+
+	rows := sqlmock.NewRows([]string{"post_id", "title", "description", "rank"}).
+		AddRow(1, "Moving Boxes", "<b>free</b> boxes, moving out", 6)
+	mock.ExpectQuery(`ILIKE`).WillReturnRows(rows)
+
+	hits, err := postUtils.SearchPosts(schema.PostSearchQuery{Text: "moving boxes", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "<mark>Moving</mark> <mark>Boxes</mark>", hits[0].TitleHighlight)
+	assert.Equal(t, "&lt;b&gt;free&lt;/b&gt; <mark>boxes</mark>, <mark>moving</mark> out", hits[0].DescriptionHighlight)
+}
+
+func TestPostgresSearchIndex(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db, SearchIndex: PostgresSearchIndex{}}
+
+	rows := sqlmock.NewRows([]string{"post_id", "title", "rank", "title_highlight", "description_highlight"}).
+		AddRow(4, "Calculator", 0.1, "\x02Calculator\x03", "works & \x02calculates\x03")
+	mock.ExpectQuery(`SELECT posts\.\*, ts_rank_cd\(search_vector, websearch_to_tsquery\('english', \$1\)\)::float8 AS rank, `+
+		`ts_headline\('english', title, websearch_to_tsquery\('english', \$2\), .+\) AS title_highlight, `+
+		`ts_headline\('english', description, websearch_to_tsquery\('english', \$3\), .+\) AS description_highlight `+
+		`FROM "posts" WHERE search_vector @@ websearch_to_tsquery\('english', \$4\) ORDER BY rank desc,post_id desc LIMIT \$5`).
+		WithArgs("calculator", "calculator", "calculator", "calculator", 21).
+		WillReturnRows(rows)
+
+	hits, err := postUtils.SearchPosts(schema.PostSearchQuery{Text: "calculator", Limit: 21})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "<mark>Calculator</mark>", hits[0].TitleHighlight)
+	assert.Equal(t, "works &amp; <mark>calculates</mark>", hits[0].DescriptionHighlight)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchCursor(t *testing.T) {
+	hit := schema.PostSearchHit{Post: schema.Post{PostID: 12}, Rank: 0.10000000149011612}
+
+	cursor, err := DecodeSearchCursor(EncodeSearchCursor(hit, false))
+	require.NoError(t, err)
+	assert.Equal(t, hit.Rank, cursor.Rank)
+	assert.Equal(t, uint(12), cursor.PostID)
+
+	_, err = DecodeSearchCursor("not a cursor")
+	assert.Error(t, err)
+}
+
+func TestParseSearchDates(t *testing.T) {
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	since, err := ParseSearchSince("2026-10-19")
+	require.NoError(t, err)
+	assert.Equal(t, day, since)
+
+	// a bare until date keeps the posts from that whole day
+	until, err := ParseSearchUntil("2026-10-19")
+	require.NoError(t, err)
+	assert.Equal(t, day.AddDate(0, 0, 1), until)
+
+	// a timestamp is taken as is
+	until, err = ParseSearchUntil("2026-10-19T15:30:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, day.Add(15*time.Hour+30*time.Minute), until)
+
+	until, err = ParseSearchUntil("")
+	require.NoError(t, err)
+	assert.True(t, until.IsZero())
+
+	_, err = ParseSearchUntil("19/10/2026")
+	assert.Error(t, err)
+}
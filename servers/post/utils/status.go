@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"errors"
+	"pagination"
+	"post/schema"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrIllegalTransition is returned when a status change isn't in the transition table for the actor
+var ErrIllegalTransition = errors.New("illegal post status transition")
+
+// postTransitions lists, for each status, the statuses it may move to and who may move it there.
+// Closed is terminal.
+var postTransitions = map[schema.PostStatus]map[schema.PostStatus][]schema.StatusActor{
+	schema.Active: {
+		schema.Matched: {schema.MatchActor},
+		schema.Closed:  {schema.OwnerActor, schema.AdminActor},
+		schema.Expired: {schema.SchedulerActor},
+		schema.Hidden:  {schema.ModeratorActor, schema.ReportsActor},
+	},
+	schema.Matched: {
+		// the match fell through
+		schema.Active: {schema.MatchActor, schema.AdminActor},
+		schema.Closed: {schema.OwnerActor, schema.MatchActor, schema.AdminActor},
+		schema.Hidden: {schema.ModeratorActor},
+	},
+	schema.Expired: {
+		// renewed by the owner, only through RenewPost so the post gets a new expiry
+		schema.Active: {schema.OwnerActor},
+		schema.Closed: {schema.OwnerActor, schema.AdminActor},
+		schema.Hidden: {schema.ModeratorActor},
+	},
+	schema.Hidden: {
+		// the reports were dismissed, the post goes back to the status it was hidden from
+		schema.Active:  {schema.ModeratorActor},
+		schema.Matched: {schema.ModeratorActor},
+		schema.Expired: {schema.ModeratorActor},
+		schema.Closed:  {schema.OwnerActor, schema.AdminActor, schema.ModeratorActor},
+	},
+	schema.Draft: {
+		// published by the owner, or by the scheduler at the chosen time
+		schema.Active: {schema.OwnerActor, schema.SchedulerActor},
+	},
+	schema.Closed: {},
+}
+
+// CanTransition reports whether actor may move a post from one status to another
+func CanTransition(from, to schema.PostStatus, actor schema.StatusActor) bool {
+	for _, allowed := range postTransitions[from][to] {
+		if allowed == actor {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionPostStatus moves a post to a new status if the transition table allows it for the actor,
+// and records the change in the status history. Drafts and renewals have their own paths, PublishPost
+// and RenewPost, so neither goes through here.
+func (pu *PostUtils) TransitionPostStatus(postID uint, to schema.PostStatus, actor schema.StatusActor, actorUserID uint, reason string) (schema.Post, error) {
+	var post schema.Post
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+			return err
+		}
+
+		// a draft goes live through publishing, which starts its lifetime, and an expired post
+		// comes back through renewing, which gives it a new expiry
+		if post.Status == schema.Draft || (post.Status == schema.Expired && to == schema.Active) {
+			return ErrIllegalTransition
+		}
+
+		return transitionPost(tx, &post, to, actor, actorUserID, reason, nil)
+	})
+	if err != nil {
+		return schema.Post{}, err
+	}
+
+	return post, nil
+}
+
+// transitionPost applies a status change with any extra column updates to a post locked in tx
+func transitionPost(tx *gorm.DB, post *schema.Post, to schema.PostStatus, actor schema.StatusActor, actorUserID uint, reason string, updates map[string]interface{}) error {
+	if !CanTransition(post.Status, to, actor) {
+		return ErrIllegalTransition
+	}
+
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	updates["date_updated"] = time.Now()
+	if err := tx.Model(&schema.Post{}).Where("post_id = ?", post.PostID).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	from := post.Status
+	post.Status = to
+	return recordStatusChange(tx, post.PostID, from, to, actor, actorUserID, reason)
+}
+
+func recordStatusChange(tx *gorm.DB, postID uint, from, to schema.PostStatus, actor schema.StatusActor, actorUserID uint, reason string) error {
+	return tx.Create(&schema.PostStatusHistory{
+		PostID:      postID,
+		FromStatus:  from,
+		ToStatus:    to,
+		Actor:       actor,
+		ActorUserID: actorUserID,
+		Reason:      reason,
+		CreatedDate: time.Now(),
+	}).Error
+}
+
+// GetPostStatusHistory retrieves a page of a post's status changes
+func (pu *PostUtils) GetPostStatusHistory(postID uint, page pagination.Page) ([]schema.PostStatusHistory, error) {
+	var history []schema.PostStatusHistory
+	if err := page.Apply(pu.DB.Where("post_id = ?", postID)).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"post/schema"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to schema.PostStatus
+		actor    schema.StatusActor
+		allowed  bool
+	}{
+		{schema.Active, schema.Matched, schema.MatchActor, true},
+		{schema.Active, schema.Matched, schema.OwnerActor, false},
+		{schema.Active, schema.Expired, schema.SchedulerActor, true},
+		{schema.Active, schema.Expired, schema.OwnerActor, false},
+		{schema.Active, schema.Closed, schema.OwnerActor, true},
+		{schema.Matched, schema.Active, schema.MatchActor, true},
+		{schema.Matched, schema.Active, schema.OwnerActor, false},
+		{schema.Expired, schema.Active, schema.OwnerActor, true},
+		{schema.Expired, schema.Matched, schema.MatchActor, false},
+		{schema.Closed, schema.Active, schema.AdminActor, false},
+		{schema.Active, schema.Active, schema.OwnerActor, false},
+		{schema.Active, schema.Hidden, schema.ReportsActor, true},
+		{schema.Matched, schema.Hidden, schema.ReportsActor, false},
+		{schema.Hidden, schema.Active, schema.ModeratorActor, true},
+		{schema.Hidden, schema.Active, schema.OwnerActor, false},
+		{schema.Draft, schema.Active, schema.OwnerActor, true},
+		{schema.Draft, schema.Active, schema.SchedulerActor, true},
+		{schema.Draft, schema.Matched, schema.MatchActor, false},
+		{schema.Active, schema.Draft, schema.OwnerActor, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, CanTransition(tt.from, tt.to, tt.actor), "%s -> %s by %s", tt.from, tt.to, tt.actor)
+	}
+}
+
+func TestTransitionPostStatus(t *testing.T) {
+	db, mock := newSearchTestDB(t)
+	postUtils := &PostUtils{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."post_id" = \$1 .+ FOR UPDATE`).
+		WithArgs(12, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "status"}).AddRow(12, 4, schema.Active))
+	mock.ExpectExec(`UPDATE "posts" SET "date_updated"=\$1,"status"=\$2 WHERE post_id = \$3`).
+		WithArgs(sqlmock.AnyArg(), schema.Closed, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "post_status_histories"`).
+		WithArgs(12, schema.Active, schema.Closed, schema.OwnerActor, 4, "sold", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"history_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	post, err := postUtils.TransitionPostStatus(12, schema.Closed, schema.OwnerActor, 4, "sold")
+	require.NoError(t, err)
+	assert.Equal(t, schema.Closed, post.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransitionPostStatusLeavesPublishAndRenewToTheirOwnPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		from schema.PostStatus
+		to   schema.PostStatus
+	}{
+		{"draft", schema.Draft, schema.Active},
+		{"draft closed", schema.Draft, schema.Closed},
+		{"expired reactivated", schema.Expired, schema.Active},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newSearchTestDB(t)
+			postUtils := &PostUtils{DB: db}
+
+			// the status is checked on the locked row and nothing is written
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."post_id" = \$1 .+ FOR UPDATE`).
+				WithArgs(12, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "status"}).AddRow(12, 4, tt.from))
+			mock.ExpectRollback()
+
+			_, err := postUtils.TransitionPostStatus(12, tt.to, schema.OwnerActor, 4, "")
+			assert.ErrorIs(t, err, ErrIllegalTransition)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
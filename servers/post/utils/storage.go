@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ImageStorage keeps post image files and knows where clients can fetch them
+type ImageStorage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Ensure both backends implement ImageStorage
+var _ ImageStorage = (*LocalImageStorage)(nil)
+var _ ImageStorage = (*S3ImageStorage)(nil)
+
+// NewImageStorageFromEnv picks the backend from POST_IMAGE_STORAGE, local disk unless set to "s3"
+func NewImageStorageFromEnv() ImageStorage {
+	if os.Getenv("POST_IMAGE_STORAGE") != "s3" {
+		return &LocalImageStorage{
+			Dir:     envString("POST_IMAGE_DIR", "media"),
+			BaseURL: envString("POST_IMAGE_URL", "/v1/post/media"),
+		}
+	}
+
+	storage, err := NewS3ImageStorage(S3Config{
+		Endpoint:  os.Getenv("POST_S3_ENDPOINT"),
+		Region:    os.Getenv("POST_S3_REGION"),
+		Bucket:    os.Getenv("POST_S3_BUCKET"),
+		AccessKey: os.Getenv("POST_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("POST_S3_SECRET_KEY"),
+		UseSSL:    os.Getenv("POST_S3_INSECURE") != "true",
+		BaseURL:   os.Getenv("POST_IMAGE_URL"),
+	})
+	if err != nil {
+		log.Fatalf("Error setting up S3 image storage: %v", err)
+	}
+	return storage
+}
+
+func envString(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// LocalImageStorage writes images under Dir, served by the post service itself at BaseURL
+type LocalImageStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+func (s *LocalImageStorage) Put(_ context.Context, key string, data []byte, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (s *LocalImageStorage) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalImageStorage) URL(key string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + key
+}
+
+// path keeps keys inside Dir
+func (s *LocalImageStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid image key %q", key)
+	}
+	return filepath.Join(s.Dir, clean), nil
+}
+
+// S3Config configures an S3 compatible bucket, such as AWS S3 or MinIO
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	BaseURL   string // public URL of the bucket, defaults to the endpoint's path-style URL
+}
+
+// S3ImageStorage stores images in an S3 compatible bucket that clients read from directly
+type S3ImageStorage struct {
+	Client  *minio.Client
+	Bucket  string
+	BaseURL string
+}
+
+func NewS3ImageStorage(config S3Config) (*S3ImageStorage, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("POST_S3_ENDPOINT and POST_S3_BUCKET are required")
+	}
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = client.EndpointURL().String() + "/" + config.Bucket
+	}
+
+	return &S3ImageStorage{Client: client, Bucket: config.Bucket, BaseURL: baseURL}, nil
+}
+
+func (s *S3ImageStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.Client.PutObject(ctx, s.Bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	return err
+}
+
+func (s *S3ImageStorage) Delete(ctx context.Context, key string) error {
+	return s.Client.RemoveObject(ctx, s.Bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3ImageStorage) URL(key string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + key
+}
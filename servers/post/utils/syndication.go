@@ -0,0 +1,410 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"post/schema"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// random bytes in a feed token, base64 encoded for the URL
+	feedTokenBytes = 32
+
+	// how long a handover lasts on the calendar
+	matchEventDuration = time.Hour
+
+	// iCalendar lines are folded after this many octets
+	icsLineOctets = 75
+
+	atomTimeLayout = time.RFC3339
+	icsTimeLayout  = "20060102T150405Z"
+)
+
+// SyndicationConfig holds the public addresses feed entries and feed URLs point at
+type SyndicationConfig struct {
+	PublicURL string // the web app, where a post's page lives
+	APIURL    string // the API gateway, where feed readers fetch the feeds
+}
+
+// SyndicationConfigFromEnv reads POST_PUBLIC_URL and POST_API_URL
+func SyndicationConfigFromEnv() SyndicationConfig {
+	config := SyndicationConfig{
+		PublicURL: os.Getenv("POST_PUBLIC_URL"),
+		APIURL:    os.Getenv("POST_API_URL"),
+	}
+	if config.PublicURL == "" {
+		config.PublicURL = "http://localhost:3000"
+	}
+	if config.APIURL == "" {
+		config.APIURL = "http://localhost:8080"
+	}
+	config.PublicURL = strings.TrimRight(config.PublicURL, "/")
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	return config
+}
+
+// PostURL is the web page of a post
+func (s SyndicationConfig) PostURL(postID uint) string {
+	return fmt.Sprintf("%s/post/%d", s.PublicURL, postID)
+}
+
+// FeedsURL is where the feeds a token unlocks live
+func (s SyndicationConfig) FeedsURL(token string) string {
+	return fmt.Sprintf("%s/v1/post/feeds/%s", s.APIURL, token)
+}
+
+// SyndicationLinks returns the addresses used in feeds
+func (pu *PostUtils) SyndicationLinks() SyndicationConfig {
+	return pu.Syndication
+}
+
+// hashFeedToken is how a feed token is stored and looked up
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateFeedToken makes a new feed token for the user, replacing the one they had so its feeds stop working
+func (pu *PostUtils) CreateFeedToken(userID uint) (string, error) {
+	raw := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	err := pu.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&schema.FeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&schema.FeedToken{UserID: userID, TokenHash: hashFeedToken(token), DateCreated: time.Now()}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RevokeFeedToken removes the user's feed token, their feeds stop working until they make a new one
+func (pu *PostUtils) RevokeFeedToken(userID uint) error {
+	return pu.DB.Where("user_id = ?", userID).Delete(&schema.FeedToken{}).Error
+}
+
+// GetFeedTokenUserID finds whose feeds a token unlocks, gorm.ErrRecordNotFound for an unknown token
+func (pu *PostUtils) GetFeedTokenUserID(token string) (uint, error) {
+	var feedToken schema.FeedToken
+	if err := pu.DB.Where("token_hash = ?", hashFeedToken(token)).First(&feedToken).Error; err != nil {
+		return 0, err
+	}
+	return feedToken.UserID, nil
+}
+
+// GetPostsByIDs retrieves posts by their IDs, keyed by ID. Posts that are gone are left out.
+func (pu *PostUtils) GetPostsByIDs(postIDs []uint) (map[uint]schema.Post, error) {
+	posts := map[uint]schema.Post{}
+	if len(postIDs) == 0 {
+		return posts, nil
+	}
+
+	var rows []schema.Post
+	if err := pu.DB.Where("post_id IN ?", postIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, post := range rows {
+		posts[post.PostID] = post
+	}
+	return posts, nil
+}
+
+// GetScheduledMatches asks the match service for the user's matches with an agreed time
+func (pu *PostUtils) GetScheduledMatches(userID uint) ([]schema.ScheduledMatch, error) {
+	matchServiceURL := os.Getenv("MATCH_SERVICE_URL") + fmt.Sprintf("/v1/internal/match/user/%d/scheduled", userID)
+	req, err := http.NewRequest("GET", matchServiceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduled schema.ScheduledMatchesResponse
+	if err := fetchInternalData(req, &scheduled); err != nil {
+		return nil, err
+	}
+	return scheduled.Matches, nil
+}
+
+// SyndicationFeed is a list of posts to render as Atom or RSS
+type SyndicationFeed struct {
+	Title   string
+	SelfURL string // where the feed itself is fetched
+	Posts   []schema.PostResponse
+	Updated time.Time // used when there are no posts to date the feed by
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string        `xml:"title"`
+	ID        string        `xml:"id"`
+	Updated   string        `xml:"updated"`
+	Published string        `xml:"published"`
+	Link      atomLink      `xml:"link"`
+	Author    atomAuthor    `xml:"author"`
+	Category  *atomCategory `xml:"category,omitempty"`
+	Summary   string        `xml:"summary"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Category    string  `xml:"category,omitempty"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// feedUpdated is when the newest post in the feed was posted
+func feedUpdated(feed SyndicationFeed) time.Time {
+	updated := feed.Updated
+	for i, post := range feed.Posts {
+		if i == 0 || post.DatePosted.After(updated) {
+			updated = post.DatePosted
+		}
+	}
+	return updated.UTC()
+}
+
+// postSummary is the plain text shown for a post in a feed reader
+func postSummary(post schema.PostResponse) string {
+	lines := []string{post.Description}
+	lines = append(lines, fmt.Sprintf("Type: %s", post.Type))
+	if post.Quantity > 0 {
+		lines = append(lines, fmt.Sprintf("Quantity: %d", post.Quantity))
+	}
+	if post.NeededBy != nil {
+		lines = append(lines, fmt.Sprintf("Needed by: %s", post.NeededBy.UTC().Format(time.RFC1123)))
+	}
+	if place := postPlace(post); place != "" {
+		lines = append(lines, fmt.Sprintf("Location: %s", place))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// postPlace names where a post's handover happens, empty when the post has no location
+func postPlace(post schema.PostResponse) string {
+	switch {
+	case post.Location == nil:
+		return ""
+	case post.Location.BuildingName != "":
+		return post.Location.BuildingName
+	case post.Location.Building != "":
+		return post.Location.Building
+	default:
+		return fmt.Sprintf("%.6f, %.6f", post.Location.Latitude, post.Location.Longitude)
+	}
+}
+
+// RenderAtom writes the feed as an Atom 1.0 document
+func (s SyndicationConfig) RenderAtom(feed SyndicationFeed) ([]byte, error) {
+	doc := atomFeed{
+		Title:   feed.Title,
+		ID:      feed.SelfURL,
+		Updated: feedUpdated(feed).Format(atomTimeLayout),
+		Links: []atomLink{
+			{Href: feed.SelfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: s.PublicURL, Rel: "alternate", Type: "text/html"},
+		},
+		Entries: []atomEntry{},
+	}
+
+	for _, post := range feed.Posts {
+		entry := atomEntry{
+			Title:     post.Title,
+			ID:        s.PostURL(post.PostID),
+			Updated:   post.DatePosted.UTC().Format(atomTimeLayout),
+			Published: post.DatePosted.UTC().Format(atomTimeLayout),
+			Link:      atomLink{Href: s.PostURL(post.PostID), Rel: "alternate", Type: "text/html"},
+			Author:    atomAuthor{Name: post.Username},
+			Summary:   postSummary(post),
+		}
+		if post.Category != "" {
+			entry.Category = &atomCategory{Term: post.Category}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshalFeed(doc)
+}
+
+// RenderRSS writes the feed as an RSS 2.0 document
+func (s SyndicationConfig) RenderRSS(feed SyndicationFeed) ([]byte, error) {
+	doc := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          s.PublicURL,
+			Description:   feed.Title,
+			LastBuildDate: feedUpdated(feed).Format(time.RFC1123Z),
+			Items:         []rssItem{},
+		},
+	}
+
+	for _, post := range feed.Posts {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       post.Title,
+			Link:        s.PostURL(post.PostID),
+			GUID:        rssGUID{IsPermaLink: true, Value: s.PostURL(post.PostID)},
+			PubDate:     post.DatePosted.UTC().Format(time.RFC1123Z),
+			Category:    post.Category,
+			Description: postSummary(post),
+		})
+	}
+
+	return marshalFeed(doc)
+}
+
+func marshalFeed(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// RenderMatchCalendar writes the user's scheduled matches as an iCalendar document, one hour long
+// event per handover. Posts holds the matched posts by ID, a match whose post is gone keeps a
+// generic title.
+func (s SyndicationConfig) RenderMatchCalendar(userID uint, matches []schema.ScheduledMatch, posts map[uint]schema.PostResponse, now time.Time) []byte {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeICSLine(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//GiveGetGo//Matches//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", "GiveGetGo matches")
+
+	for _, match := range matches {
+		post, found := posts[match.PostID]
+
+		summary := "GiveGetGo handover"
+		if found {
+			summary = post.Title
+		}
+
+		description := []string{}
+		if found && post.Description != "" {
+			description = append(description, post.Description)
+		}
+		if match.HelperUserID == userID {
+			description = append(description, "You are giving.")
+		} else {
+			description = append(description, "You are receiving.")
+		}
+		if match.FulfillmentDetails != "" {
+			description = append(description, "Details: "+match.FulfillmentDetails)
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", fmt.Sprintf("match-%d@givegetgo", match.MatchID))
+		line("DTSTAMP", now.UTC().Format(icsTimeLayout))
+		line("DTSTART", match.AgreedTime.UTC().Format(icsTimeLayout))
+		line("DTEND", match.AgreedTime.Add(matchEventDuration).UTC().Format(icsTimeLayout))
+		line("SUMMARY", escapeICSText(summary))
+		line("DESCRIPTION", escapeICSText(strings.Join(description, "\n")))
+		if found {
+			if place := postPlace(post); place != "" {
+				line("LOCATION", escapeICSText(place))
+				line("GEO", fmt.Sprintf("%.6f;%.6f", post.Location.Latitude, post.Location.Longitude))
+			}
+		}
+		line("URL", s.PostURL(match.PostID))
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// escapeICSText escapes a TEXT value, RFC 5545 section 3.3.11
+func escapeICSText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(value)
+}
+
+// writeICSLine writes a content line ended by CRLF, folded so no line is longer than 75 octets.
+// Folds never split a UTF-8 character.
+func writeICSLine(b *strings.Builder, content string) {
+	limit := icsLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		// the leading space of a continuation line counts towards its length
+		limit = icsLineOctets - 1
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"encoding/xml"
+	"post/schema"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSyndication = SyndicationConfig{PublicURL: "https://givegetgo.test", APIURL: "https://api.givegetgo.test"}
+
+func testFeed() SyndicationFeed {
+	posted := time.Date(2024, time.March, 4, 15, 30, 0, 0, time.UTC)
+	return SyndicationFeed{
+		Title:   "GiveGetGo: recent posts",
+		SelfURL: "https://api.givegetgo.test/v1/post/feeds/abc/recent",
+		Posts: []schema.PostResponse{
+			{
+				PostID:      7,
+				Title:       "Desk lamp & bulbs",
+				Description: "Works fine <3",
+				Category:    "furniture",
+				Type:        schema.OfferPost,
+				Username:    "sam",
+				DatePosted:  posted,
+				Location:    &schema.PostLocation{Latitude: 40.4237, Longitude: -86.9212, Building: "pmu", BuildingName: "Purdue Memorial Union"},
+			},
+			{
+				PostID:     5,
+				Title:      "Calculator",
+				Type:       schema.RequestPost,
+				Username:   "ari",
+				DatePosted: posted.Add(-time.Hour),
+			},
+		},
+	}
+}
+
+func TestRenderAtom(t *testing.T) {
+	body, err := testSyndication.RenderAtom(testFeed())
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), xml.Header))
+
+	var doc atomFeed
+	assert.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, "GiveGetGo: recent posts", doc.Title)
+	assert.Equal(t, "2024-03-04T15:30:00Z", doc.Updated)
+	assert.Len(t, doc.Entries, 2)
+
+	entry := doc.Entries[0]
+	assert.Equal(t, "Desk lamp & bulbs", entry.Title)
+	assert.Equal(t, "https://givegetgo.test/post/7", entry.ID)
+	assert.Equal(t, "https://givegetgo.test/post/7", entry.Link.Href)
+	assert.Equal(t, "sam", entry.Author.Name)
+	assert.Equal(t, "furniture", entry.Category.Term)
+	assert.Contains(t, entry.Summary, "Works fine <3")
+	assert.Contains(t, entry.Summary, "Location: Purdue Memorial Union")
+
+	// an uncategorized post has no category element
+	assert.Nil(t, doc.Entries[1].Category)
+}
+
+func TestRenderRSS(t *testing.T) {
+	body, err := testSyndication.RenderRSS(testFeed())
+	assert.NoError(t, err)
+
+	var doc rssFeed
+	assert.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "Mon, 04 Mar 2024 15:30:00 +0000", doc.Channel.LastBuildDate)
+	assert.Len(t, doc.Channel.Items, 2)
+	assert.Equal(t, "https://givegetgo.test/post/5", doc.Channel.Items[1].GUID.Value)
+	assert.True(t, doc.Channel.Items[1].GUID.IsPermaLink)
+}
+
+func TestRenderEmptyFeed(t *testing.T) {
+	updated := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	body, err := testSyndication.RenderAtom(SyndicationFeed{Title: "empty", SelfURL: "https://api.givegetgo.test/feed", Updated: updated})
+	assert.NoError(t, err)
+
+	var doc atomFeed
+	assert.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, "2024-03-05T00:00:00Z", doc.Updated)
+	assert.Empty(t, doc.Entries)
+}
+
+func TestEscapeICSText(t *testing.T) {
+	assert.Equal(t, `a\, b\; c\\d\ne`, escapeICSText("a, b; c\\d\r\ne"))
+}
+
+func TestWriteICSLineFolds(t *testing.T) {
+	var b strings.Builder
+	writeICSLine(&b, "DESCRIPTION:"+strings.Repeat("é", 80))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	unfolded := lines[0]
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), 75)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+			unfolded += line[1:]
+		}
+	}
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("é", 80), unfolded)
+}
+
+func TestRenderMatchCalendar(t *testing.T) {
+	agreed := time.Date(2024, time.March, 6, 17, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	now := time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)
+	matches := []schema.ScheduledMatch{
+		{MatchID: 3, PostID: 7, HelperUserID: 2, RecipientUserID: 9, AgreedTime: agreed, FulfillmentDetails: "Side door, 5pm"},
+		{MatchID: 4, PostID: 8, HelperUserID: 9, RecipientUserID: 2, AgreedTime: agreed.Add(24 * time.Hour)},
+	}
+	posts := map[uint]schema.PostResponse{7: testFeed().Posts[0]}
+
+	calendar := string(testSyndication.RenderMatchCalendar(9, matches, posts, now))
+
+	assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(calendar, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(calendar, "BEGIN:VEVENT"))
+
+	assert.Contains(t, calendar, "UID:match-3@givegetgo\r\n")
+	assert.Contains(t, calendar, "DTSTAMP:20240305T120000Z\r\n")
+	assert.Contains(t, calendar, "DTSTART:20240306T220000Z\r\n")
+	assert.Contains(t, calendar, "DTEND:20240306T230000Z\r\n")
+	assert.Contains(t, calendar, "SUMMARY:Desk lamp & bulbs\r\n")
+	assert.Contains(t, calendar, "DESCRIPTION:Works fine <3\\nYou are receiving.\\nDetails: Side door\\, 5pm\r\n")
+	assert.Contains(t, calendar, "LOCATION:Purdue Memorial Union\r\n")
+	assert.Contains(t, calendar, "GEO:40.423700;-86.921200\r\n")
+	assert.Contains(t, calendar, "URL:https://givegetgo.test/post/7\r\n")
+
+	// the second match's post is gone, it keeps a generic title and no location
+	assert.Contains(t, calendar, "SUMMARY:GiveGetGo handover\r\nDESCRIPTION:You are giving.\r\nURL:https://givegetgo.test/post/8\r\n")
+}
+
+func TestHashFeedToken(t *testing.T) {
+	hash := hashFeedToken("token")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, hashFeedToken("token"))
+	assert.NotEqual(t, hash, hashFeedToken("token2"))
+}
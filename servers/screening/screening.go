@@ -0,0 +1,306 @@
+// Package screening checks user text before it is stored. It normalizes markup away, enforces length
+// limits and finds banned words, configured patterns, contact details and payment links, each handled
+// by its policy. It's a module of its own in the workspace so every service that stores user text
+// screens it the same way.
+package screening
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MatchType is the kind of content a rule found
+type MatchType string
+
+const (
+	BannedWord  MatchType = "banned_word"
+	Pattern     MatchType = "pattern"
+	Phone       MatchType = "phone"
+	Email       MatchType = "email"
+	PaymentLink MatchType = "payment_link"
+)
+
+// Action is what happens to text with a match
+type Action string
+
+const (
+	Allow Action = "allow"
+	Mask  Action = "mask"  // replace the match and store the rest
+	Flag  Action = "flag"  // store the text and ask a moderator to look at it
+	Block Action = "block" // refuse the text
+)
+
+// MaskText replaces masked matches
+const MaskText = "[hidden]"
+
+var (
+	ErrTooShort = errors.New("text is too short")
+	ErrTooLong  = errors.New("text is too long")
+	ErrBlocked  = errors.New("text contains content that isn't allowed")
+)
+
+// DefaultPolicy applies to match types the rules don't mention
+var DefaultPolicy = map[MatchType]Action{
+	BannedWord:  Block,
+	Pattern:     Flag,
+	Phone:       Mask,
+	Email:       Mask,
+	PaymentLink: Block,
+}
+
+var (
+	phonePattern = regexp.MustCompile(`(?:\+?1[\s.-]?)?(?:\(\d{3}\)|\b\d{3})[\s.-]?\d{3}[\s.-]?\d{4}\b`)
+	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)
+	// payment apps, with or without a scheme, and cash app $cashtags
+	paymentPattern = regexp.MustCompile(`(?i)(?:\bhttps?://)?(?:www\.)?\b(?:venmo\.com|paypal\.me|paypal\.com|cash\.app|cash\.me|zellepay\.com|ko-fi\.com|buymeacoffee\.com)(?:/\S*)?|(?:^|\s)\$[a-z][a-z0-9_-]{1,19}\b`)
+
+	// markup is stripped rather than escaped, the stored text is plain text
+	blockTagPattern = regexp.MustCompile(`(?is)<\s*(script|style|iframe|object|embed|template)\b.*?(?:<\s*/\s*(?:script|style|iframe|object|embed|template)\s*>|$)`)
+	tagPattern      = regexp.MustCompile(`(?s)<\s*[a-zA-Z/!?][^>]*(?:>|$)`)
+	spacePattern    = regexp.MustCompile(`[ \t\f\v\p{Zs}]+`)
+	newlinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// Limit is the allowed length of a field in characters, counted after normalization
+type Limit struct {
+	Min int
+	Max int
+}
+
+// Rules are the configurable part of screening
+type Rules struct {
+	BannedWords []string             `json:"banned_words"`
+	Patterns    []string             `json:"patterns"`
+	Policy      map[MatchType]Action `json:"policy"`
+}
+
+// Match is one piece of text a rule found
+type Match struct {
+	Type   MatchType
+	Action Action
+	Text   string
+	start  int
+	end    int
+}
+
+// Result is the screened text and what was found in it
+type Result struct {
+	Text    string // normalized, with masked matches replaced
+	Matches []Match
+	Flagged bool // a match asks for a moderator to review the text
+}
+
+// Types lists the distinct types of the matches handled by the action, in the order they were found
+func (r Result) Types(action Action) []MatchType {
+	var types []MatchType
+	seen := map[MatchType]bool{}
+	for _, match := range r.Matches {
+		if match.Action == action && !seen[match.Type] {
+			seen[match.Type] = true
+			types = append(types, match.Type)
+		}
+	}
+	return types
+}
+
+type detector struct {
+	matchType MatchType
+	pattern   *regexp.Regexp
+}
+
+// Screener applies a set of rules to text
+type Screener struct {
+	policy    map[MatchType]Action
+	detectors []detector
+}
+
+// New compiles the rules into a screener
+func New(rules Rules) (*Screener, error) {
+	screener := &Screener{policy: map[MatchType]Action{}}
+	for matchType, action := range DefaultPolicy {
+		screener.policy[matchType] = action
+	}
+	for matchType, action := range rules.Policy {
+		switch action {
+		case Allow, Mask, Flag, Block:
+			screener.policy[matchType] = action
+		default:
+			return nil, fmt.Errorf("unknown screening action %q for %s", action, matchType)
+		}
+	}
+
+	var words []string
+	for _, word := range rules.BannedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+	}
+	if len(words) > 0 {
+		screener.detectors = append(screener.detectors, detector{BannedWord, regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b`)})
+	}
+
+	for _, expr := range rules.Patterns {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid screening pattern %q: %w", expr, err)
+		}
+		screener.detectors = append(screener.detectors, detector{Pattern, pattern})
+	}
+
+	screener.detectors = append(screener.detectors,
+		detector{PaymentLink, paymentPattern},
+		detector{Email, emailPattern},
+		detector{Phone, phonePattern},
+	)
+
+	return screener, nil
+}
+
+// NewFromEnv builds a screener from the JSON rules file in SCREENING_RULES, or the default policy without one
+func NewFromEnv() *Screener {
+	var rules Rules
+	if path := os.Getenv("SCREENING_RULES"); path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &rules)
+		}
+		if err != nil {
+			log.Fatalf("Error loading screening rules from %s: %v", path, err)
+		}
+	}
+
+	screener, err := New(rules)
+	if err != nil {
+		log.Fatalf("Error compiling screening rules: %v", err)
+	}
+	return screener
+}
+
+// Screen normalizes the text, checks its length and applies the rules. Blocked text returns ErrBlocked
+// along with the result, so the caller can tell what was found.
+func (s *Screener) Screen(text string, limit Limit) (Result, error) {
+	result := Result{Text: Normalize(text)}
+
+	length := utf8.RuneCountInString(result.Text)
+	if length < limit.Min {
+		return result, ErrTooShort
+	}
+	if limit.Max > 0 && length > limit.Max {
+		return result, ErrTooLong
+	}
+
+	for _, detector := range s.detectors {
+		action := s.policy[detector.matchType]
+		if action == Allow {
+			continue
+		}
+		for _, loc := range detector.pattern.FindAllStringIndex(result.Text, -1) {
+			// the cashtag alternative may start at the whitespace before the $
+			start := loc[0]
+			for start < loc[1] && unicode.IsSpace(rune(result.Text[start])) {
+				start++
+			}
+			result.Matches = append(result.Matches, Match{
+				Type:   detector.matchType,
+				Action: action,
+				Text:   result.Text[start:loc[1]],
+				start:  start,
+				end:    loc[1],
+			})
+		}
+	}
+
+	for _, match := range result.Matches {
+		switch match.Action {
+		case Block:
+			return result, fmt.Errorf("%w: %s", ErrBlocked, match.Type)
+		case Flag:
+			result.Flagged = true
+		}
+	}
+
+	result.Text = mask(result.Text, result.Matches)
+	return result, nil
+}
+
+// mask replaces the masked matches, earlier rules win where matches overlap
+func mask(text string, matches []Match) string {
+	var masked []Match
+	for _, match := range matches {
+		if match.Action != Mask {
+			continue
+		}
+		overlaps := false
+		for _, other := range masked {
+			if match.start < other.end && other.start < match.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			masked = append(masked, match)
+		}
+	}
+	if len(masked) == 0 {
+		return text
+	}
+
+	sort.Slice(masked, func(i, j int) bool { return masked[i].start < masked[j].start })
+	var builder strings.Builder
+	last := 0
+	for _, match := range masked {
+		builder.WriteString(text[last:match.start])
+		builder.WriteString(MaskText)
+		last = match.end
+	}
+	builder.WriteString(text[last:])
+	return builder.String()
+}
+
+// Normalize turns user input into plain text: markup and scripts are removed, entities decoded,
+// invisible characters dropped and whitespace tidied
+func Normalize(text string) string {
+	text = strings.ToValidUTF8(text, "")
+
+	// decoding can reveal markup that was escaped, possibly more than once
+	for i := 0; i < 5; i++ {
+		text = tagPattern.ReplaceAllString(blockTagPattern.ReplaceAllString(text, ""), "")
+		decoded := html.UnescapeString(text)
+		if decoded == text {
+			break
+		}
+		text = decoded
+	}
+	text = tagPattern.ReplaceAllString(blockTagPattern.ReplaceAllString(text, ""), "")
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\r':
+			return '\n'
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			// control and format characters, including the zero width ones used to dodge filters
+			return -1
+		}
+		return r
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spacePattern.ReplaceAllString(line, " "))
+	}
+	text = newlinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,80 @@
+package screening
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain text is kept", "Need a TI-84 for MA 161", "Need a TI-84 for MA 161"},
+		{"tags are stripped", "<b>Free</b> <a href=\"x\">couch</a>", "Free couch"},
+		{"scripts are dropped with their content", "hi<script>alert(1)</script> there", "hi there"},
+		{"unclosed script drops the rest", "hi <script>alert(1)", "hi"},
+		{"escaped markup is stripped too", "&lt;img src=x onerror=alert(1)&gt;lamp &amp;lt;b&amp;gt;", "lamp"},
+		{"comparisons survive", "3 < 5 &amp; 7 > 2", "3 < 5 & 7 > 2"},
+		{"zero width characters are removed", "sc\u200bam", "scam"},
+		{"whitespace is tidied", "  one \t two  \r\n\n\n\n three  ", "one two\n\nthree"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.in))
+		})
+	}
+}
+
+func TestScreen(t *testing.T) {
+	screener, err := New(Rules{
+		BannedWords: []string{"scam", "fake id"},
+		Patterns:    []string{`(?i)\bgift ?cards?\b`},
+		Policy:      map[MatchType]Action{Email: Flag},
+	})
+	require.NoError(t, err)
+	limit := Limit{Min: 1, Max: 60}
+
+	result, err := screener.Screen("Call me at (765) 555-0134 or +1 765.555.0199", limit)
+	require.NoError(t, err)
+	assert.Equal(t, "Call me at [hidden] or [hidden]", result.Text)
+	assert.False(t, result.Flagged)
+	assert.Equal(t, []MatchType{Phone}, result.Types(Mask))
+
+	result, err = screener.Screen("write to boiler@purdue.edu", limit)
+	require.NoError(t, err)
+	assert.Equal(t, "write to boiler@purdue.edu", result.Text)
+	assert.True(t, result.Flagged)
+
+	result, err = screener.Screen("paying in Gift Cards only", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Flagged)
+	assert.Equal(t, []MatchType{Pattern}, result.Types(Flag))
+
+	for _, text := range []string{"pay me at venmo.com/u/boiler", "send it to $boilerup", "https://www.paypal.me/boiler", "not a SCAM", "selling a fake ID"} {
+		_, err := screener.Screen(text, limit)
+		assert.ErrorIs(t, err, ErrBlocked, text)
+	}
+
+	// prices, dates and words containing a banned word are fine
+	result, err = screener.Screen("$20 on 2024-05-01, no scammers", limit)
+	require.NoError(t, err)
+	assert.Empty(t, result.Matches)
+
+	_, err = screener.Screen("<p> </p>", limit)
+	assert.ErrorIs(t, err, ErrTooShort)
+	_, err = screener.Screen("ünïcödé counts characters, not bytes: ääääääääääääääääääää", limit)
+	assert.NoError(t, err)
+	_, err = screener.Screen("this description goes on for quite a bit more than sixty characters", limit)
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func TestNewRejectsBadRules(t *testing.T) {
+	_, err := New(Rules{Patterns: []string{"("}})
+	assert.Error(t, err)
+
+	_, err = New(Rules{Policy: map[MatchType]Action{Phone: "shred"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testIP        = "203.0.113.7"
+	testUserAgent = "Mozilla/5.0 (test)"
+)
+
+// serve runs a handler behind a session store, signed in as userID unless it's 0,
+// and returns the recorded response
+func serve(handler gin.HandlerFunc, userID uint, method, target, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(sessions.Sessions("givegetgo", cookie.NewStore([]byte("test-secret"))))
+	r.Handle(method, "/test", func(c *gin.Context) {
+		if userID != 0 {
+			sessions.Default(c).Set("userid", userID)
+		}
+		handler(c)
+	})
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", testUserAgent)
+	req.RemoteAddr = testIP + ":51234"
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -6,12 +6,14 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"user/schema"
 	"user/utils"
 
 	"github.com/GiveGetGo/shared/res"
 	"github.com/GiveGetGo/shared/types"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/pquerna/otp/totp"
 	"gorm.io/gorm"
 )
@@ -73,6 +75,7 @@ func VerifyMFAHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 		// Validate the TOTP code.
 		isValid := totp.Validate(req.VerificationCode, string(decryptedSecret))
 		if !isValid {
+			recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.MFAFailureSecurityEvent, "totp")
 			res.ResponseError(c, http.StatusBadRequest, types.InvalidVerification())
 			return
 		}
@@ -84,6 +87,7 @@ func VerifyMFAHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 			return
 		}
 
+		recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.MFAVerifiedSecurityEvent, "totp")
 		res.ResponseSuccess(c, http.StatusOK, "verify-mfa", types.Success())
 	}
 }
@@ -160,7 +164,73 @@ func GetMFAHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 				return
 			}
 
+			recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.MFAEnrolledSecurityEvent, "totp")
 			userUtils.GenerateAndSendQRCode(c, user.Email, []byte(secret.Secret()))
 		}
 	}
 }
+
+// BeginWebAuthnMFAHandler returns the options for passing the MFA gate with one of the user's passkeys
+func BeginWebAuthnMFAHandler(userUtils utils.IUserUtils, w *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webAuthnUser, ok := getWebAuthnUser(c, userUtils)
+		if !ok {
+			return
+		}
+
+		if len(webAuthnUser.Credentials) == 0 {
+			res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			return
+		}
+
+		assertion, sessionData, err := w.BeginLogin(webAuthnUser)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		if err := saveWebAuthnSession(c, webAuthnMFA, sessionData); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "verify-mfa-webauthn-begin", types.Success(), assertion)
+	}
+}
+
+// VerifyWebAuthnMFAHandler accepts a passkey assertion in place of a TOTP code
+func VerifyWebAuthnMFAHandler(userUtils utils.IUserUtils, w *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webAuthnUser, ok := getWebAuthnUser(c, userUtils)
+		if !ok {
+			return
+		}
+
+		sessionData, err := loadWebAuthnSession(c, webAuthnMFA)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidSession())
+			return
+		}
+
+		credential, err := w.FinishLogin(webAuthnUser, sessionData, c.Request)
+		if err != nil {
+			recordSecurityEvent(c, userUtils, webAuthnUser.User.UserID, webAuthnUser.User.Email, schema.MFAFailureSecurityEvent, "webauthn")
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidVerification())
+			return
+		}
+
+		if !updateSignCount(c, userUtils, webAuthnUser, credential) {
+			return
+		}
+
+		// Mark the user as MFA verified.
+		err = userUtils.MarkMFAVerified(webAuthnUser.User.UserID)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		recordSecurityEvent(c, userUtils, webAuthnUser.User.UserID, webAuthnUser.User.Email, schema.MFAVerifiedSecurityEvent, "webauthn")
+		res.ResponseSuccess(c, http.StatusOK, "verify-mfa-webauthn", types.Success())
+	}
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"user/schema"
+	"user/utils"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// session keys holding the state of an in-flight SSO login
+const (
+	oidcStateKey    = "oidc_state"
+	oidcNonceKey    = "oidc_nonce"
+	oidcVerifierKey = "oidc_verifier"
+	oidcModeKey     = "oidc_mode"
+
+	oidcModeLogin = "login"
+	oidcModeLink  = "link"
+)
+
+// SSOLoginHandler starts the campus SSO login by redirecting to the provider
+func SSOLoginHandler(oidcUtils utils.IOIDCUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startSSO(c, oidcUtils, oidcModeLogin)
+	}
+}
+
+// SSOLinkHandler starts the campus SSO flow to link an identity to the logged in user
+func SSOLinkHandler(oidcUtils utils.IOIDCUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startSSO(c, oidcUtils, oidcModeLink)
+	}
+}
+
+// SSOCallbackHandler finishes the campus SSO flow, logging the user in or linking the identity
+func SSOCallbackHandler(userUtils utils.IUserUtils, oidcUtils utils.IOIDCUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		expectedState, _ := session.Get(oidcStateKey).(string)
+		nonce, _ := session.Get(oidcNonceKey).(string)
+		verifier, _ := session.Get(oidcVerifierKey).(string)
+		mode, _ := session.Get(oidcModeKey).(string)
+
+		// the state can only be used once
+		session.Delete(oidcStateKey)
+		session.Delete(oidcNonceKey)
+		session.Delete(oidcVerifierKey)
+		session.Delete(oidcModeKey)
+		if err := session.Save(); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		state := c.Query("state")
+		if expectedState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expectedState)) != 1 {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidSession())
+			return
+		}
+
+		if c.Query("error") != "" {
+			log.Printf("sso provider returned an error: %s", c.Query("error"))
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		code := c.Query("code")
+		if code == "" {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		claims, err := oidcUtils.Exchange(c.Request.Context(), code, verifier, nonce)
+		if err != nil {
+			log.Printf("sso exchange failed: %v", err)
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		email := strings.ToLower(claims.Email)
+		matched, _ := regexp.MatchString(`^[a-zA-Z0-9]+@purdue\.edu$`, email)
+		if !matched {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidEmail())
+			return
+		}
+
+		// identity already known - it either belongs to this account or to someone else
+		linkedUser, err := userUtils.GetUserByIdentity(claims.Issuer, claims.Subject)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+		identityLinked := err == nil
+
+		if mode == oidcModeLink {
+			userId, ok := session.Get("userid").(uint)
+			if !ok {
+				res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+				return
+			}
+
+			if identityLinked {
+				if linkedUser.UserID != userId {
+					res.ResponseError(c, http.StatusBadRequest, types.AlreadyExists())
+					return
+				}
+				res.ResponseSuccess(c, http.StatusOK, "sso-link", types.Success())
+				return
+			}
+
+			if err := userUtils.LinkIdentity(userId, claims.Issuer, claims.Subject, email); err != nil {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				return
+			}
+
+			recordSecurityEvent(c, userUtils, userId, email, schema.SSOLinkedSecurityEvent, "sso")
+
+			res.ResponseSuccess(c, http.StatusOK, "sso-link", types.Success())
+			return
+		}
+
+		user := linkedUser
+		if !identityLinked {
+			user, err = userUtils.GetUserByEmail(email)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				return
+			}
+
+			if err == nil {
+				// a password account must prove ownership by logging in and linking first
+				if user.HashedPassword != "" {
+					res.ResponseError(c, http.StatusBadRequest, types.AlreadyExists())
+					return
+				}
+			} else {
+				user, err = userUtils.CreateSSOUser(strings.Split(email, "@")[0], email)
+				if err != nil {
+					res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+					return
+				}
+				recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.RegisterSecurityEvent, "sso")
+			}
+
+			if err := userUtils.LinkIdentity(user.UserID, claims.Issuer, claims.Subject, email); err != nil {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				return
+			}
+		}
+
+		if rejectSuspended(c, userUtils, user, "sso") {
+			return
+		}
+
+		// set session
+		session.Set("userid", user.UserID)
+		if err := session.Save(); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		recordLogin(c, userUtils, user, "sso")
+		res.ResponseSuccess(c, http.StatusOK, "sso-login", types.LoginSuccess())
+	}
+}
+
+// startSSO stores the state, nonce and PKCE verifier in the session and redirects to the provider
+func startSSO(c *gin.Context, oidcUtils utils.IOIDCUtils, mode string) {
+	if !oidcUtils.Enabled() {
+		res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+		return
+	}
+
+	state, err := utils.GenerateRandomString(32)
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	nonce, err := utils.GenerateRandomString(32)
+	if err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := oidcUtils.AuthCodeURL(state, nonce, verifier)
+	if err != nil {
+		log.Printf("sso unavailable: %v", err)
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	session := sessions.Default(c)
+	session.Set(oidcStateKey, state)
+	session.Set(oidcNonceKey, nonce)
+	session.Set(oidcVerifierKey, verifier)
+	session.Set(oidcModeKey, mode)
+	if err := session.Save(); err != nil {
+		res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
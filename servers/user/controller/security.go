@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"user/schema"
+	"user/utils"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSecurityEventLimit = 50
+	maxSecurityEventLimit     = 200
+
+	// how stale LastActiveDate may get before a request refreshes it
+	lastActiveRefreshInterval = 5 * time.Minute
+)
+
+// GetSecurityEventsHandler returns the caller's own security history
+func GetSecurityEventsHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, ok := sessions.Default(c).Get("userid").(uint)
+		if !ok {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		filter, ok := parseSecurityEventFilter(c)
+		if !ok {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+		filter.UserID = userId
+
+		events, err := userUtils.GetSecurityEvents(filter)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get security events", types.Success(), toSecurityEventResponses(events))
+	}
+}
+
+// AdminGetSecurityEventsHandler queries the security log across users, admins only
+func AdminGetSecurityEventsHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, ok := sessions.Default(c).Get("userid").(uint)
+		if !ok {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		caller, err := userUtils.GetUserByID(userId)
+		if err != nil {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		if caller.Role != schema.AdminRole {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+
+		filter, ok := parseSecurityEventFilter(c)
+		if !ok {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		if userIDParam, ok := c.GetQuery("user_id"); ok {
+			queryUserID, err := strconv.ParseUint(userIDParam, 10, 32)
+			if err != nil {
+				res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+				return
+			}
+			filter.UserID = uint(queryUserID)
+		}
+
+		events, err := userUtils.GetSecurityEvents(filter)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "admin get security events", types.Success(), toSecurityEventResponses(events))
+	}
+}
+
+// parseSecurityEventFilter reads the event, since, until, before and limit query parameters
+func parseSecurityEventFilter(c *gin.Context) (schema.SecurityEventFilter, bool) {
+	filter := schema.SecurityEventFilter{
+		EventType: schema.SecurityEventType(c.Query("event")),
+		Limit:     defaultSecurityEventLimit,
+	}
+
+	if limitParam, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 {
+			return filter, false
+		}
+		filter.Limit = min(limit, maxSecurityEventLimit)
+	}
+
+	if beforeParam, ok := c.GetQuery("before"); ok {
+		before, err := strconv.ParseUint(beforeParam, 10, 32)
+		if err != nil {
+			return filter, false
+		}
+		filter.BeforeID = uint(before)
+	}
+
+	for param, dest := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value, ok := c.GetQuery(param); ok {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, false
+			}
+			*dest = parsed
+		}
+	}
+
+	return filter, true
+}
+
+func toSecurityEventResponses(events []schema.SecurityEvent) []schema.SecurityEventResponse {
+	responseEvents := []schema.SecurityEventResponse{}
+	for _, event := range events {
+		responseEvents = append(responseEvents, schema.SecurityEventResponse{
+			EventID:     event.EventID,
+			UserID:      event.UserID,
+			Email:       event.Email,
+			EventType:   event.EventType,
+			Method:      event.Method,
+			IPAddress:   event.IPAddress,
+			UserAgent:   event.UserAgent,
+			CreatedDate: event.CreatedDate,
+		})
+	}
+	return responseEvents
+}
+
+// recordSecurityEvent appends to the security log with the request's IP and user agent,
+// a failure to record is logged but doesn't fail the request
+func recordSecurityEvent(c *gin.Context, userUtils utils.IUserUtils, userID uint, email string, eventType schema.SecurityEventType, method string) {
+	err := userUtils.RecordSecurityEvent(schema.SecurityEvent{
+		UserID:      userID,
+		Email:       email,
+		EventType:   eventType,
+		Method:      method,
+		IPAddress:   c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		CreatedDate: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error recording security event %s for user %d: %v", eventType, userID, err)
+	}
+}
+
+// recordLogin records a successful login and refreshes the user's last active date
+func recordLogin(c *gin.Context, userUtils utils.IUserUtils, user schema.User, method string) {
+	recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.LoginSuccessSecurityEvent, method)
+	if err := userUtils.TouchLastActive(user.UserID); err != nil {
+		log.Printf("Error updating last active date for user %d: %v", user.UserID, err)
+	}
+}
+
+// rejectSuspended refuses to sign in a suspended user, writing the error response
+func rejectSuspended(c *gin.Context, userUtils utils.IUserUtils, user schema.User, method string) bool {
+	if !user.Suspended {
+		return false
+	}
+
+	recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.LoginFailureSecurityEvent, method)
+	res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+	return true
+}
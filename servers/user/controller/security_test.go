@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"user/schema"
+	"user/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLoginRecordsSecurityEvent(t *testing.T) {
+	user := schema.User{UserID: 4, Email: "sam@purdue.edu", EmailVerified: true}
+	body := `{"email": "sam@purdue.edu", "password": "Secret-123"}`
+
+	// the event carries who, what, and where the request came from
+	expectEvent := func(userUtils *utils.MockIUserUtils, userID uint, eventType schema.SecurityEventType) {
+		userUtils.EXPECT().RecordSecurityEvent(gomock.Any()).DoAndReturn(func(event schema.SecurityEvent) error {
+			assert.Equal(t, userID, event.UserID)
+			assert.Equal(t, "sam@purdue.edu", event.Email)
+			assert.Equal(t, eventType, event.EventType)
+			assert.Equal(t, "password", event.Method)
+			assert.Equal(t, testIP, event.IPAddress)
+			assert.Equal(t, testUserAgent, event.UserAgent)
+			assert.False(t, event.CreatedDate.IsZero())
+			return nil
+		})
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+		userUtils.EXPECT().GetUserByEmail("sam@purdue.edu").Return(user, nil)
+		userUtils.EXPECT().AuthenticateUser(user, "Secret-123").Return(true)
+		expectEvent(userUtils, 4, schema.LoginSuccessSecurityEvent)
+		userUtils.EXPECT().TouchLastActive(uint(4)).Return(nil)
+
+		w := serve(LoginHandler(userUtils), 0, http.MethodPost, "/test", body)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+		userUtils.EXPECT().GetUserByEmail("sam@purdue.edu").Return(user, nil)
+		userUtils.EXPECT().AuthenticateUser(user, "Secret-123").Return(false)
+		expectEvent(userUtils, 4, schema.LoginFailureSecurityEvent)
+
+		w := serve(LoginHandler(userUtils), 0, http.MethodPost, "/test", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+		userUtils.EXPECT().GetUserByEmail("sam@purdue.edu").Return(schema.User{}, gorm.ErrRecordNotFound)
+		expectEvent(userUtils, 0, schema.LoginFailureSecurityEvent)
+
+		w := serve(LoginHandler(userUtils), 0, http.MethodPost, "/test", body)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetSecurityEventsHandler(t *testing.T) {
+	t.Run("own history", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+		// a user_id in the query doesn't reach someone else's history
+		userUtils.EXPECT().GetSecurityEvents(gomock.Any()).DoAndReturn(func(filter schema.SecurityEventFilter) ([]schema.SecurityEvent, error) {
+			assert.Equal(t, uint(4), filter.UserID)
+			assert.Equal(t, schema.LoginFailureSecurityEvent, filter.EventType)
+			return []schema.SecurityEvent{{EventID: 1, UserID: 4, EventType: schema.LoginFailureSecurityEvent}}, nil
+		})
+
+		w := serve(GetSecurityEventsHandler(userUtils), 4, http.MethodGet, "/test?event=login_failure&user_id=9", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("signed out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+
+		w := serve(GetSecurityEventsHandler(userUtils), 0, http.MethodGet, "/test", "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAdminGetSecurityEventsHandler(t *testing.T) {
+	t.Run("admin queries another user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+		userUtils.EXPECT().GetUserByID(uint(1)).Return(schema.User{UserID: 1, Role: schema.AdminRole}, nil)
+		userUtils.EXPECT().GetSecurityEvents(gomock.Any()).DoAndReturn(func(filter schema.SecurityEventFilter) ([]schema.SecurityEvent, error) {
+			assert.Equal(t, uint(9), filter.UserID)
+			return nil, nil
+		})
+
+		w := serve(AdminGetSecurityEventsHandler(userUtils), 1, http.MethodGet, "/test?user_id=9", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("member is forbidden", func(t *testing.T) {
+		// the mock fails on an unexpected GetSecurityEvents
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+		userUtils.EXPECT().GetUserByID(uint(4)).Return(schema.User{UserID: 4, Role: schema.MemberRole}, nil)
+
+		w := serve(AdminGetSecurityEventsHandler(userUtils), 4, http.MethodGet, "/test?user_id=9", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 	"user/schema"
 	"user/utils"
 
@@ -76,6 +78,11 @@ func RegisterHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 			return
 		}
 
+		recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.RegisterSecurityEvent, "password")
+		if err := userUtils.TouchLastActive(user.UserID); err != nil {
+			log.Printf("Error updating last active date for user %d: %v", user.UserID, err)
+		}
+
 		// request the verification server to send a verification email
 		err = userUtils.RequestRegisterVerificationEmail(user.UserID, req.Email, req.Email)
 		if err != nil {
@@ -109,6 +116,7 @@ func LoginHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 		user, err := userUtils.GetUserByEmail(req.Email)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
+				recordSecurityEvent(c, userUtils, 0, req.Email, schema.LoginFailureSecurityEvent, "password")
 				res.ResponseError(c, http.StatusNotFound, types.UserNotFound())
 			} else {
 				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
@@ -131,10 +139,15 @@ func LoginHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 
 		authenticated := userUtils.AuthenticateUser(user, req.Password)
 		if !authenticated {
+			recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.LoginFailureSecurityEvent, "password")
 			res.ResponseError(c, http.StatusBadRequest, types.InvalidCredentials())
 			return
 		}
 
+		if rejectSuspended(c, userUtils, user, "password") {
+			return
+		}
+
 		// set session
 		session := sessions.Default(c)
 		session.Set("userid", user.UserID)
@@ -144,6 +157,7 @@ func LoginHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 			return
 		}
 
+		recordLogin(c, userUtils, user, "password")
 		res.ResponseSuccess(c, http.StatusOK, "login", types.LoginSuccess())
 	}
 }
@@ -242,6 +256,8 @@ func ResetPasswordHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 			return
 		}
 
+		recordSecurityEvent(c, userUtils, user.UserID, user.Email, schema.PasswordResetSecurityEvent, "email")
+
 		// Return success
 		res.ResponseSuccess(c, http.StatusOK, "reset-password", types.Success())
 	}
@@ -292,14 +308,90 @@ func SetUserEmailVerifiedHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 	}
 }
 
+// SetUserSuspendedHandler suspends or reinstates a user on behalf of moderation in another service
+func SetUserSuspendedHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req schema.UserSuspensionRequest
+		if err := c.BindJSON(&req); err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		user, err := userUtils.GetUserByID(req.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.UserNotFound())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+
+		if err := userUtils.SetSuspended(user.UserID, req.Suspended); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		event := schema.SuspendedSecurityEvent
+		if !req.Suspended {
+			event = schema.ReinstatedSecurityEvent
+		}
+		recordSecurityEvent(c, userUtils, user.UserID, user.Email, event, "moderation")
+		if req.Reason != "" {
+			log.Printf("User %d suspended=%t: %s", user.UserID, req.Suspended, req.Reason)
+		}
+
+		res.ResponseSuccess(c, http.StatusOK, "set-user-suspended", types.Success())
+	}
+}
+
+// maxProfileLookup is how many users one profile lookup may ask for
+const maxProfileLookup = 500
+
+// GetUserProfilesHandler looks up the public profiles of several users for another service
+func GetUserProfilesHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req schema.UserProfilesRequest
+		if err := c.BindJSON(&req); err != nil || len(req.UserIDs) > maxProfileLookup {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		users, err := userUtils.GetUsersByIDs(req.UserIDs)
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		profiles := make([]schema.UserProfileResponse, 0, len(users))
+		for _, user := range users {
+			profiles = append(profiles, schema.UserProfileResponse{
+				UserID:          user.UserID,
+				Username:        user.UserName,
+				Class:           user.Class,
+				Major:           user.Major,
+				ReputationScore: user.ReputationScore,
+			})
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get-user-profiles", types.Success(), profiles)
+	}
+}
+
 // Logout handler for session termination
 func LogoutHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
+		userId, loggedIn := session.Get("userid").(uint)
 		session.Clear()
 		err := session.Save()
 		if err != nil {
 			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		if loggedIn {
+			recordSecurityEvent(c, userUtils, userId, "", schema.SessionRevokedSecurityEvent, "logout")
 		}
 
 		res.ResponseSuccess(c, http.StatusOK, "logout", types.Success())
@@ -335,16 +427,19 @@ func GetMeHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 			return
 		}
 
-		responseInfo := types.UserInfoResponse{
-			UserID:        user.UserID,
-			Username:      user.UserName,
-			Email:         user.Email,
-			Class:         user.Class,
-			Major:         user.Major,
-			ProfileImage:  user.ProfileImage,
-			ProfileInfo:   user.ProfileInfo,
-			EmailVerified: user.EmailVerified,
-			MfaVerified:   user.MFAVerified,
+		responseInfo := schema.UserInfoResponse{
+			UserInfoResponse: types.UserInfoResponse{
+				UserID:        user.UserID,
+				Username:      user.UserName,
+				Email:         user.Email,
+				Class:         user.Class,
+				Major:         user.Major,
+				ProfileImage:  user.ProfileImage,
+				ProfileInfo:   user.ProfileInfo,
+				EmailVerified: user.EmailVerified,
+				MfaVerified:   user.MFAVerified,
+			},
+			Role: user.Role,
 		}
 
 		res.ResponseSuccessWithData(c, http.StatusOK, "get user info", types.Success(), responseInfo)
@@ -415,6 +510,8 @@ func DeleteUserHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 			return
 		}
 
+		recordSecurityEvent(c, userUtils, userId, "", schema.AccountDeletedSecurityEvent, "")
+
 		// Return a success response if deletion is successful
 		res.ResponseSuccess(c, http.StatusOK, "User Deleted", types.Success())
 	}
@@ -445,12 +542,26 @@ func VerifiedHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
 			return
 		}
 
+		// every authenticated request from the other services passes through here,
+		// so refresh the last active date, but only every few minutes
+		if time.Since(user.LastActiveDate) > lastActiveRefreshInterval {
+			if err := userUtils.TouchLastActive(user.UserID); err != nil {
+				log.Printf("Error updating last active date for user %d: %v", user.UserID, err)
+			}
+		}
+
 		// Check if the user's email is verified
 		if !user.EmailVerified {
 			res.ResponseError(c, http.StatusBadRequest, types.EmailNotVerified())
 			return
 		}
 
+		// a suspension also ends the sessions the user already has
+		if user.Suspended {
+			res.ResponseError(c, http.StatusForbidden, schema.Forbidden())
+			return
+		}
+
 		// Check if the user is MFA verified
 		if !user.MFAVerified {
 			res.ResponseError(c, http.StatusBadRequest, types.MFANotVerified())
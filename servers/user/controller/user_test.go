@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"user/schema"
+	"user/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifiedHandlerRefreshesLastActive(t *testing.T) {
+	user := schema.User{UserID: 4, EmailVerified: true, MFAVerified: true}
+
+	t.Run("within the refresh interval", func(t *testing.T) {
+		// the mock fails on an unexpected TouchLastActive
+		recent := user
+		recent.LastActiveDate = time.Now().Add(-lastActiveRefreshInterval / 2)
+
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+		userUtils.EXPECT().GetUserByID(uint(4)).Return(recent, nil)
+
+		w := serve(VerifiedHandler(userUtils), 4, http.MethodGet, "/test", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("once the interval has passed", func(t *testing.T) {
+		stale := user
+		stale.LastActiveDate = time.Now().Add(-2 * lastActiveRefreshInterval)
+
+		ctrl := gomock.NewController(t)
+		userUtils := utils.NewMockIUserUtils(ctrl)
+		userUtils.EXPECT().GetUserByID(uint(4)).Return(stale, nil)
+		userUtils.EXPECT().TouchLastActive(uint(4)).Return(nil)
+
+		w := serve(VerifiedHandler(userUtils), 4, http.MethodGet, "/test", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,313 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"user/schema"
+	"user/utils"
+
+	"github.com/GiveGetGo/shared/res"
+	"github.com/GiveGetGo/shared/types"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/gorm"
+)
+
+// ceremonies kept in the session between the begin and finish calls
+const (
+	webAuthnRegistration = "webauthn_registration"
+	webAuthnLogin        = "webauthn_login"
+	webAuthnMFA          = "webauthn_mfa"
+)
+
+// BeginPasskeyRegistrationHandler returns the options for creating a new passkey
+func BeginPasskeyRegistrationHandler(userUtils utils.IUserUtils, w *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webAuthnUser, ok := getWebAuthnUser(c, userUtils)
+		if !ok {
+			return
+		}
+
+		// don't let the same authenticator register twice
+		var exclusions []protocol.CredentialDescriptor
+		for _, credential := range webAuthnUser.WebAuthnCredentials() {
+			exclusions = append(exclusions, credential.Descriptor())
+		}
+
+		creation, sessionData, err := w.BeginRegistration(webAuthnUser, webauthn.WithExclusions(exclusions))
+		if err != nil {
+			log.Printf("Error beginning passkey registration: %v", err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		if err := saveWebAuthnSession(c, webAuthnRegistration, sessionData); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "passkey-register-begin", types.Success(), creation)
+	}
+}
+
+// FinishPasskeyRegistrationHandler verifies the attestation and stores the new passkey
+func FinishPasskeyRegistrationHandler(userUtils utils.IUserUtils, w *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webAuthnUser, ok := getWebAuthnUser(c, userUtils)
+		if !ok {
+			return
+		}
+
+		sessionData, err := loadWebAuthnSession(c, webAuthnRegistration)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidSession())
+			return
+		}
+
+		credential, err := w.FinishRegistration(webAuthnUser, sessionData, c.Request)
+		if err != nil {
+			log.Printf("Error finishing passkey registration: %v", err)
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidVerification())
+			return
+		}
+
+		name := c.DefaultQuery("name", "Passkey")
+		err = userUtils.AddWebAuthnCredential(utils.NewWebAuthnCredential(webAuthnUser.User.UserID, name, credential))
+		if err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		recordSecurityEvent(c, userUtils, webAuthnUser.User.UserID, webAuthnUser.User.Email, schema.PasskeyAddedSecurityEvent, "webauthn")
+		res.ResponseSuccess(c, http.StatusCreated, "passkey-register", types.Success())
+	}
+}
+
+// GetPasskeysHandler lists the caller's passkeys
+func GetPasskeysHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webAuthnUser, ok := getWebAuthnUser(c, userUtils)
+		if !ok {
+			return
+		}
+
+		responseCredentials := []schema.WebAuthnCredentialResponse{}
+		for _, credential := range webAuthnUser.Credentials {
+			responseCredentials = append(responseCredentials, schema.WebAuthnCredentialResponse{
+				CredentialID: credential.CredentialID,
+				Name:         credential.Name,
+				DateCreated:  credential.DateCreated,
+				LastUsedDate: credential.LastUsedDate,
+			})
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "get passkeys", types.Success(), responseCredentials)
+	}
+}
+
+// DeletePasskeyHandler removes one of the caller's passkeys
+func DeletePasskeyHandler(userUtils utils.IUserUtils) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, ok := sessions.Default(c).Get("userid").(uint)
+		if !ok {
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		credentialID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidRequest())
+			return
+		}
+
+		err = userUtils.DeleteWebAuthnCredential(userId, uint(credentialID))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				res.ResponseError(c, http.StatusNotFound, types.RecordNotFound())
+			} else {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			}
+			return
+		}
+
+		recordSecurityEvent(c, userUtils, userId, "", schema.PasskeyRemovedSecurityEvent, "webauthn")
+		res.ResponseSuccess(c, http.StatusOK, "delete passkey", types.Success())
+	}
+}
+
+// BeginPasskeyLoginHandler returns the options for a passwordless login with any discoverable passkey
+func BeginPasskeyLoginHandler(w *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		assertion, sessionData, err := w.BeginDiscoverableLogin()
+		if err != nil {
+			log.Printf("Error beginning passkey login: %v", err)
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		if err := saveWebAuthnSession(c, webAuthnLogin, sessionData); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		res.ResponseSuccessWithData(c, http.StatusOK, "passkey-login-begin", types.Success(), assertion)
+	}
+}
+
+// FinishPasskeyLoginHandler verifies the assertion and logs the passkey's owner in
+func FinishPasskeyLoginHandler(userUtils utils.IUserUtils, w *webauthn.WebAuthn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionData, err := loadWebAuthnSession(c, webAuthnLogin)
+		if err != nil {
+			res.ResponseError(c, http.StatusBadRequest, types.InvalidSession())
+			return
+		}
+
+		// resolve the user from the handle the authenticator returned
+		var webAuthnUser utils.WebAuthnUser
+		credential, err := w.FinishDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			userId, err := utils.UserIDFromWebAuthnHandle(userHandle)
+			if err != nil {
+				return nil, err
+			}
+
+			webAuthnUser, err = loadWebAuthnUser(userUtils, userId)
+			return webAuthnUser, err
+		}, sessionData, c.Request)
+		if err != nil {
+			log.Printf("Error finishing passkey login: %v", err)
+			if webAuthnUser.User.UserID != 0 {
+				recordSecurityEvent(c, userUtils, webAuthnUser.User.UserID, webAuthnUser.User.Email, schema.LoginFailureSecurityEvent, "passkey")
+			}
+			res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+			return
+		}
+
+		if !updateSignCount(c, userUtils, webAuthnUser, credential) {
+			return
+		}
+
+		if !webAuthnUser.User.EmailVerified {
+			res.ResponseError(c, http.StatusBadRequest, types.EmailNotVerified())
+			return
+		}
+
+		if rejectSuspended(c, userUtils, webAuthnUser.User, "passkey") {
+			return
+		}
+
+		// a user verified passkey is already two factors, so it satisfies the MFA gate
+		if credential.Flags.UserVerified && !webAuthnUser.User.MFAVerified {
+			if err := userUtils.MarkMFAVerified(webAuthnUser.User.UserID); err != nil {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				return
+			}
+		}
+
+		session := sessions.Default(c)
+		session.Set("userid", webAuthnUser.User.UserID)
+		if err := session.Save(); err != nil {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+			return
+		}
+
+		recordLogin(c, userUtils, webAuthnUser.User, "passkey")
+		res.ResponseSuccess(c, http.StatusOK, "passkey-login", types.LoginSuccess())
+	}
+}
+
+// getWebAuthnUser loads the session user with their passkeys, writing the error response on failure
+func getWebAuthnUser(c *gin.Context, userUtils utils.IUserUtils) (utils.WebAuthnUser, bool) {
+	userId, ok := sessions.Default(c).Get("userid").(uint)
+	if !ok {
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return utils.WebAuthnUser{}, false
+	}
+
+	webAuthnUser, err := loadWebAuthnUser(userUtils, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res.ResponseError(c, http.StatusNotFound, types.UserNotFound())
+		} else {
+			res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+		}
+		return utils.WebAuthnUser{}, false
+	}
+
+	return webAuthnUser, true
+}
+
+func loadWebAuthnUser(userUtils utils.IUserUtils, userId uint) (utils.WebAuthnUser, error) {
+	user, err := userUtils.GetUserByID(userId)
+	if err != nil {
+		return utils.WebAuthnUser{}, err
+	}
+
+	credentials, err := userUtils.GetWebAuthnCredentials(userId)
+	if err != nil {
+		return utils.WebAuthnUser{}, err
+	}
+
+	return utils.WebAuthnUser{User: user, Credentials: credentials}, nil
+}
+
+// updateSignCount enforces the signature counter check and stores the new counter
+func updateSignCount(c *gin.Context, userUtils utils.IUserUtils, webAuthnUser utils.WebAuthnUser, credential *webauthn.Credential) bool {
+	if err := utils.CheckSignCount(credential); err != nil {
+		log.Printf("Rejected passkey assertion for user %d: %v", webAuthnUser.User.UserID, err)
+		res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+		return false
+	}
+
+	for _, stored := range webAuthnUser.Credentials {
+		if bytes.Equal(stored.RawID, credential.ID) {
+			if err := userUtils.UpdateWebAuthnSignCount(stored.CredentialID, credential.Authenticator.SignCount); err != nil {
+				res.ResponseError(c, http.StatusInternalServerError, types.InternalServerError())
+				return false
+			}
+			return true
+		}
+	}
+
+	res.ResponseError(c, http.StatusUnauthorized, types.InvalidCredentials())
+	return false
+}
+
+// saveWebAuthnSession keeps the ceremony challenge in the session until the finish call
+func saveWebAuthnSession(c *gin.Context, ceremony string, sessionData *webauthn.SessionData) error {
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return err
+	}
+
+	session := sessions.Default(c)
+	session.Set(ceremony, string(data))
+	return session.Save()
+}
+
+// loadWebAuthnSession returns the ceremony challenge and removes it so it can't be replayed
+func loadWebAuthnSession(c *gin.Context, ceremony string) (webauthn.SessionData, error) {
+	session := sessions.Default(c)
+	data, ok := session.Get(ceremony).(string)
+	if !ok {
+		return webauthn.SessionData{}, errors.New("no webauthn ceremony in progress")
+	}
+
+	session.Delete(ceremony)
+	if err := session.Save(); err != nil {
+		return webauthn.SessionData{}, err
+	}
+
+	var sessionData webauthn.SessionData
+	if err := json.Unmarshal([]byte(data), &sessionData); err != nil {
+		return webauthn.SessionData{}, err
+	}
+
+	return sessionData, nil
+}
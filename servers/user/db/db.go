@@ -48,12 +48,30 @@ func ConnectPostgresDB() *gorm.DB {
 // AutoMigratePostgresDB migrates the database schema
 func AutoMigratePostgresDB(db *gorm.DB) error {
 	// Migrate the schema
-	err := db.AutoMigrate(&schema.User{})
+	err := db.AutoMigrate(&schema.User{}, &schema.UserIdentity{}, &schema.WebAuthnCredential{}, &schema.SecurityEvent{})
 	if err != nil {
 		log.Fatalf("Error migrating PostgreSQL schema: %v", err)
 		return err
 	}
 
+	// security events are append-only, reject updates and deletes at the database level
+	err = db.Exec(`CREATE OR REPLACE FUNCTION reject_security_event_change() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'security_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql`).Error
+	if err == nil {
+		err = db.Exec(`DROP TRIGGER IF EXISTS security_events_append_only ON security_events`).Error
+	}
+	if err == nil {
+		err = db.Exec(`CREATE TRIGGER security_events_append_only BEFORE UPDATE OR DELETE ON security_events
+			FOR EACH ROW EXECUTE FUNCTION reject_security_event_change()`).Error
+	}
+	if err != nil {
+		log.Fatalf("Error creating security event trigger: %v", err)
+		return err
+	}
+
 	log.Println("Successfully migrated PostgreSQL schema")
 	return nil
 }
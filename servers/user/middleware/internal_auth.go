@@ -2,23 +2,28 @@ package middleware
 
 import (
 	"os"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 )
 
-// InternalAuthMiddleware - middleware to authenticate internal requests
-func InternalAuthMiddleware() gin.HandlerFunc {
+// InternalAuthMiddleware - middleware to authenticate internal requests from one of the given services.
+// The caller has to name itself in X-Service and send that service's configured <SERVICE>_API_KEY,
+// a service without a configured key is never let in.
+func InternalAuthMiddleware(services ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get which service is calling
 		service := c.GetHeader("X-Service")
 
-		// Construct the environment variable name and retrieve the API key
-		envVarName := service + "_API_KEY"
-		expectedApiKey := os.Getenv(envVarName)
+		// Only the expected services have a key to check against
+		expectedApiKey := ""
+		if slices.Contains(services, service) {
+			expectedApiKey = os.Getenv(service + "_API_KEY")
+		}
 
 		// Check API key
 		apiKey := c.GetHeader("X-Api-Key")
-		if apiKey != expectedApiKey {
+		if expectedApiKey == "" || apiKey != expectedApiKey {
 			c.JSON(403, gin.H{
 				"code":    "40301",
 				"message": "Forbidden - Invalid API Key",
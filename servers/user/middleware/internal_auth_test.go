@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternalAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("POST_API_KEY", "post-key")
+	t.Setenv("MATCH_API_KEY", "match-key")
+
+	r := gin.New()
+	r.PUT("/v1/internal/user/suspension", InternalAuthMiddleware("POST"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name    string
+		service string
+		apiKey  string
+		want    int
+	}{
+		{"expected service with its key", "POST", "post-key", http.StatusOK},
+		{"expected service with a wrong key", "POST", "match-key", http.StatusForbidden},
+		{"expected service without a key", "POST", "", http.StatusForbidden},
+		{"missing service and key", "", "", http.StatusForbidden},
+		// an unset UNKNOWN_API_KEY must not match the missing key
+		{"unknown service", "UNKNOWN", "", http.StatusForbidden},
+		{"configured service that isn't allowed", "MATCH", "match-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/v1/internal/user/suspension", nil)
+			if tt.service != "" {
+				req.Header.Set("X-Service", tt.service)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set("X-Api-Key", tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestInternalAuthMiddlewareWithoutConfiguredKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("POST_API_KEY", "")
+
+	r := gin.New()
+	r.PUT("/v1/internal/user/suspension", InternalAuthMiddleware("POST"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/internal/user/suspension", nil)
+	req.Header.Set("X-Service", "POST")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,20 @@
+package schema
+
+import "github.com/GiveGetGo/shared/types"
+
+// UserInfoResponse is the shared user info with the user's role, so other services can authorize admin and moderator actions
+type UserInfoResponse struct {
+	types.UserInfoResponse
+	Role UserRole `json:"role"`
+}
+
+// 403 - not part of the shared response codes yet
+const ForbiddenCode = "40301"
+
+// func Forbidden() Response
+func Forbidden() types.Response {
+	return types.Response{
+		Code: ForbiddenCode,
+		Msg:  "Forbidden",
+	}
+}
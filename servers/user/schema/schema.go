@@ -4,6 +4,14 @@ import (
 	"time"
 )
 
+type UserRole string
+
+const (
+	MemberRole    UserRole = "member"
+	ModeratorRole UserRole = "moderator"
+	AdminRole     UserRole = "admin"
+)
+
 type User struct {
 	UserID          uint   `gorm:"primaryKey"`
 	UserName        string `gorm:"column:username"`
@@ -19,4 +27,113 @@ type User struct {
 	MFASecret       string
 	DateJoined      time.Time
 	LastActiveDate  time.Time
+	Role            UserRole `gorm:"default:member"`
+	Suspended       bool     `gorm:"default:false"` // set by moderation, a suspended user can't sign in
+}
+
+// UserIdentity links an external SSO (OpenID Connect) identity to a user
+type UserIdentity struct {
+	IdentityID uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"index"`
+	Issuer     string `gorm:"uniqueIndex:idx_identity_issuer_subject"`
+	Subject    string `gorm:"uniqueIndex:idx_identity_issuer_subject"`
+	Email      string
+	DateLinked time.Time
+}
+
+// WebAuthnCredential is a passkey registered by a user
+type WebAuthnCredential struct {
+	CredentialID    uint   `gorm:"primaryKey"`
+	UserID          uint   `gorm:"index"`
+	RawID           []byte `gorm:"uniqueIndex"` // credential ID chosen by the authenticator
+	PublicKey       []byte
+	AttestationType string
+	Transports      string // comma separated authenticator transports
+	AAGUID          []byte
+	SignCount       uint32
+	BackupEligible  bool
+	BackupState     bool
+	Name            string
+	DateCreated     time.Time
+	LastUsedDate    time.Time
+}
+
+type WebAuthnCredentialResponse struct {
+	CredentialID uint      `json:"credentialID"`
+	Name         string    `json:"name"`
+	DateCreated  time.Time `json:"date_created"`
+	LastUsedDate time.Time `json:"last_used_date"`
+}
+
+type SecurityEventType string
+
+const (
+	RegisterSecurityEvent       SecurityEventType = "register"
+	LoginSuccessSecurityEvent   SecurityEventType = "login_success"
+	LoginFailureSecurityEvent   SecurityEventType = "login_failure"
+	MFAEnrolledSecurityEvent    SecurityEventType = "mfa_enrolled"
+	MFAVerifiedSecurityEvent    SecurityEventType = "mfa_verified"
+	MFAFailureSecurityEvent     SecurityEventType = "mfa_failure"
+	PasswordResetSecurityEvent  SecurityEventType = "password_reset"
+	SessionRevokedSecurityEvent SecurityEventType = "session_revoked"
+	AccountDeletedSecurityEvent SecurityEventType = "account_deleted"
+	SSOLinkedSecurityEvent      SecurityEventType = "sso_linked"
+	PasskeyAddedSecurityEvent   SecurityEventType = "passkey_added"
+	PasskeyRemovedSecurityEvent SecurityEventType = "passkey_removed"
+	SuspendedSecurityEvent      SecurityEventType = "account_suspended"
+	ReinstatedSecurityEvent     SecurityEventType = "account_reinstated"
+)
+
+// UserSuspensionRequest - internal request body for suspending or reinstating a user
+type UserSuspensionRequest struct {
+	UserID    uint   `json:"userID" binding:"required"`
+	Suspended bool   `json:"suspended"`
+	Reason    string `json:"reason"`
+}
+
+// UserProfilesRequest - internal request body for looking up several users at once
+type UserProfilesRequest struct {
+	UserIDs []uint `json:"userIDs" binding:"required"`
+}
+
+// UserProfileResponse is the public part of a user's profile another service ranks or displays posts with
+type UserProfileResponse struct {
+	UserID          uint   `json:"userID"`
+	Username        string `json:"username"`
+	Class           string `json:"class"`
+	Major           string `json:"major"`
+	ReputationScore int    `json:"reputation_score"`
+}
+
+// SecurityEvent is an append-only record of something that happened to an account
+type SecurityEvent struct {
+	EventID     uint              `gorm:"primaryKey"`
+	UserID      uint              `gorm:"index"` // 0 when the account could not be resolved, e.g. login with an unknown email
+	Email       string            `gorm:"index"`
+	EventType   SecurityEventType `gorm:"index"`
+	Method      string            // password, sso, passkey, totp, webauthn, moderation
+	IPAddress   string
+	UserAgent   string
+	CreatedDate time.Time `gorm:"index"`
+}
+
+type SecurityEventResponse struct {
+	EventID     uint              `json:"eventID"`
+	UserID      uint              `json:"userID"`
+	Email       string            `json:"email"`
+	EventType   SecurityEventType `json:"event_type"`
+	Method      string            `json:"method"`
+	IPAddress   string            `json:"ip_address"`
+	UserAgent   string            `json:"user_agent"`
+	CreatedDate time.Time         `json:"created_date"`
+}
+
+// SecurityEventFilter narrows down a security event query
+type SecurityEventFilter struct {
+	UserID    uint
+	EventType SecurityEventType
+	Since     time.Time
+	Until     time.Time
+	BeforeID  uint // only events older than this one, for paging
+	Limit     int
 }
@@ -20,6 +20,8 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 	r.Use(sessions.Sessions("givegetgo", store)) // Use sessions with the store
 
 	userUtils := utils.NewUserUtils(DB, redisClient) // Set up user utils
+	oidcUtils := utils.NewOIDCUtilsFromEnv()         // Set up campus SSO
+	webAuthn := utils.NewWebAuthnFromEnv()           // Set up passkeys
 	defaultRateLimiter := middleware.SetupRateLimiter(redisClient, "60-M")
 	sensitiveRateLimiter := middleware.SetupRateLimiter(redisClient, "10-M")
 
@@ -38,6 +40,10 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 		{
 			sensitiveUnAuthGroup.POST("/user/register", controller.RegisterHandler(userUtils))
 			sensitiveUnAuthGroup.POST("/user/login", controller.LoginHandler(userUtils))
+			sensitiveUnAuthGroup.GET("/user/sso/login", controller.SSOLoginHandler(oidcUtils))
+			sensitiveUnAuthGroup.GET("/user/sso/callback", controller.SSOCallbackHandler(userUtils, oidcUtils))
+			sensitiveUnAuthGroup.POST("/user/passkey/login/begin", controller.BeginPasskeyLoginHandler(webAuthn))
+			sensitiveUnAuthGroup.POST("/user/passkey/login/finish", controller.FinishPasskeyLoginHandler(userUtils, webAuthn))
 		}
 	}
 
@@ -53,6 +59,10 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 			userGroup.GET("/me", controller.GetMeHandler(userUtils))
 			userGroup.PUT("/me", controller.EditMeHandler(userUtils))
 			userGroup.DELETE("/me", controller.DeleteUserHandler(userUtils))
+			userGroup.GET("/passkeys", controller.GetPasskeysHandler(userUtils))
+			userGroup.DELETE("/passkeys/:id", controller.DeletePasskeyHandler(userUtils))
+			userGroup.GET("/security-events", controller.GetSecurityEventsHandler(userUtils))
+			userGroup.GET("/admin/security-events", controller.AdminGetSecurityEventsHandler(userUtils))
 		}
 
 		sensitiveUserGroup := userGroup.Group("")
@@ -60,6 +70,9 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 		{
 			sensitiveUserGroup.POST("/forgot-password", controller.ForgotPasswordHandler(userUtils))
 			sensitiveUserGroup.POST("/reset-password", controller.ResetPasswordHandler(userUtils))
+			sensitiveUserGroup.GET("/sso/link", controller.SSOLinkHandler(oidcUtils))
+			sensitiveUserGroup.POST("/passkeys/register/begin", controller.BeginPasskeyRegistrationHandler(userUtils, webAuthn))
+			sensitiveUserGroup.POST("/passkeys/register/finish", controller.FinishPasskeyRegistrationHandler(userUtils, webAuthn))
 		}
 
 		mfaGroup := authGroup.Group("/mfa")
@@ -67,14 +80,17 @@ func NewRouter(DB *gorm.DB, redisClient *redis.Client) *gin.Engine {
 		{
 			mfaGroup.POST("", controller.VerifyMFAHandler(userUtils))
 			mfaGroup.GET("", controller.GetMFAHandler(userUtils))
+			mfaGroup.POST("/webauthn/begin", controller.BeginWebAuthnMFAHandler(userUtils, webAuthn))
+			mfaGroup.POST("/webauthn", controller.VerifyWebAuthnMFAHandler(userUtils, webAuthn))
 		}
 	}
 
-	// Internal routes - with auth middleware
+	// Internal routes - with auth middleware, each only open to the services that call it
 	internalGroup := r.Group("/v1/internal")
-	internalGroup.Use(middleware.InternalAuthMiddleware())
 	{
-		internalGroup.POST("/user/email-verified", controller.SetUserEmailVerifiedHandler(userUtils))
+		internalGroup.POST("/user/email-verified", middleware.InternalAuthMiddleware("VERIFICATION"), controller.SetUserEmailVerifiedHandler(userUtils))
+		internalGroup.PUT("/user/suspension", middleware.InternalAuthMiddleware("POST"), controller.SetUserSuspendedHandler(userUtils))
+		internalGroup.POST("/user/profiles", middleware.InternalAuthMiddleware("POST"), controller.GetUserProfilesHandler(userUtils))
 	}
 
 	return r
@@ -37,6 +37,20 @@ func (m *MockIUserUtils) EXPECT() *MockIUserUtilsMockRecorder {
 	return m.recorder
 }
 
+// AddWebAuthnCredential mocks base method.
+func (m *MockIUserUtils) AddWebAuthnCredential(credential schema.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebAuthnCredential", credential)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWebAuthnCredential indicates an expected call of AddWebAuthnCredential.
+func (mr *MockIUserUtilsMockRecorder) AddWebAuthnCredential(credential interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebAuthnCredential", reflect.TypeOf((*MockIUserUtils)(nil).AddWebAuthnCredential), credential)
+}
+
 // AuthenticateUser mocks base method.
 func (m *MockIUserUtils) AuthenticateUser(user schema.User, password string) bool {
 	m.ctrl.T.Helper()
@@ -65,6 +79,21 @@ func (mr *MockIUserUtilsMockRecorder) CheckEmailVerificationSession(ctx, userID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckEmailVerificationSession", reflect.TypeOf((*MockIUserUtils)(nil).CheckEmailVerificationSession), ctx, userID, event)
 }
 
+// CreateSSOUser mocks base method.
+func (m *MockIUserUtils) CreateSSOUser(username, email string) (schema.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSSOUser", username, email)
+	ret0, _ := ret[0].(schema.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSSOUser indicates an expected call of CreateSSOUser.
+func (mr *MockIUserUtilsMockRecorder) CreateSSOUser(username, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSSOUser", reflect.TypeOf((*MockIUserUtils)(nil).CreateSSOUser), username, email)
+}
+
 // CreateUser mocks base method.
 func (m *MockIUserUtils) CreateUser(username, email, hashedPassword, class, major string) (schema.User, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +123,20 @@ func (mr *MockIUserUtilsMockRecorder) DeleteUser(userID interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockIUserUtils)(nil).DeleteUser), userID)
 }
 
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockIUserUtils) DeleteWebAuthnCredential(userID, credentialID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", userID, credentialID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockIUserUtilsMockRecorder) DeleteWebAuthnCredential(userID, credentialID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockIUserUtils)(nil).DeleteWebAuthnCredential), userID, credentialID)
+}
+
 // GenerateAndSendQRCode mocks base method.
 func (m *MockIUserUtils) GenerateAndSendQRCode(c *gin.Context, email string, secret []byte) {
 	m.ctrl.T.Helper()
@@ -106,6 +149,21 @@ func (mr *MockIUserUtilsMockRecorder) GenerateAndSendQRCode(c, email, secret int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAndSendQRCode", reflect.TypeOf((*MockIUserUtils)(nil).GenerateAndSendQRCode), c, email, secret)
 }
 
+// GetSecurityEvents mocks base method.
+func (m *MockIUserUtils) GetSecurityEvents(filter schema.SecurityEventFilter) ([]schema.SecurityEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecurityEvents", filter)
+	ret0, _ := ret[0].([]schema.SecurityEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecurityEvents indicates an expected call of GetSecurityEvents.
+func (mr *MockIUserUtilsMockRecorder) GetSecurityEvents(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecurityEvents", reflect.TypeOf((*MockIUserUtils)(nil).GetSecurityEvents), filter)
+}
+
 // GetUserByEmail mocks base method.
 func (m *MockIUserUtils) GetUserByEmail(email string) (schema.User, error) {
 	m.ctrl.T.Helper()
@@ -136,6 +194,51 @@ func (mr *MockIUserUtilsMockRecorder) GetUserByID(userID interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockIUserUtils)(nil).GetUserByID), userID)
 }
 
+// GetUserByIdentity mocks base method.
+func (m *MockIUserUtils) GetUserByIdentity(issuer, subject string) (schema.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdentity", issuer, subject)
+	ret0, _ := ret[0].(schema.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByIdentity indicates an expected call of GetUserByIdentity.
+func (mr *MockIUserUtilsMockRecorder) GetUserByIdentity(issuer, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdentity", reflect.TypeOf((*MockIUserUtils)(nil).GetUserByIdentity), issuer, subject)
+}
+
+// GetUsersByIDs mocks base method.
+func (m *MockIUserUtils) GetUsersByIDs(userIDs []uint) ([]schema.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersByIDs", userIDs)
+	ret0, _ := ret[0].([]schema.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersByIDs indicates an expected call of GetUsersByIDs.
+func (mr *MockIUserUtilsMockRecorder) GetUsersByIDs(userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByIDs", reflect.TypeOf((*MockIUserUtils)(nil).GetUsersByIDs), userIDs)
+}
+
+// GetWebAuthnCredentials mocks base method.
+func (m *MockIUserUtils) GetWebAuthnCredentials(userID uint) ([]schema.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredentials", userID)
+	ret0, _ := ret[0].([]schema.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredentials indicates an expected call of GetWebAuthnCredentials.
+func (mr *MockIUserUtilsMockRecorder) GetWebAuthnCredentials(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredentials", reflect.TypeOf((*MockIUserUtils)(nil).GetWebAuthnCredentials), userID)
+}
+
 // HashPassword mocks base method.
 func (m *MockIUserUtils) HashPassword(password string) (string, error) {
 	m.ctrl.T.Helper()
@@ -151,6 +254,20 @@ func (mr *MockIUserUtilsMockRecorder) HashPassword(password interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashPassword", reflect.TypeOf((*MockIUserUtils)(nil).HashPassword), password)
 }
 
+// LinkIdentity mocks base method.
+func (m *MockIUserUtils) LinkIdentity(userID uint, issuer, subject, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkIdentity", userID, issuer, subject, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkIdentity indicates an expected call of LinkIdentity.
+func (mr *MockIUserUtilsMockRecorder) LinkIdentity(userID, issuer, subject, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkIdentity", reflect.TypeOf((*MockIUserUtils)(nil).LinkIdentity), userID, issuer, subject, email)
+}
+
 // MarkEmailVerified mocks base method.
 func (m *MockIUserUtils) MarkEmailVerified(email string) error {
 	m.ctrl.T.Helper()
@@ -179,6 +296,20 @@ func (mr *MockIUserUtilsMockRecorder) MarkMFAVerified(userID interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMFAVerified", reflect.TypeOf((*MockIUserUtils)(nil).MarkMFAVerified), userID)
 }
 
+// RecordSecurityEvent mocks base method.
+func (m *MockIUserUtils) RecordSecurityEvent(event schema.SecurityEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordSecurityEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordSecurityEvent indicates an expected call of RecordSecurityEvent.
+func (mr *MockIUserUtilsMockRecorder) RecordSecurityEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordSecurityEvent", reflect.TypeOf((*MockIUserUtils)(nil).RecordSecurityEvent), event)
+}
+
 // RequestForgetpassVerificationEmail mocks base method.
 func (m *MockIUserUtils) RequestForgetpassVerificationEmail(userID uint, username, email string) error {
 	m.ctrl.T.Helper()
@@ -207,6 +338,20 @@ func (mr *MockIUserUtilsMockRecorder) RequestRegisterVerificationEmail(userID, u
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestRegisterVerificationEmail", reflect.TypeOf((*MockIUserUtils)(nil).RequestRegisterVerificationEmail), userID, username, email)
 }
 
+// SetSuspended mocks base method.
+func (m *MockIUserUtils) SetSuspended(userID uint, suspended bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSuspended", userID, suspended)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSuspended indicates an expected call of SetSuspended.
+func (mr *MockIUserUtilsMockRecorder) SetSuspended(userID, suspended interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSuspended", reflect.TypeOf((*MockIUserUtils)(nil).SetSuspended), userID, suspended)
+}
+
 // StoreEncryptedTOTPSecret mocks base method.
 func (m *MockIUserUtils) StoreEncryptedTOTPSecret(userID uint, encryptedSecret string) error {
 	m.ctrl.T.Helper()
@@ -221,6 +366,20 @@ func (mr *MockIUserUtilsMockRecorder) StoreEncryptedTOTPSecret(userID, encrypted
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreEncryptedTOTPSecret", reflect.TypeOf((*MockIUserUtils)(nil).StoreEncryptedTOTPSecret), userID, encryptedSecret)
 }
 
+// TouchLastActive mocks base method.
+func (m *MockIUserUtils) TouchLastActive(userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchLastActive", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchLastActive indicates an expected call of TouchLastActive.
+func (mr *MockIUserUtilsMockRecorder) TouchLastActive(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchLastActive", reflect.TypeOf((*MockIUserUtils)(nil).TouchLastActive), userID)
+}
+
 // UpdatePassword mocks base method.
 func (m *MockIUserUtils) UpdatePassword(userID uint, hashedPassword string) error {
 	m.ctrl.T.Helper()
@@ -249,6 +408,20 @@ func (mr *MockIUserUtilsMockRecorder) UpdateUser(userID, updates interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockIUserUtils)(nil).UpdateUser), userID, updates)
 }
 
+// UpdateWebAuthnSignCount mocks base method.
+func (m *MockIUserUtils) UpdateWebAuthnSignCount(credentialID uint, signCount uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnSignCount", credentialID, signCount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebAuthnSignCount indicates an expected call of UpdateWebAuthnSignCount.
+func (mr *MockIUserUtilsMockRecorder) UpdateWebAuthnSignCount(credentialID, signCount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnSignCount", reflect.TypeOf((*MockIUserUtils)(nil).UpdateWebAuthnSignCount), credentialID, signCount)
+}
+
 // ValidatePassword mocks base method.
 func (m *MockIUserUtils) ValidatePassword(password string) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// IOIDCUtils is the interface for the OpenID Connect relying party for mocking
+type IOIDCUtils interface {
+	Enabled() bool
+	AuthCodeURL(state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (OIDCClaims, error)
+}
+
+// OIDCClaims are the claims we read from a verified ID token
+type OIDCClaims struct {
+	Issuer        string `json:"iss"`
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified *bool  `json:"email_verified"`
+	Nonce         string `json:"nonce"`
+}
+
+// OIDCUtils is a relying party for the campus SSO (authorization code flow with PKCE)
+type OIDCUtils struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	HTTPClient   *http.Client
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// Ensure OIDCUtils implements IOIDCUtils
+var _ IOIDCUtils = (*OIDCUtils)(nil)
+
+func NewOIDCUtils(issuerURL, clientID, clientSecret, redirectURL string) *OIDCUtils {
+	return &OIDCUtils{
+		IssuerURL:    issuerURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewOIDCUtilsFromEnv creates the SSO relying party from the OIDC_* environment variables
+func NewOIDCUtilsFromEnv() *OIDCUtils {
+	return NewOIDCUtils(
+		os.Getenv("OIDC_ISSUER_URL"),
+		os.Getenv("OIDC_CLIENT_ID"),
+		os.Getenv("OIDC_CLIENT_SECRET"),
+		os.Getenv("OIDC_REDIRECT_URL"),
+	)
+}
+
+// Enabled reports whether SSO has been configured
+func (o *OIDCUtils) Enabled() bool {
+	return o.IssuerURL != "" && o.ClientID != ""
+}
+
+// AuthCodeURL builds the authorization URL the user is redirected to
+func (o *OIDCUtils) AuthCodeURL(state, nonce, verifier string) (string, error) {
+	config, _, err := o.oauth2Config()
+	if err != nil {
+		return "", err
+	}
+
+	return config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange trades the authorization code for tokens and returns the verified ID token claims
+func (o *OIDCUtils) Exchange(ctx context.Context, code, verifier, nonce string) (OIDCClaims, error) {
+	config, provider, err := o.oauth2Config()
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+
+	ctx = oidc.ClientContext(ctx, o.HTTPClient)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return OIDCClaims{}, fmt.Errorf("exchanging authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return OIDCClaims{}, errors.New("token response has no id_token")
+	}
+
+	// the provider's key set is cached and only refetched when an unknown key ID shows up
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: o.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return OIDCClaims{}, fmt.Errorf("verifying id token: %w", err)
+	}
+
+	var claims OIDCClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return OIDCClaims{}, err
+	}
+
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return OIDCClaims{}, errors.New("id token nonce does not match")
+	}
+	if claims.Email == "" {
+		return OIDCClaims{}, errors.New("id token has no email claim")
+	}
+	if claims.EmailVerified != nil && !*claims.EmailVerified {
+		return OIDCClaims{}, errors.New("sso email is not verified")
+	}
+
+	return claims, nil
+}
+
+// oauth2Config discovers the provider once and builds the OAuth2 client config
+func (o *OIDCUtils) oauth2Config() (*oauth2.Config, *oidc.Provider, error) {
+	if !o.Enabled() {
+		return nil, nil, errors.New("sso is not configured")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.provider == nil {
+		// the provider keeps this context for key set refreshes, so don't tie it to the request
+		providerCtx := oidc.ClientContext(context.Background(), o.HTTPClient)
+		provider, err := oidc.NewProvider(providerCtx, o.IssuerURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("discovering sso provider: %w", err)
+		}
+		o.provider = provider
+	}
+
+	return &oauth2.Config{
+		ClientID:     o.ClientID,
+		ClientSecret: o.ClientSecret,
+		RedirectURL:  o.RedirectURL,
+		Endpoint:     o.provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}, o.provider, nil
+}
+
+// GenerateRandomString returns a URL safe random string built from n random bytes
+func GenerateRandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOIDCProvider is a minimal local OpenID provider for exercising the relying party
+type mockOIDCProvider struct {
+	server     *httptest.Server
+	key        *rsa.PrivateKey
+	challenge  string
+	nonce      string
+	claims     map[string]interface{}
+	jwksHits   int
+	tokenCalls int
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &mockOIDCProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.jwksHits++
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test-key", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.tokenCalls++
+		r.ParseForm()
+
+		// PKCE - the verifier must hash to the challenge sent with the authorization request
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     p.signIDToken(t),
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+func (p *mockOIDCProvider) signIDToken(t *testing.T) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: p.key},
+		(&jose.SignerOptions{}).WithHeader("kid", "test-key"),
+	)
+	require.NoError(t, err)
+
+	claims := map[string]interface{}{
+		"iss":            p.server.URL,
+		"sub":            "campus-user-1",
+		"aud":            "givegetgo",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          p.nonce,
+		"email":          "student@purdue.edu",
+		"email_verified": true,
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed, err := signer.Sign(payload)
+	require.NoError(t, err)
+	token, err := signed.CompactSerialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+// authorize mimics the user approving the login at the provider
+func (p *mockOIDCProvider) authorize(t *testing.T, authURL string) {
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	p.challenge = parsed.Query().Get("code_challenge")
+	p.nonce = parsed.Query().Get("nonce")
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	oidcUtils := NewOIDCUtils(provider.server.URL, "givegetgo", "secret", "http://localhost/v1/user/sso/callback")
+
+	authURL, err := oidcUtils.AuthCodeURL("state-123", "nonce-123", "verifier-that-is-long-enough-for-pkce-123456")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, provider.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "state-123", parsed.Query().Get("state"))
+	assert.Equal(t, "nonce-123", parsed.Query().Get("nonce"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.NotEmpty(t, parsed.Query().Get("code_challenge"))
+	assert.Contains(t, parsed.Query().Get("scope"), "openid")
+}
+
+func TestOIDCExchange(t *testing.T) {
+	verifier := "verifier-that-is-long-enough-for-pkce-123456"
+
+	t.Run("valid login", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		oidcUtils := NewOIDCUtils(provider.server.URL, "givegetgo", "secret", "http://localhost/callback")
+
+		authURL, err := oidcUtils.AuthCodeURL("state", "nonce-1", verifier)
+		require.NoError(t, err)
+		provider.authorize(t, authURL)
+
+		claims, err := oidcUtils.Exchange(context.Background(), "code", verifier, "nonce-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "campus-user-1", claims.Subject)
+		assert.Equal(t, provider.server.URL, claims.Issuer)
+		assert.Equal(t, "student@purdue.edu", claims.Email)
+
+		// a second login reuses the cached key set
+		_, err = oidcUtils.Exchange(context.Background(), "code", verifier, "nonce-1")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, provider.jwksHits)
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		oidcUtils := NewOIDCUtils(provider.server.URL, "givegetgo", "secret", "http://localhost/callback")
+
+		authURL, err := oidcUtils.AuthCodeURL("state", "nonce-1", verifier)
+		require.NoError(t, err)
+		provider.authorize(t, authURL)
+
+		_, err = oidcUtils.Exchange(context.Background(), "code", verifier, "another-nonce")
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong pkce verifier", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		oidcUtils := NewOIDCUtils(provider.server.URL, "givegetgo", "secret", "http://localhost/callback")
+
+		authURL, err := oidcUtils.AuthCodeURL("state", "nonce-1", verifier)
+		require.NoError(t, err)
+		provider.authorize(t, authURL)
+
+		_, err = oidcUtils.Exchange(context.Background(), "code", "some-other-verifier-of-enough-length-000000", "nonce-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		provider.claims = map[string]interface{}{"email_verified": false}
+		oidcUtils := NewOIDCUtils(provider.server.URL, "givegetgo", "secret", "http://localhost/callback")
+
+		authURL, err := oidcUtils.AuthCodeURL("state", "nonce-1", verifier)
+		require.NoError(t, err)
+		provider.authorize(t, authURL)
+
+		_, err = oidcUtils.Exchange(context.Background(), "code", verifier, "nonce-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("token for another client", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		provider.claims = map[string]interface{}{"aud": "someone-else"}
+		oidcUtils := NewOIDCUtils(provider.server.URL, "givegetgo", "secret", "http://localhost/callback")
+
+		authURL, err := oidcUtils.AuthCodeURL("state", "nonce-1", verifier)
+		require.NoError(t, err)
+		provider.authorize(t, authURL)
+
+		_, err = oidcUtils.Exchange(context.Background(), "code", verifier, "nonce-1")
+		assert.Error(t, err)
+	})
+}
+
+func TestOIDCDisabled(t *testing.T) {
+	oidcUtils := NewOIDCUtils("", "", "", "")
+	assert.False(t, oidcUtils.Enabled())
+
+	_, err := oidcUtils.AuthCodeURL("state", "nonce", "verifier")
+	assert.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 	"user/db"
 	"user/middleware"
 	"user/schema"
@@ -20,24 +21,40 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/skip2/go-qrcode"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // IUserUtils is the interface for the user utils for mocking
 type IUserUtils interface {
 	// Create
 	CreateUser(username, email, hashedPassword string, class string, major string) (schema.User, error)
+	CreateSSOUser(username, email string) (schema.User, error)
 
 	// Get info
 	GetUserByID(userID uint) (schema.User, error)
+	GetUsersByIDs(userIDs []uint) ([]schema.User, error)
 	GetUserByEmail(email string) (schema.User, error)
+	GetUserByIdentity(issuer, subject string) (schema.User, error)
 
 	// Update
 	UpdateUser(userID uint, updates types.UserUpdateRequest) error
 	UpdatePassword(userID uint, hashedPassword string) error
+	LinkIdentity(userID uint, issuer, subject, email string) error
 
 	// Delete
 	DeleteUser(userID uint) error
 
+	// Passkeys
+	GetWebAuthnCredentials(userID uint) ([]schema.WebAuthnCredential, error)
+	AddWebAuthnCredential(credential schema.WebAuthnCredential) error
+	UpdateWebAuthnSignCount(credentialID uint, signCount uint32) error
+	DeleteWebAuthnCredential(userID uint, credentialID uint) error
+
+	// Security events
+	RecordSecurityEvent(event schema.SecurityEvent) error
+	GetSecurityEvents(filter schema.SecurityEventFilter) ([]schema.SecurityEvent, error)
+	TouchLastActive(userID uint) error
+
 	// Others
 	ValidatePassword(password string) error
 	HashPassword(password string) (string, error)
@@ -46,6 +63,7 @@ type IUserUtils interface {
 	RequestForgetpassVerificationEmail(userID uint, username string, email string) error
 	MarkEmailVerified(email string) error
 	MarkMFAVerified(userID uint) error
+	SetSuspended(userID uint, suspended bool) error
 	StoreEncryptedTOTPSecret(userID uint, encryptedSecret string) error
 	CheckEmailVerificationSession(ctx context.Context, userID uint, event string) error
 	GenerateAndSendQRCode(c *gin.Context, email string, secret []byte)
@@ -76,6 +94,19 @@ func (u *UserUtils) GetUserByID(userID uint) (schema.User, error) {
 	return user, nil
 }
 
+// GetUsersByIDs retrieves the users with the given IDs, IDs without a user are skipped
+func (u *UserUtils) GetUsersByIDs(userIDs []uint) ([]schema.User, error) {
+	users := []schema.User{}
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
+	if err := u.DB.Where("user_id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // GetUserByEmail retrieves a user by email
 func (u *UserUtils) GetUserByEmail(email string) (schema.User, error) {
 	var user schema.User
@@ -107,6 +138,46 @@ func (u *UserUtils) CreateUser(username, email, hashedPassword string, class str
 	return user, nil
 }
 
+// CreateSSOUser creates a user signing up through the campus SSO, the SSO already verified the email
+func (u *UserUtils) CreateSSOUser(username, email string) (schema.User, error) {
+	user := schema.User{
+		UserName:      username,
+		Email:         email,
+		EmailVerified: true,
+		DateJoined:    time.Now(),
+	}
+	err := u.DB.Create(&user).Error
+	if err != nil {
+		return schema.User{}, err
+	}
+
+	return user, nil
+}
+
+// GetUserByIdentity retrieves the user an SSO identity is linked to
+func (u *UserUtils) GetUserByIdentity(issuer, subject string) (schema.User, error) {
+	var identity schema.UserIdentity
+	err := u.DB.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if err != nil {
+		return schema.User{}, err
+	}
+
+	return u.GetUserByID(identity.UserID)
+}
+
+// LinkIdentity links an SSO identity to a user
+func (u *UserUtils) LinkIdentity(userID uint, issuer, subject, email string) error {
+	identity := schema.UserIdentity{
+		UserID:     userID,
+		Issuer:     issuer,
+		Subject:    subject,
+		Email:      email,
+		DateLinked: time.Now(),
+	}
+
+	return u.DB.Create(&identity).Error
+}
+
 // ValidatePassword checks if a password is valid (not empty, and at least 8 characters, includes a number, and includes a special character)
 func (u *UserUtils) ValidatePassword(password string) error {
 	// check if the password is at least 8 characters
@@ -384,6 +455,91 @@ func (u *UserUtils) DeleteUser(userID uint) error {
 	return nil
 }
 
+// GetWebAuthnCredentials retrieves the passkeys registered by a user
+func (u *UserUtils) GetWebAuthnCredentials(userID uint) ([]schema.WebAuthnCredential, error) {
+	var credentials []schema.WebAuthnCredential
+	if err := u.DB.Where("user_id = ?", userID).Order("date_created").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// AddWebAuthnCredential stores a newly registered passkey
+func (u *UserUtils) AddWebAuthnCredential(credential schema.WebAuthnCredential) error {
+	return u.DB.Create(&credential).Error
+}
+
+// UpdateWebAuthnSignCount stores the latest signature counter after a successful assertion
+func (u *UserUtils) UpdateWebAuthnSignCount(credentialID uint, signCount uint32) error {
+	return u.DB.Model(&schema.WebAuthnCredential{}).Where("credential_id = ?", credentialID).Updates(map[string]interface{}{
+		"sign_count":     signCount,
+		"last_used_date": time.Now(),
+	}).Error
+}
+
+// DeleteWebAuthnCredential removes one of the user's passkeys
+func (u *UserUtils) DeleteWebAuthnCredential(userID uint, credentialID uint) error {
+	result := u.DB.Where("user_id = ?", userID).Delete(&schema.WebAuthnCredential{}, credentialID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RecordSecurityEvent appends an event to the security log, events are never updated or deleted
+func (u *UserUtils) RecordSecurityEvent(event schema.SecurityEvent) error {
+	if event.CreatedDate.IsZero() {
+		event.CreatedDate = time.Now()
+	}
+	return u.DB.Create(&event).Error
+}
+
+// GetSecurityEvents retrieves security events newest first
+func (u *UserUtils) GetSecurityEvents(filter schema.SecurityEventFilter) ([]schema.SecurityEvent, error) {
+	query := u.DB.Model(&schema.SecurityEvent{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_date >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_date < ?", filter.Until)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("event_id < ?", filter.BeforeID)
+	}
+
+	var events []schema.SecurityEvent
+	if err := query.Order("event_id desc").Limit(filter.Limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// SetSuspended suspends or reinstates a user
+func (u *UserUtils) SetSuspended(userID uint, suspended bool) error {
+	result := u.DB.Model(&schema.User{}).Where("user_id = ?", userID).Update("suspended", suspended)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchLastActive records that the user was just active
+func (u *UserUtils) TouchLastActive(userID uint) error {
+	return u.DB.Model(&schema.User{}).Where("user_id = ?", userID).Update("last_active_date", time.Now()).Error
+}
+
 func (u *UserUtils) GenerateAndSendQRCode(c *gin.Context, email string, secret []byte) {
 	uri := fmt.Sprintf("otpauth://totp/GiveGetGo:%s?secret=%s&issuer=GiveGetGo", email, string(secret))
 	qrCode, err := qrcode.Encode(uri, qrcode.Medium, 256)
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"user/schema"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// ErrClonedAuthenticator is returned when an assertion's signature counter did not move forward
+var ErrClonedAuthenticator = errors.New("authenticator signature counter did not increase, it may be cloned")
+
+// WebAuthnUser adapts a user and their passkeys to the webauthn.User interface
+type WebAuthnUser struct {
+	User        schema.User
+	Credentials []schema.WebAuthnCredential
+}
+
+// Ensure WebAuthnUser implements webauthn.User
+var _ webauthn.User = WebAuthnUser{}
+
+func (u WebAuthnUser) WebAuthnID() []byte {
+	return WebAuthnUserHandle(u.User.UserID)
+}
+
+func (u WebAuthnUser) WebAuthnName() string {
+	return u.User.Email
+}
+
+func (u WebAuthnUser) WebAuthnDisplayName() string {
+	return u.User.UserName
+}
+
+func (u WebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Credentials))
+	for _, credential := range u.Credentials {
+		credentials = append(credentials, ToWebAuthnCredential(credential))
+	}
+
+	return credentials
+}
+
+// NewWebAuthnFromEnv sets up the passkey relying party from the WEBAUTHN_* environment variables
+func NewWebAuthnFromEnv() *webauthn.WebAuthn {
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		rpID = "localhost"
+	}
+
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_RP_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{"http://localhost:3000"}
+	}
+
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: "GiveGetGo",
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up webauthn: %v", err)
+		return nil
+	}
+
+	return w
+}
+
+// WebAuthnUserHandle is the opaque user handle stored on the authenticator, the big endian user ID
+func WebAuthnUserHandle(userID uint) []byte {
+	handle := make([]byte, 8)
+	binary.BigEndian.PutUint64(handle, uint64(userID))
+	return handle
+}
+
+// UserIDFromWebAuthnHandle reverses WebAuthnUserHandle
+func UserIDFromWebAuthnHandle(handle []byte) (uint, error) {
+	if len(handle) != 8 {
+		return 0, errors.New("invalid webauthn user handle")
+	}
+
+	return uint(binary.BigEndian.Uint64(handle)), nil
+}
+
+// ToWebAuthnCredential converts a stored passkey into the library's credential record
+func ToWebAuthnCredential(credential schema.WebAuthnCredential) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	for _, transport := range strings.Split(credential.Transports, ",") {
+		if transport != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              credential.RawID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    credential.AAGUID,
+			SignCount: credential.SignCount,
+		},
+	}
+}
+
+// NewWebAuthnCredential converts a freshly registered credential into the stored passkey
+func NewWebAuthnCredential(userID uint, name string, credential *webauthn.Credential) schema.WebAuthnCredential {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return schema.WebAuthnCredential{
+		UserID:          userID,
+		RawID:           credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+		DateCreated:     time.Now(),
+	}
+}
+
+// CheckSignCount rejects an assertion whose signature counter went backwards or stood still
+func CheckSignCount(credential *webauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		return ErrClonedAuthenticator
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+	"user/schema"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebAuthnUserHandle(t *testing.T) {
+	handle := WebAuthnUserHandle(42)
+	assert.Len(t, handle, 8)
+
+	userID, err := UserIDFromWebAuthnHandle(handle)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), userID)
+
+	_, err = UserIDFromWebAuthnHandle([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestWebAuthnCredentialConversion(t *testing.T) {
+	registered := &webauthn.Credential{
+		ID:              []byte("raw-id"),
+		PublicKey:       []byte("public-key"),
+		AttestationType: "none",
+		Transport:       []protocol.AuthenticatorTransport{protocol.USB, protocol.Internal},
+		Flags:           webauthn.CredentialFlags{BackupEligible: true},
+		Authenticator:   webauthn.Authenticator{AAGUID: []byte("aaguid"), SignCount: 3},
+	}
+
+	stored := NewWebAuthnCredential(7, "Laptop", registered)
+	assert.Equal(t, uint(7), stored.UserID)
+	assert.Equal(t, "Laptop", stored.Name)
+	assert.Equal(t, "usb,internal", stored.Transports)
+	assert.Equal(t, uint32(3), stored.SignCount)
+
+	stored.CredentialID = 1
+	user := WebAuthnUser{User: schema.User{UserID: 7}, Credentials: []schema.WebAuthnCredential{stored}}
+	credentials := user.WebAuthnCredentials()
+	assert.Len(t, credentials, 1)
+	assert.Equal(t, registered.ID, credentials[0].ID)
+	assert.Equal(t, registered.Transport, credentials[0].Transport)
+	assert.Equal(t, uint32(3), credentials[0].Authenticator.SignCount)
+}
+
+func TestCheckSignCount(t *testing.T) {
+	t.Run("counter moved forward", func(t *testing.T) {
+		credential := &webauthn.Credential{Authenticator: webauthn.Authenticator{SignCount: 3}}
+		credential.Authenticator.UpdateCounter(4)
+		assert.NoError(t, CheckSignCount(credential))
+		assert.Equal(t, uint32(4), credential.Authenticator.SignCount)
+	})
+
+	t.Run("counter replayed", func(t *testing.T) {
+		credential := &webauthn.Credential{Authenticator: webauthn.Authenticator{SignCount: 3}}
+		credential.Authenticator.UpdateCounter(3)
+		assert.ErrorIs(t, CheckSignCount(credential), ErrClonedAuthenticator)
+	})
+
+	t.Run("authenticator without counter", func(t *testing.T) {
+		credential := &webauthn.Credential{}
+		credential.Authenticator.UpdateCounter(0)
+		assert.NoError(t, CheckSignCount(credential))
+	})
+}